@@ -4,15 +4,24 @@
 package check
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cilium/cilium/api/v1/flow"
 	"github.com/cilium/cilium/api/v1/observer"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/cilium/cilium-cli/connectivity/filters"
+	"github.com/cilium/cilium-cli/defaults"
 	"github.com/cilium/cilium-cli/k8s"
 	"github.com/cilium/cilium-cli/sysdump"
 )
@@ -22,14 +31,40 @@ type Parameters struct {
 	CiliumNamespace       string
 	TestNamespace         string
 	SingleNode            bool
+	Minimal               bool
 	PrintFlows            bool
 	ForceDeploy           bool
+	DeleteNamespaceFirst  bool
+	VerifyCleanup         bool
+	K8sClientQPS          float32
+	K8sClientBurst        int
+	StrictLeftovers       bool
+	StrictImages          bool
+	Reconcile             bool
+	PrePullImages         bool
+	NoAutomountSAToken    bool
+	SeccompProfile        string
+	ProbeFromAllClients   bool
+	NodePortAllAddresses  bool
+	ClientNetworks        string
+	ServiceAccount        string
+	NoHostNetNSNetRaw     bool
+	ClientDaemonSet       bool
+	ClientSource          string
+	ClientSelector        string
+	ExpectRoutingMode     string
+	MTUCheck              MTUCheckMode
+	NodeHealthCheck       NodeHealthCheckMode
+	ServiceIPFamily       string
+	WaitCEPAddressing     bool
+	VerboseDeploy         bool
 	Hubble                bool
 	HubbleServer          string
 	MultiCluster          string
 	RunTests              []*regexp.Regexp
 	SkipTests             []*regexp.Regexp
 	PostTestSleepDuration time.Duration
+	LogSince              time.Duration
 	FlowValidation        string
 	AllFlows              bool
 	Writer                io.ReadWriter
@@ -38,26 +73,81 @@ type Parameters struct {
 	Timestamp             bool
 	PauseOnFail           bool
 	SkipIPCacheCheck      bool
+	SkipExternalWorkloads bool
+	IPCacheTimeout        time.Duration
+	IPCacheInterval       time.Duration
 	Perf                  bool
 	PerfDuration          time.Duration
 	PerfCRR               bool
 	PerfHostNet           bool
 	PerfSamples           int
+	PerfGuaranteedQoS     bool
+	StrictPerfZone        bool
+	PerfZoneWeight        int
+	PerfZoneRequired      bool
+	EchoConnectionCounter bool
+	EchoStatusCode        int
+	ProbeCount            int
+	ProbeSuccessRatio     float64
+	PingCount             int
+	PingMaxLoss           float64
+	EchoLBAlgorithm       string
+	EchoLBClass           string
+	ExternalNameService   bool
+	EchoLBService         bool
+	EchoTopologyHints     bool
+	EchoTLS               bool
 	CurlImage             string
 	PerformanceImage      string
 	JSONMockImage         string
+	EchoSameNodeImage     string
+	EchoOtherNodeImage    string
 	AgentDaemonSetName    string
 	DNSTestServerImage    string
+	DNSConfigMap          string
+	DNSConfigMapNamespace string
+	ClientShell           string
+	PerfShell             string
 	Datapath              bool
 	AgentPodSelector      string
 	NodeSelector          map[string]string
+	HostNetNSImages       map[string]string
+	HostNetNSNodes        []string
+	NamespaceLabels       map[string]string
+	NamespaceAnnotations  map[string]string
+	NamespaceRetries      int
+	OwnerReferences       []metav1.OwnerReference
 	ExternalTarget        string
+	ExternalFQDN          string
 	ExternalCIDR          string
 	ExternalIP            string
 	ExternalOtherIP       string
+	ExpectedEgressIP      string
 	ExternalFromCIDRs     []string
 	ExternalFromCIDRMasks []int // Derived from ExternalFromCIDRs
+	ExpectedPodCIDRs      []string
 	JunitFile             string
+	TopologyFile          string
+	CiliumConfigFile      string
+	CreatedResourcesFile  string
+	MetricsFile           string
+
+	EchoPort               int
+	DNSTestServerReadyPort int
+	DNSTestServerReadyPath string
+	ServiceDNSTarget       string
+	ClusterDomain          string
+
+	// DNSTestServerReadyTimeout and DNSTestServerReadyFailures relax the DNS
+	// test server readiness probe on slow nodes, where CoreDNS may not be
+	// ready within the default failure window.
+	DNSTestServerReadyTimeout  time.Duration
+	DNSTestServerReadyFailures int
+
+	// MultiClusterBidirectional additionally deploys a client in the
+	// MultiCluster cluster and makes the echo-same-node service global, so
+	// that the scenarios also run from the remote to the local cluster.
+	MultiClusterBidirectional bool
 
 	K8sVersion           string
 	HelmChartDirectory   string
@@ -71,10 +161,114 @@ type Parameters struct {
 	ConnectTimeout time.Duration
 	RequestTimeout time.Duration
 
+	// CurlHeaders, CurlMethod, CurlPath and CurlUserAgent customize the
+	// requests sent to the echo backends, e.g. to exercise L7 policies.
+	CurlHeaders   []string
+	CurlMethod    string
+	CurlPath      string
+	CurlUserAgent string
+
+	// NetemLatency and NetemLoss degrade the network of the NetemTarget
+	// pods, through a tc netem sidecar running NetemImage, which must ship
+	// tc and a POSIX shell. The connection counter sidecar also runs
+	// NetemImage, which must then ship iptables as well.
+	NetemLatency time.Duration
+	NetemLoss    float64
+	NetemTarget  string
+	NetemImage   string
+
+	DeploymentPollInterval time.Duration
+	DeploymentRolloutGrace time.Duration
+
+	// PodReadinessCondition is a pod condition, e.g. set by a readiness gate,
+	// which must be True as well for the test pods to be considered ready.
+	PodReadinessCondition string
+
+	// ServicePollInterval is doubled after each failed service lookup, up to
+	// ServicePollMaxInterval. ServiceMaxAttempts bounds the number of lookups.
+	ServicePollInterval    time.Duration
+	ServicePollMaxInterval time.Duration
+	ServiceMaxAttempts     int
+
+	// NamespaceDeletePollInterval is doubled after each check that the test
+	// namespace still exists during teardown, up to
+	// NamespaceDeletePollMaxInterval. NamespaceDeleteTimeout bounds the wait.
+	NamespaceDeletePollInterval    time.Duration
+	NamespaceDeletePollMaxInterval time.Duration
+	NamespaceDeleteTimeout         time.Duration
+
+	// SuiteTimeout bounds the deployment and validation of the test workloads.
+	// When it expires, the test workloads are deleted, within
+	// SuiteCleanupTimeout, so that a hung run doesn't hold on to them.
+	SuiteTimeout        time.Duration
+	SuiteCleanupTimeout time.Duration
+
+	// MultiClusterDeployConcurrency is the number of clusters the per-cluster
+	// deployment steps, e.g. creating the namespace or the echo workloads, run
+	// in at once in multi-cluster mode, all of them if unset.
+	// MultiClusterDeployTimeout bounds each of them.
+	MultiClusterDeployConcurrency int
+	MultiClusterDeployTimeout     time.Duration
+
+	// MaxParallelDeployments bounds the create requests issued at once while
+	// deploying, across all clusters, not to trip the API priority and
+	// fairness limits of shared clusters.
+	MaxParallelDeployments int
+
+	// ValidationKubeconfig and ValidationContext select a separate identity,
+	// e.g. bound to read-only RBAC, for the List and Get requests issued while
+	// validating the test deployments. Alternatively, ValidationAs and
+	// ValidationAsGroups impersonate a user and groups for these requests.
+	// Deploying the test resources and running the tests isn't affected.
+	ValidationKubeconfig string
+	ValidationContext    string
+	ValidationAs         string
+	ValidationAsGroups   []string
+
 	CollectSysdumpOnFailure bool
 	SysdumpOptions          sysdump.Options
 }
 
+// MarshalJSON marshals the parameters for e.g. printing the effective
+// configuration: the test filters are rendered as their regular expressions,
+// and the writers and the sysdump client config are omitted.
+func (p Parameters) MarshalJSON() ([]byte, error) {
+	type parameters Parameters
+	regexps := func(res []*regexp.Regexp) []string {
+		s := make([]string, 0, len(res))
+		for _, re := range res {
+			s = append(s, re.String())
+		}
+		return s
+	}
+	// The nil omitempty fields shadow the ones of the embedded structs.
+	type omitted *struct{}
+	type sysdumpOptions struct {
+		sysdump.Options
+		RESTClientGetter omitted `json:",omitempty"`
+		Writer           omitted `json:",omitempty"`
+	}
+	return json.Marshal(struct {
+		parameters
+		RunTests       []string
+		SkipTests      []string
+		Writer         omitted `json:",omitempty"`
+		SysdumpOptions sysdumpOptions
+	}{
+		parameters:     parameters(p),
+		RunTests:       regexps(p.RunTests),
+		SkipTests:      regexps(p.SkipTests),
+		SysdumpOptions: sysdumpOptions{Options: p.SysdumpOptions},
+	})
+}
+
+func (p Parameters) suiteCleanupTimeout() time.Duration {
+	if p.SuiteCleanupTimeout > 0 {
+		return p.SuiteCleanupTimeout
+	}
+	return defaults.ConnectivitySuiteCleanupTimeout
+}
+
 func (p Parameters) ciliumEndpointTimeout() time.Duration {
 	return 5 * time.Minute
 }
@@ -87,10 +281,287 @@ func (p Parameters) serviceReadyTimeout() time.Duration {
 	return 30 * time.Second
 }
 
-func (p Parameters) ipCacheTimeout() time.Duration {
+func (p Parameters) dnsLookupTimeout() time.Duration {
 	return 20 * time.Second
 }
 
+func (p Parameters) ipCacheTimeout() time.Duration {
+	if p.IPCacheTimeout > 0 {
+		return p.IPCacheTimeout
+	}
+	return defaults.IPCacheTimeout
+}
+
+func (p Parameters) ipCacheInterval() time.Duration {
+	if p.IPCacheInterval > 0 {
+		return p.IPCacheInterval
+	}
+	return defaults.IPCacheInterval
+}
+
+func (p Parameters) maxParallelDeployments() int {
+	if p.MaxParallelDeployments > 0 {
+		return p.MaxParallelDeployments
+	}
+	return defaults.ConnectivityMaxParallelDeployments
+}
+
+func (p Parameters) pollInterval() time.Duration {
+	if p.DeploymentPollInterval > 0 {
+		return p.DeploymentPollInterval
+	}
+	return defaults.DeploymentPollInterval
+}
+
+func (p Parameters) servicePollInterval() time.Duration {
+	if p.ServicePollInterval > 0 {
+		return p.ServicePollInterval
+	}
+	return p.pollInterval()
+}
+
+// servicePollBackoff returns the interval to wait after the given number of
+// failed service lookups.
+func (p Parameters) servicePollBackoff(failures int) time.Duration {
+	return pollBackoff(p.servicePollInterval(), p.ServicePollMaxInterval, failures)
+}
+
+// namespaceDeleteBackoff returns the interval to wait after the given number
+// of checks finding the test namespace still present.
+func (p Parameters) namespaceDeleteBackoff(failures int) time.Duration {
+	interval := p.NamespaceDeletePollInterval
+	if interval <= 0 {
+		interval = defaults.NamespaceDeletePollInterval
+	}
+	return pollBackoff(interval, p.NamespaceDeletePollMaxInterval, failures)
+}
+
+// pollBackoff doubles interval after each failure, up to ceiling. A ceiling
+// lower than interval disables the backoff.
+func pollBackoff(interval, ceiling time.Duration, failures int) time.Duration {
+	if ceiling < interval {
+		ceiling = interval
+	}
+	for i := 1; i < failures && interval < ceiling; i++ {
+		interval *= 2
+	}
+	if interval > ceiling {
+		interval = ceiling
+	}
+	return interval
+}
+
+func (p Parameters) clientShell() string {
+	if p.ClientShell != "" {
+		return p.ClientShell
+	}
+	return defaults.ConnectivityClientShell
+}
+
+func (p Parameters) perfZoneWeight() int32 {
+	if p.PerfZoneWeight > 0 {
+		return int32(p.PerfZoneWeight)
+	}
+	return defaults.ConnectivityPerformanceZoneWeight
+}
+
+func (p Parameters) perfShell() string {
+	if p.PerfShell != "" {
+		return p.PerfShell
+	}
+	return defaults.ConnectivityPerformanceShell
+}
+
+func (p Parameters) echoPort() int {
+	if p.EchoPort > 0 {
+		return p.EchoPort
+	}
+	return defaults.ConnectivityEchoPort
+}
+
+// EchoServerPort returns the port the echo servers listen on, which the
+// policy templates allow.
+func (p Parameters) EchoServerPort() int {
+	return p.echoPort()
+}
+
+// echoNamedPort returns the name of the echo container port, which is
+// referenced by the named port policies.
+func (p Parameters) echoNamedPort() string {
+	return fmt.Sprintf("http-%d", p.echoPort())
+}
+
+func (p Parameters) dnsTestServerReadyPort() int {
+	if p.DNSTestServerReadyPort > 0 {
+		return p.DNSTestServerReadyPort
+	}
+	return defaults.ConnectivityDNSTestServerReadyPort
+}
+
+// dnsTestServerReadinessProbe returns the readiness probe of the DNS test
+// server sidecar, with the configured timing.
+func (p Parameters) dnsTestServerReadinessProbe() *corev1.Probe {
+	probe := newLocalReadinessProbe(p.dnsTestServerReadyPort(), p.dnsTestServerReadyPath())
+	if p.DNSTestServerReadyTimeout > 0 {
+		probe.TimeoutSeconds = int32(p.DNSTestServerReadyTimeout.Round(time.Second).Seconds())
+	}
+	if p.DNSTestServerReadyFailures > 0 {
+		probe.FailureThreshold = int32(p.DNSTestServerReadyFailures)
+	}
+	return probe
+}
+
+func (p Parameters) dnsTestServerReadyPath() string {
+	if p.DNSTestServerReadyPath != "" {
+		return p.DNSTestServerReadyPath
+	}
+	return defaults.ConnectivityDNSTestServerReadyPath
+}
+
+func (p Parameters) serviceDNSTarget() string {
+	if p.ServiceDNSTarget != "" {
+		return p.ServiceDNSTarget
+	}
+	return defaults.ConnectivityServiceDNSTarget
+}
+
+func (p Parameters) clusterDomain() string {
+	if p.ClusterDomain != "" {
+		return strings.Trim(p.ClusterDomain, ".")
+	}
+	return defaults.ConnectivityClusterDomain
+}
+
+// netemArgs returns the tc netem options matching the configured latency and
+// packet loss, or an empty string if the network shouldn't be degraded.
+func (p Parameters) netemArgs() string {
+	var args []string
+	if p.NetemLatency > 0 {
+		args = append(args, fmt.Sprintf("delay %dms", p.NetemLatency.Milliseconds()))
+	}
+	if p.NetemLoss > 0 {
+		args = append(args, fmt.Sprintf("loss %s%%", strconv.FormatFloat(p.NetemLoss, 'f', -1, 64)))
+	}
+	return strings.Join(args, " ")
+}
+
+func (p Parameters) netemTarget() string {
+	if p.NetemTarget != "" {
+		return p.NetemTarget
+	}
+	return kindEchoName
+}
+
+func (p Parameters) clientSelector() string {
+	if p.ClientSelector != "" {
+		return p.ClientSelector
+	}
+	return "kind=" + kindClientName
+}
+
+// seccompProfile returns the seccomp profile of the test pods, or nil if none
+// is configured. SeccompProfile is checked by validate.
+func (p Parameters) seccompProfile() *corev1.SeccompProfile {
+	profile, _ := parseSeccompProfile(p.SeccompProfile)
+	return profile
+}
+
+// parseSeccompProfile parses a seccomp profile given as RuntimeDefault,
+// Unconfined or Localhost/<path>, where path is relative to the seccomp
+// profile directory of the kubelet.
+func parseSeccompProfile(s string) (*corev1.SeccompProfile, error) {
+	switch {
+	case s == "":
+		return nil, nil
+	case s == string(corev1.SeccompProfileTypeRuntimeDefault), s == string(corev1.SeccompProfileTypeUnconfined):
+		return &corev1.SeccompProfile{Type: corev1.SeccompProfileType(s)}, nil
+	case strings.HasPrefix(s, string(corev1.SeccompProfileTypeLocalhost)+"/"):
+		path := strings.TrimPrefix(s, string(corev1.SeccompProfileTypeLocalhost)+"/")
+		if path == "" {
+			return nil, fmt.Errorf("missing path of localhost seccomp profile %q", s)
+		}
+		return &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: &path}, nil
+	}
+	return nil, fmt.Errorf("invalid seccomp profile %q, expected RuntimeDefault, Unconfined or Localhost/<path>", s)
+}
+
+// logsSince returns the time from which logs are dumped for a test started at
+// start: the start of the test, or LogSince ago if that is more recent.
+func (p Parameters) logsSince(start, now time.Time) time.Time {
+	if p.LogSince > 0 && now.Add(-p.LogSince).After(start) {
+		return now.Add(-p.LogSince)
+	}
+	return start
+}
+
+func (p Parameters) clientSource() string {
+	if p.ClientSource != "" {
+		return p.ClientSource
+	}
+	return kindClientName
+}
+
+// dnsConfigMapNamespace returns the namespace of the existing DNSConfigMap,
+// the test namespace unless DNSConfigMapNamespace is set.
+func (p Parameters) dnsConfigMapNamespace() string {
+	if p.DNSConfigMapNamespace != "" {
+		return p.DNSConfigMapNamespace
+	}
+	return p.TestNamespace
+}
+
+// dnsConfigMapName returns the name of the configmap holding the Corefile of
+// the DNS test server: the existing DNSConfigMap if it lives in the test
+// namespace, otherwise the one deployed by the tests, which holds a copy of
+// DNSConfigMap if set.
+func (p Parameters) dnsConfigMapName() string {
+	if p.DNSConfigMap != "" && p.dnsConfigMapNamespace() == p.TestNamespace {
+		return p.DNSConfigMap
+	}
+	return corednsConfigMapName
+}
+
+func (p Parameters) echoSameNodeImage() string {
+	if p.EchoSameNodeImage != "" {
+		return p.EchoSameNodeImage
+	}
+	return p.JSONMockImage
+}
+
+func (p Parameters) echoOtherNodeImage() string {
+	if p.EchoOtherNodeImage != "" {
+		return p.EchoOtherNodeImage
+	}
+	return p.JSONMockImage
+}
+
+// echoImage returns the image the echo deployment of the given name runs.
+func (p Parameters) echoImage(name string) string {
+	switch name {
+	case echoSameNodeDeploymentName:
+		return p.echoSameNodeImage()
+	case echoOtherNodeDeploymentName:
+		return p.echoOtherNodeImage()
+	}
+	return p.JSONMockImage
+}
+
+// clientPodAnnotations returns the annotations of the client pods, requesting
+// Multus to attach them to the ClientNetworks if set.
+func (p Parameters) clientPodAnnotations() map[string]string {
+	if p.ClientNetworks == "" {
+		return nil
+	}
+	return map[string]string{multusNetworksAnnotation: p.ClientNetworks}
+}
+
+func (p Parameters) netemImage() string {
+	if p.NetemImage != "" {
+		return p.NetemImage
+	}
+	return defaults.ConnectivityNetemImage
+}
+
 func (p Parameters) validate() error {
 	switch p.FlowValidation {
 	case FlowValidationModeDisabled, FlowValidationModeWarning, FlowValidationModeStrict:
@@ -98,6 +569,215 @@ func (p Parameters) validate() error {
 		return fmt.Errorf("invalid flow validation mode %q", p.FlowValidation)
 	}
 
+	if p.Minimal && (p.Perf || p.MultiCluster != "") {
+		return fmt.Errorf("minimal profile can not be combined with performance or multi-cluster tests")
+	}
+
+	if p.LogSince < 0 {
+		return fmt.Errorf("invalid log duration %s", p.LogSince)
+	}
+
+	if _, err := parseSeccompProfile(p.SeccompProfile); err != nil {
+		return err
+	}
+
+	if p.MultiClusterBidirectional && (p.MultiCluster == "" || p.Perf) {
+		return fmt.Errorf("bidirectional multi-cluster tests require --multi-cluster and can not be combined with performance tests")
+	}
+
+	if p.ClientDaemonSet && p.Perf {
+		return fmt.Errorf("client daemonset can not be combined with performance tests")
+	}
+
+	if p.PerfZoneWeight < 0 || p.PerfZoneWeight > 100 {
+		return fmt.Errorf("invalid perf zone affinity weight %d, must be between 0 and 100", p.PerfZoneWeight)
+	}
+
+	if p.ServiceMaxAttempts < 0 {
+		return fmt.Errorf("invalid service lookup attempts %d", p.ServiceMaxAttempts)
+	}
+
+	if p.MultiClusterDeployConcurrency < 0 || p.MultiClusterDeployTimeout < 0 {
+		return fmt.Errorf("invalid multi-cluster deploy concurrency %d or timeout %s", p.MultiClusterDeployConcurrency, p.MultiClusterDeployTimeout)
+	}
+
+	if p.MaxParallelDeployments < 0 {
+		return fmt.Errorf("invalid maximum parallel deployments %d", p.MaxParallelDeployments)
+	}
+
+	if p.SuiteTimeout < 0 || p.SuiteCleanupTimeout < 0 {
+		return fmt.Errorf("invalid suite timeout %s or cleanup timeout %s", p.SuiteTimeout, p.SuiteCleanupTimeout)
+	}
+
+	if p.NamespaceDeleteTimeout < 0 {
+		return fmt.Errorf("invalid namespace deletion timeout %s", p.NamespaceDeleteTimeout)
+	}
+
+	if p.NetemLoss < 0 || p.NetemLoss > 100 {
+		return fmt.Errorf("invalid netem packet loss %v%%, must be between 0 and 100", p.NetemLoss)
+	}
+	if p.NetemLatency < 0 {
+		return fmt.Errorf("invalid netem latency %s", p.NetemLatency)
+	}
+
+	if p.DNSTestServerReadyPort < 0 || p.DNSTestServerReadyPort > 65535 {
+		return fmt.Errorf("invalid DNS test server ready port %d", p.DNSTestServerReadyPort)
+	}
+	if p.DNSTestServerReadyPath != "" && !strings.HasPrefix(p.DNSTestServerReadyPath, "/") {
+		return fmt.Errorf("invalid DNS test server ready path %q, must start with /", p.DNSTestServerReadyPath)
+	}
+	if p.DNSTestServerReadyTimeout != 0 && p.DNSTestServerReadyTimeout < time.Second {
+		return fmt.Errorf("invalid DNS test server ready timeout %s, must be at least 1s", p.DNSTestServerReadyTimeout)
+	}
+	if p.DNSTestServerReadyFailures < 0 {
+		return fmt.Errorf("invalid DNS test server ready failures %d", p.DNSTestServerReadyFailures)
+	}
+
+	if p.EchoPort < 0 || p.EchoPort > 65535 {
+		return fmt.Errorf("invalid echo port %d", p.EchoPort)
+	}
+
+	if _, err := labels.Parse(p.ClientSelector); err != nil {
+		return fmt.Errorf("invalid client selector %q: %w", p.ClientSelector, err)
+	}
+
+	switch p.ClientSource {
+	case "", kindClientName, kindHostNetNS:
+	default:
+		return fmt.Errorf("invalid client source %q, must be %q or %q", p.ClientSource, kindClientName, kindHostNetNS)
+	}
+
+	switch p.NetemTarget {
+	case "", kindClientName, kindEchoName:
+	default:
+		return fmt.Errorf("invalid netem target %q, must be %q or %q", p.NetemTarget, kindClientName, kindEchoName)
+	}
+
+	switch p.ServiceIPFamily {
+	case "", "ipv4", "ipv6":
+	default:
+		return fmt.Errorf("invalid service IP family %q", p.ServiceIPFamily)
+	}
+
+	switch p.MTUCheck {
+	case "", MTUCheckModeDisabled, MTUCheckModeWarning, MTUCheckModeStrict:
+	default:
+		return fmt.Errorf("invalid MTU check mode %q", p.MTUCheck)
+	}
+
+	switch p.NodeHealthCheck {
+	case "", NodeHealthCheckModeDisabled, NodeHealthCheckModeWarning, NodeHealthCheckModeStrict:
+	default:
+		return fmt.Errorf("invalid node health check mode %q", p.NodeHealthCheck)
+	}
+
+	switch p.ExpectRoutingMode {
+	case "", "native", "tunnel", "vxlan", "geneve":
+	default:
+		return fmt.Errorf("invalid expected routing mode %q", p.ExpectRoutingMode)
+	}
+
+	if p.ExpectedEgressIP != "" && net.ParseIP(p.ExpectedEgressIP) == nil {
+		return fmt.Errorf("invalid expected egress IP %q", p.ExpectedEgressIP)
+	}
+
+	if p.EchoStatusCode != 0 && (p.EchoStatusCode < 100 || p.EchoStatusCode > 599) {
+		return fmt.Errorf("invalid echo status code %d", p.EchoStatusCode)
+	}
+
+	if p.NamespaceRetries < 0 {
+		return fmt.Errorf("invalid number of namespace retries %d", p.NamespaceRetries)
+	}
+
+	if p.K8sClientQPS < 0 || p.K8sClientBurst < 0 {
+		return fmt.Errorf("invalid Kubernetes client rate limit: QPS %v, burst %d", p.K8sClientQPS, p.K8sClientBurst)
+	}
+
+	if len(p.ValidationAsGroups) > 0 && p.ValidationAs == "" {
+		return fmt.Errorf("impersonating validation groups requires a validation user")
+	}
+	if (p.ValidationKubeconfig != "" || p.ValidationContext != "") && p.MultiCluster != "" {
+		return fmt.Errorf("a separate validation kubeconfig or context can not be combined with multi-cluster tests, use impersonation instead")
+	}
+
+	if p.ProbeCount < 0 {
+		return fmt.Errorf("invalid probe count %d", p.ProbeCount)
+	}
+	if p.PingCount < 0 {
+		return fmt.Errorf("invalid ping count %d", p.PingCount)
+	}
+	if p.PingMaxLoss < 0 || p.PingMaxLoss > 100 {
+		return fmt.Errorf("invalid ping maximum loss %v, must be between 0 and 100", p.PingMaxLoss)
+	}
+	if p.ProbeSuccessRatio < 0 || p.ProbeSuccessRatio > 1 {
+		return fmt.Errorf("invalid probe success ratio %v, must be between 0 and 1", p.ProbeSuccessRatio)
+	}
+
+	if p.EchoLBService {
+		if !p.EchoConnectionCounter {
+			return fmt.Errorf("the echo load-balancing service requires the echo connection counter")
+		}
+		if p.SingleNode || p.Minimal || p.MultiCluster != "" {
+			return fmt.Errorf("the echo load-balancing service can not be combined with single-node, minimal or multi-cluster tests")
+		}
+	}
+
+	if p.EchoTopologyHints {
+		if !p.EchoConnectionCounter {
+			return fmt.Errorf("the echo topology-aware hints test requires the echo connection counter")
+		}
+		if p.SingleNode || p.Minimal || p.Perf || p.MultiCluster != "" {
+			return fmt.Errorf("the echo topology-aware hints test can not be combined with single-node, minimal, performance or multi-cluster tests")
+		}
+	}
+
+	for _, header := range p.CurlHeaders {
+		if name, _, ok := strings.Cut(header, ":"); !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("invalid curl header %q, expected \"Name: value\"", header)
+		}
+	}
+
+	if p.CurlPath != "" && !strings.HasPrefix(p.CurlPath, "/") {
+		return fmt.Errorf("invalid curl path %q, must start with /", p.CurlPath)
+	}
+
+	switch p.EchoLBAlgorithm {
+	case "", "maglev", "random":
+	default:
+		return fmt.Errorf("invalid echo service load-balancing algorithm %q", p.EchoLBAlgorithm)
+	}
+
+	for _, cidr := range p.ExpectedPodCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid expected pod CIDR %q: %w", cidr, err)
+		}
+	}
+
+	if p.DNSConfigMap != "" {
+		if errs := validation.IsDNS1123Subdomain(p.DNSConfigMap); len(errs) > 0 {
+			return fmt.Errorf("invalid DNS configmap name %q: %s", p.DNSConfigMap, strings.Join(errs, ", "))
+		}
+		// Deleting the test namespace would delete the configmap along with it.
+		if p.ForceDeploy && p.dnsConfigMapNamespace() == p.TestNamespace {
+			return fmt.Errorf("DNS configmap %s in the test namespace would be deleted by --force-deploy, use --dns-configmap-namespace to copy it from another namespace", p.DNSConfigMap)
+		}
+	}
+	if p.DNSConfigMapNamespace != "" {
+		if p.DNSConfigMap == "" {
+			return fmt.Errorf("a DNS configmap namespace requires a DNS configmap")
+		}
+		if errs := validation.IsDNS1123Label(p.DNSConfigMapNamespace); len(errs) > 0 {
+			return fmt.Errorf("invalid DNS configmap namespace %q: %s", p.DNSConfigMapNamespace, strings.Join(errs, ", "))
+		}
+	}
+
+	if p.EchoLBClass != "" {
+		// The API server only accepts domain-prefixed load balancer classes.
+		if errs := validation.IsQualifiedName(p.EchoLBClass); len(errs) > 0 || !strings.Contains(p.EchoLBClass, "/") {
+			return fmt.Errorf("invalid echo service load balancer class %q, must be a domain-prefixed name, e.g. io.cilium/l2-announcer", p.EchoLBClass)
+		}
+	}
+
 	return nil
 }
 
@@ -215,6 +895,12 @@ type deploymentClients struct {
 	dst *k8s.Client
 }
 
+// validationIdentity returns whether the deployment validation uses a
+// separate identity for its read requests.
+func (p Parameters) validationIdentity() bool {
+	return p.ValidationKubeconfig != "" || p.ValidationContext != "" || p.ValidationAs != ""
+}
+
 func (d *deploymentClients) clients() []*k8s.Client {
 	if d.src != d.dst {
 		return []*k8s.Client{d.src, d.dst}
@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cilium/cilium-cli/defaults"
+	"github.com/cilium/cilium-cli/sysdump"
+)
+
+func TestParametersValidate(t *testing.T) {
+	for name, tt := range map[string]struct {
+		params  Parameters
+		wantErr bool
+	}{
+		"defaults": {},
+
+		"dns test server ready custom":        {params: Parameters{DNSTestServerReadyPort: 8080, DNSTestServerReadyPath: "/health"}},
+		"dns test server ready relative path": {params: Parameters{DNSTestServerReadyPath: "ready"}, wantErr: true},
+		"dns test server ready invalid port":  {params: Parameters{DNSTestServerReadyPort: 70000}, wantErr: true},
+
+		"suite timeouts":                 {params: Parameters{SuiteTimeout: time.Hour, SuiteCleanupTimeout: time.Minute}},
+		"negative suite timeout":         {params: Parameters{SuiteTimeout: -time.Second}, wantErr: true},
+		"negative suite cleanup timeout": {params: Parameters{SuiteCleanupTimeout: -time.Second}, wantErr: true},
+
+		"dns configmap":         {params: Parameters{DNSConfigMap: "my-corefile"}},
+		"invalid dns configmap": {params: Parameters{DNSConfigMap: "My_Corefile"}, wantErr: true},
+
+		"dns configmap namespace":              {params: Parameters{DNSConfigMap: "my-corefile", DNSConfigMapNamespace: "kube-system"}},
+		"dns configmap namespace force deploy": {params: Parameters{DNSConfigMap: "my-corefile", DNSConfigMapNamespace: "kube-system", ForceDeploy: true}},
+		"dns configmap force deploy":           {params: Parameters{DNSConfigMap: "my-corefile", ForceDeploy: true}, wantErr: true},
+		"dns configmap namespace without name": {params: Parameters{DNSConfigMapNamespace: "kube-system"}, wantErr: true},
+		"invalid dns configmap namespace":      {params: Parameters{DNSConfigMap: "my-corefile", DNSConfigMapNamespace: "kube.system"}, wantErr: true},
+
+		"echo lb class":            {params: Parameters{EchoLBClass: "io.cilium/l2-announcer"}},
+		"echo lb class no prefix":  {params: Parameters{EchoLBClass: "l2-announcer"}, wantErr: true},
+		"echo lb class invalid":    {params: Parameters{EchoLBClass: "io.cilium/l2 announcer"}, wantErr: true},
+		"echo lb class empty name": {params: Parameters{EchoLBClass: "io.cilium/"}, wantErr: true},
+
+		"max parallel deployments":          {params: Parameters{MaxParallelDeployments: 1}},
+		"negative max parallel deployments": {params: Parameters{MaxParallelDeployments: -1}, wantErr: true},
+
+		"perf zone weight":          {params: Parameters{PerfZoneWeight: 100}},
+		"negative perf zone weight": {params: Parameters{PerfZoneWeight: -1}, wantErr: true},
+		"perf zone weight too high": {params: Parameters{PerfZoneWeight: 101}, wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := tt.params
+			p.FlowValidation = FlowValidationModeWarning
+			if err := p.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServicePollBackoff(t *testing.T) {
+	for name, tt := range map[string]struct {
+		params Parameters
+		want   []time.Duration
+	}{
+		"default": {
+			params: Parameters{},
+			want:   []time.Duration{time.Second, time.Second, time.Second},
+		},
+		"no ceiling": {
+			params: Parameters{ServicePollInterval: 2 * time.Second},
+			want:   []time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second},
+		},
+		"backoff": {
+			params: Parameters{ServicePollInterval: time.Second, ServicePollMaxInterval: 5 * time.Second},
+			want:   []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for i, want := range tt.want {
+				if got := tt.params.servicePollBackoff(i + 1); got != want {
+					t.Errorf("attempt %d: expected %s, got %s", i+1, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNamespaceDeleteBackoff(t *testing.T) {
+	for name, tt := range map[string]struct {
+		params Parameters
+		want   []time.Duration
+	}{
+		"default": {
+			params: Parameters{},
+			want:   []time.Duration{time.Second, time.Second, time.Second},
+		},
+		"no ceiling": {
+			params: Parameters{NamespaceDeletePollInterval: 500 * time.Millisecond},
+			want:   []time.Duration{500 * time.Millisecond, 500 * time.Millisecond},
+		},
+		"backoff": {
+			params: Parameters{NamespaceDeletePollMaxInterval: 10 * time.Second},
+			want:   []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for i, want := range tt.want {
+				if got := tt.params.namespaceDeleteBackoff(i + 1); got != want {
+					t.Errorf("attempt %d: expected %s, got %s", i+1, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParametersMarshalJSON(t *testing.T) {
+	p := Parameters{
+		TestNamespace:  "cilium-test",
+		RunTests:       []*regexp.Regexp{regexp.MustCompile("^pod-to-")},
+		SkipTests:      []*regexp.Regexp{regexp.MustCompile("world")},
+		Writer:         os.Stdout,
+		SysdumpOptions: sysdump.Options{OutputFileName: "sysdump", Writer: os.Stdout},
+	}
+	out, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("unable to marshal parameters: %s", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("unable to unmarshal parameters: %s", err)
+	}
+	if got["TestNamespace"] != "cilium-test" {
+		t.Errorf("unexpected TestNamespace %v", got["TestNamespace"])
+	}
+	if !reflect.DeepEqual(got["RunTests"], []any{"^pod-to-"}) || !reflect.DeepEqual(got["SkipTests"], []any{"world"}) {
+		t.Errorf("unexpected test filters %v, %v", got["RunTests"], got["SkipTests"])
+	}
+	if _, ok := got["Writer"]; ok {
+		t.Errorf("unexpected Writer in %s", out)
+	}
+	sysdumpOptions := got["SysdumpOptions"].(map[string]any)
+	if _, ok := sysdumpOptions["Writer"]; ok {
+		t.Errorf("unexpected sysdump Writer in %s", out)
+	}
+	if sysdumpOptions["OutputFileName"] != "sysdump" {
+		t.Errorf("unexpected sysdump OutputFileName %v", sysdumpOptions["OutputFileName"])
+	}
+}
+
+func TestDNSTestServerReadinessProbe(t *testing.T) {
+	for name, tt := range map[string]struct {
+		params   Parameters
+		timeout  int32
+		failures int32
+	}{
+		"defaults": {params: Parameters{}, timeout: 2, failures: 3},
+		"relaxed": {
+			params:   Parameters{DNSTestServerReadyTimeout: 5 * time.Second, DNSTestServerReadyFailures: 10},
+			timeout:  5,
+			failures: 10,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			probe := tt.params.dnsTestServerReadinessProbe()
+			if probe.TimeoutSeconds != tt.timeout || probe.FailureThreshold != tt.failures {
+				t.Errorf("expected timeout %ds and %d failures, got %ds and %d failures",
+					tt.timeout, tt.failures, probe.TimeoutSeconds, probe.FailureThreshold)
+			}
+			if probe.HTTPGet.Port.IntValue() != 8181 || probe.HTTPGet.Path != "/ready" {
+				t.Errorf("unexpected probe endpoint %v", probe.HTTPGet)
+			}
+		})
+	}
+}
+
+func TestSuiteCleanupTimeout(t *testing.T) {
+	for name, tt := range map[string]struct {
+		cleanupTimeout time.Duration
+		wantCleanup    time.Duration
+	}{
+		"default": {wantCleanup: defaults.ConnectivitySuiteCleanupTimeout},
+		"custom":  {cleanupTimeout: time.Minute, wantCleanup: time.Minute},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := Parameters{SuiteCleanupTimeout: tt.cleanupTimeout}
+			if got := p.suiteCleanupTimeout(); got != tt.wantCleanup {
+				t.Errorf("expected cleanup timeout %s, got %s", tt.wantCleanup, got)
+			}
+		})
+	}
+}
+
+func TestClientNetworks(t *testing.T) {
+	p := Parameters{ClientNetworks: "macvlan-conf"}
+	dep := newDeployment(deploymentParameters{Name: clientDeploymentName, Kind: kindClientName, Annotations: p.clientPodAnnotations()})
+	if got := dep.Spec.Template.Annotations[multusNetworksAnnotation]; got != "macvlan-conf" {
+		t.Errorf("%s annotation = %q, want %q", multusNetworksAnnotation, got, "macvlan-conf")
+	}
+	if dep.Annotations != nil {
+		t.Errorf("unexpected deployment annotations %v", dep.Annotations)
+	}
+
+	dep = newDeployment(deploymentParameters{Name: clientDeploymentName, Kind: kindClientName, Annotations: Parameters{}.clientPodAnnotations()})
+	if dep.Spec.Template.Annotations != nil {
+		t.Errorf("unexpected pod annotations %v without client networks", dep.Spec.Template.Annotations)
+	}
+}
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cilium/cilium-cli/defaults"
+)
+
+// agentConfig is the configuration of a single Cilium agent as captured by
+// collectCiliumConfig.
+type agentConfig struct {
+	Cluster       string            `json:"cluster"`
+	Pod           string            `json:"pod"`
+	Node          string            `json:"node"`
+	ConfigMap     map[string]string `json:"configMap,omitempty"`
+	RuntimeConfig json.RawMessage   `json:"runtimeConfig,omitempty"`
+}
+
+// collectCiliumConfig writes the Cilium ConfigMap and the runtime
+// configuration of each Cilium agent to the file given by --collect-cilium-config,
+// so that test results can be correlated with the datapath configuration in
+// effect. Agents whose configuration cannot be read are skipped with a warning.
+func (ct *ConnectivityTest) collectCiliumConfig(ctx context.Context) error {
+	if ct.params.CiliumConfigFile == "" {
+		return nil
+	}
+
+	configMaps := make(map[string]map[string]string)
+	names := make([]string, 0, len(ct.ciliumPods))
+	for name := range ct.ciliumPods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	agents := make([]agentConfig, 0, len(names))
+	for _, name := range names {
+		ciliumPod := ct.ciliumPods[name]
+		cluster := ciliumPod.K8sClient.ClusterName()
+		agent := agentConfig{
+			Cluster: cluster,
+			Pod:     ciliumPod.Name(),
+			Node:    ciliumPod.Pod.Spec.NodeName,
+		}
+
+		data, ok := configMaps[cluster]
+		if !ok {
+			cm, err := ciliumPod.K8sClient.GetConfigMap(ctx, ct.params.CiliumNamespace, defaults.ConfigMapName, metav1.GetOptions{})
+			if err != nil {
+				ct.Warnf("[%s] Unable to retrieve ConfigMap %q: %s", cluster, defaults.ConfigMapName, err)
+			} else {
+				data = cm.Data
+			}
+			configMaps[cluster] = data
+		}
+		agent.ConfigMap = data
+
+		stdout, err := ciliumPod.K8sClient.ExecInPod(ctx, ciliumPod.Pod.Namespace, ciliumPod.Pod.Name,
+			defaults.AgentContainerName, []string{"cat", "/var/run/cilium/state/agent-runtime-config.json"})
+		if err != nil {
+			ct.Warnf("[%s] Unable to fetch runtime config of Cilium pod %s: %s", cluster, ciliumPod.Name(), err)
+		} else if json.Valid(stdout.Bytes()) {
+			agent.RuntimeConfig = json.RawMessage(stdout.Bytes())
+		}
+
+		agents = append(agents, agent)
+	}
+
+	f, err := os.Create(ct.params.CiliumConfigFile)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(agents); err != nil {
+		if e := f.Close(); e != nil {
+			return errors.Join(err, e)
+		}
+		return err
+	}
+
+	ct.Infof("Cilium configuration of %d agents written to %s", len(agents), ct.params.CiliumConfigFile)
+	return f.Close()
+}
@@ -11,8 +11,10 @@ import (
 	"net"
 	"net/netip"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/blang/semver/v4"
@@ -30,6 +32,14 @@ import (
 	"github.com/cilium/cilium-cli/k8s"
 )
 
+// setupStep is a deployment or validation step of the connectivity test.
+type setupStep struct {
+	name     string
+	start    time.Time
+	duration time.Duration
+	err      error
+}
+
 // ConnectivityTest is the root context of the connectivity test suite
 // and holds all resources belonging to it. It implements interface
 // ConnectivityTest and is instantiated once at the start of the program,
@@ -51,6 +61,9 @@ type ConnectivityTest struct {
 
 	// Clients for source and destination clusters.
 	clients *deploymentClients
+	// Clients for the read requests of the deployment validation, if a
+	// separate identity is configured for them.
+	readClients *deploymentClients
 
 	ciliumPods        map[string]Pod
 	echoPods          map[string]Pod
@@ -61,10 +74,47 @@ type ConnectivityTest struct {
 	PerfResults       map[PerfTests]PerfResult
 	echoServices      map[string]Service
 	ingressService    map[string]Service
+	extNameServices   map[string]Service
+	echoLBServices    map[string]Service
+	apiServices       map[string]Service
 	externalWorkloads map[string]ExternalWorkload
 
+	// Echo pods spread across zones and the Service with topology-aware
+	// hints fronting them.
+	echoTopologyPods     map[string]Pod
+	echoTopologyServices map[string]Service
+
+	// Service fronting the echo pod serving HTTPS, and the CA which issued
+	// its certificate.
+	echoTLSServices map[string]Service
+	echoTLSCA       []byte
+
+	// createSem bounds the create requests issued at once while deploying.
+	createSem chan struct{}
+
+	// Deployment and validation steps, reported in the junit file.
+	setupSteps []setupStep
+
+	// validationPhases measures the phases of the last deployment validation.
+	validationPhases *phaseTimer
+
 	hostNetNSPodsByNode map[string]Pod
 
+	// Addressing of the CiliumEndpoints of the client and echo pods, by pod name.
+	endpointAddressing map[string]ciliumv2.AddressPairList
+
+	// validationMu protects the pod and service maps as well as the setup
+	// steps, which may be populated concurrently during the validation. The
+	// maps are only read through copies taken by their accessors.
+	validationMu sync.Mutex
+
+	// Resources created on deploy, written to CreatedResourcesFile.
+	createdResources   []createdResource
+	createdResourcesMu sync.Mutex
+
+	// Optional deployments required by the enabled scenarios, computed on deploy.
+	optionalDeployments map[OptionalDeployment]bool
+
 	tests     []*Test
 	testNames map[string]struct{}
 
@@ -72,6 +122,8 @@ type ConnectivityTest struct {
 
 	nodes              map[string]*corev1.Node
 	nodesWithoutCilium []string
+	// externalNodes are the nodes not running Cilium, keyed by name.
+	externalNodes map[string]*corev1.Node
 
 	manifests      map[string]string
 	helmYAMLValues string
@@ -178,24 +230,32 @@ func NewConnectivityTest(client *k8s.Client, p Parameters, version string) (*Con
 	}
 
 	k := &ConnectivityTest{
-		client:              client,
-		params:              p,
-		version:             version,
-		ciliumPods:          make(map[string]Pod),
-		echoPods:            make(map[string]Pod),
-		echoExternalPods:    make(map[string]Pod),
-		clientPods:          make(map[string]Pod),
-		perfClientPods:      make(map[string]Pod),
-		perfServerPod:       make(map[string]Pod),
-		PerfResults:         make(map[PerfTests]PerfResult),
-		echoServices:        make(map[string]Service),
-		ingressService:      make(map[string]Service),
-		externalWorkloads:   make(map[string]ExternalWorkload),
-		hostNetNSPodsByNode: make(map[string]Pod),
-		nodes:               make(map[string]*corev1.Node),
-		tests:               []*Test{},
-		testNames:           make(map[string]struct{}),
-		lastFlowTimestamps:  make(map[string]time.Time),
+		client:               client,
+		params:               p,
+		createSem:            make(chan struct{}, p.maxParallelDeployments()),
+		version:              version,
+		ciliumPods:           make(map[string]Pod),
+		echoPods:             make(map[string]Pod),
+		echoExternalPods:     make(map[string]Pod),
+		clientPods:           make(map[string]Pod),
+		perfClientPods:       make(map[string]Pod),
+		perfServerPod:        make(map[string]Pod),
+		PerfResults:          make(map[PerfTests]PerfResult),
+		echoServices:         make(map[string]Service),
+		ingressService:       make(map[string]Service),
+		extNameServices:      make(map[string]Service),
+		echoLBServices:       make(map[string]Service),
+		echoTopologyPods:     make(map[string]Pod),
+		echoTopologyServices: make(map[string]Service),
+		echoTLSServices:      make(map[string]Service),
+		apiServices:          make(map[string]Service),
+		externalWorkloads:    make(map[string]ExternalWorkload),
+		hostNetNSPodsByNode:  make(map[string]Pod),
+		nodes:                make(map[string]*corev1.Node),
+		externalNodes:        make(map[string]*corev1.Node),
+		tests:                []*Test{},
+		testNames:            make(map[string]struct{}),
+		lastFlowTimestamps:   make(map[string]time.Time),
 	}
 
 	return k, nil
@@ -243,6 +303,16 @@ func (ct *ConnectivityTest) NewTest(name string) *Test {
 // such as the client pods and validates the deployment of them along with
 // Cilium. This must be run before Run() is called.
 func (ct *ConnectivityTest) SetupAndValidate(ctx context.Context) error {
+	if err := ct.Setup(ctx); err != nil {
+		return err
+	}
+	return ct.DeployAndValidate(ctx)
+}
+
+// Setup detects the Cilium installation and its features. Tests registered
+// after Setup and before DeployAndValidate are taken into account to only
+// deploy the workloads required by their scenarios.
+func (ct *ConnectivityTest) Setup(ctx context.Context) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
@@ -263,6 +333,15 @@ func (ct *ConnectivityTest) SetupAndValidate(ctx context.Context) error {
 	if err := ct.getNodes(ctx); err != nil {
 		return err
 	}
+	if err := ct.checkMTU(ctx); err != nil {
+		return err
+	}
+	if err := ct.checkNodeHealth(ctx); err != nil {
+		return err
+	}
+	if err := ct.collectCiliumConfig(ctx); err != nil {
+		return fmt.Errorf("writing Cilium configuration to %s failed: %w", ct.params.CiliumConfigFile, err)
+	}
 
 	if ct.debug() {
 		fs := make([]Feature, 0, len(ct.features))
@@ -280,22 +359,59 @@ func (ct *ConnectivityTest) SetupAndValidate(ctx context.Context) error {
 		ct.Info("Monitor aggregation detected, will skip some flow validation steps")
 	}
 
+	if ct.features.MatchRequirements(RequireFeatureEnabled(FeatureNodeWithoutCilium)) {
+		if err := ct.validateExternalFromCIDRsWithNodesWithoutCilium(); err != nil {
+			return fmt.Errorf("invalid configuration for nodes without Cilium: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeployAndValidate deploys the test workloads and validates them. This must
+// be run after Setup() and before Run() is called. If the SuiteTimeout expires
+// in the meantime, the test workloads are deleted.
+func (ct *ConnectivityTest) DeployAndValidate(ctx context.Context) error {
+	if ct.params.SuiteTimeout == 0 {
+		return ct.deployAndValidate(ctx)
+	}
+
+	suiteCtx, cancel := context.WithTimeout(ctx, ct.params.SuiteTimeout)
+	defer cancel()
+	err := ct.deployAndValidate(suiteCtx)
+	if err == nil || ctx.Err() != nil || !errors.Is(suiteCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	ct.Warnf("Deploying and validating the test workloads took longer than the suite timeout of %s, deleting them...", ct.params.SuiteTimeout)
+	cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), ct.params.suiteCleanupTimeout())
+	defer cleanupCancel()
+	for _, client := range ct.clients.clients() {
+		if cleanupErr := ct.deleteDeployments(cleanupCtx, client); cleanupErr != nil {
+			ct.Warnf("[%s] Unable to delete the test workloads: %s", client.ClusterName(), cleanupErr)
+		}
+	}
+	return fmt.Errorf("suite timeout of %s exceeded: %w", ct.params.SuiteTimeout, err)
+}
+
+func (ct *ConnectivityTest) deployAndValidate(ctx context.Context) error {
+	defer ct.writeSetupMetrics()
+
 	if err := ct.deploy(ctx); err != nil {
+		ct.writeSetupJunit()
 		return err
 	}
 	if err := ct.validateDeployment(ctx); err != nil {
+		ct.writeSetupJunit()
 		return err
 	}
+	if err := ct.writeTopology(); err != nil {
+		return fmt.Errorf("writing topology to %s failed: %w", ct.params.TopologyFile, err)
+	}
 	if ct.params.Hubble {
 		if err := ct.enableHubbleClient(ctx); err != nil {
 			return fmt.Errorf("unable to create hubble client: %s", err)
 		}
 	}
-	if ct.features.MatchRequirements(RequireFeatureEnabled(FeatureNodeWithoutCilium)) {
-		if err := ct.validateExternalFromCIDRsWithNodesWithoutCilium(); err != nil {
-			return fmt.Errorf("invalid configuration for nodes without Cilium: %w", err)
-		}
-	}
 	return nil
 }
 
@@ -358,7 +474,7 @@ func (ct *ConnectivityTest) Run(ctx context.Context) error {
 		<-done
 	}
 
-	if err := ct.writeJunit(); err != nil {
+	if err := ct.writeJunit(ct.junitSetupSuite(), ct.junitTestSuite()); err != nil {
 		ct.Failf("writing to junit file %s failed: %s", ct.Params().JunitFile, err)
 	}
 
@@ -373,11 +489,122 @@ func (ct *ConnectivityTest) skip(t *Test) {
 	t.skipped = true
 }
 
-func (ct *ConnectivityTest) writeJunit() error {
-	if ct.Params().JunitFile == "" {
+// writeSetupJunit writes the junit file with only the setup steps, for when
+// the tests don't get to run.
+func (ct *ConnectivityTest) writeSetupJunit() {
+	if err := ct.writeJunit(ct.junitSetupSuite()); err != nil {
+		ct.Failf("writing to junit file %s failed: %s", ct.Params().JunitFile, err)
+	}
+}
+
+// recordSetupStep records the outcome of a deployment or validation step which
+// started at the given time, to be reported in the junit file.
+func (ct *ConnectivityTest) recordSetupStep(name string, start time.Time, err error) {
+	ct.validationMu.Lock()
+	defer ct.validationMu.Unlock()
+
+	ct.setupSteps = append(ct.setupSteps, setupStep{
+		name:     name,
+		start:    start,
+		duration: time.Since(start),
+		err:      err,
+	})
+}
+
+// addPod stores the given pod under name in one of the pod maps.
+func (ct *ConnectivityTest) addPod(pods map[string]Pod, name string, pod Pod) {
+	ct.validationMu.Lock()
+	defer ct.validationMu.Unlock()
+
+	pods[name] = pod
+}
+
+// addService stores the given service under name in one of the service maps.
+// If replace is false, an already stored service is kept.
+func (ct *ConnectivityTest) addService(services map[string]Service, name string, svc Service, replace bool) {
+	ct.validationMu.Lock()
+	defer ct.validationMu.Unlock()
+
+	if _, exists := services[name]; exists && !replace {
+		return
+	}
+	services[name] = svc
+}
+
+// setEchoTLSCA stores the PEM encoded CA certificate of the echo-tls Service.
+func (ct *ConnectivityTest) setEchoTLSCA(ca []byte) {
+	ct.validationMu.Lock()
+	defer ct.validationMu.Unlock()
+
+	ct.echoTLSCA = ca
+}
+
+// setValidationPhases stores the phases measured by the last deployment
+// validation.
+func (ct *ConnectivityTest) setValidationPhases(timer *phaseTimer) {
+	ct.validationMu.Lock()
+	defer ct.validationMu.Unlock()
+
+	ct.validationPhases = timer
+}
+
+// addEndpointAddressing stores the addressing of the CiliumEndpoint of pod.
+func (ct *ConnectivityTest) addEndpointAddressing(pod Pod, cep *ciliumv2.CiliumEndpoint) {
+	ct.validationMu.Lock()
+	defer ct.validationMu.Unlock()
+
+	if ct.endpointAddressing == nil {
+		ct.endpointAddressing = make(map[string]ciliumv2.AddressPairList)
+	}
+	ct.endpointAddressing[pod.Name()] = endpointAddressing(cep)
+}
+
+// EndpointAddressing returns the addressing reported by the CiliumEndpoint of
+// the given client or echo pod when the deployment was validated, or nil if
+// it isn't known.
+func (ct *ConnectivityTest) EndpointAddressing(pod Pod) ciliumv2.AddressPairList {
+	ct.validationMu.Lock()
+	defer ct.validationMu.Unlock()
+
+	return ct.endpointAddressing[pod.Name()]
+}
+
+// junitSetupSuite returns the recorded deployment and validation steps as a
+// junit TestSuite, or nil if no step was recorded.
+func (ct *ConnectivityTest) junitSetupSuite() *junit.TestSuite {
+	if len(ct.setupSteps) == 0 {
 		return nil
 	}
 
+	suite := &junit.TestSuite{
+		Name:      "connectivity setup",
+		Package:   "cilium",
+		Tests:     len(ct.setupSteps),
+		Timestamp: ct.setupSteps[0].start.Format("2006-01-02T15:04:05"),
+	}
+
+	for _, s := range ct.setupSteps {
+		test := &junit.TestCase{
+			Name:      s.name,
+			Classname: "connectivity setup",
+			Status:    "passed",
+			Time:      s.duration.Seconds(),
+		}
+		suite.Time += test.Time
+
+		if s.err != nil {
+			test.Status = "failed"
+			test.Failure = &junit.Failure{Message: s.name + " failed", Type: "failure", Value: s.err.Error()}
+			suite.Failures++
+		}
+
+		suite.TestCases = append(suite.TestCases, test)
+	}
+
+	return suite
+}
+
+func (ct *ConnectivityTest) junitTestSuite() *junit.TestSuite {
 	suite := &junit.TestSuite{
 		Name:    "connectivity test",
 		Package: "cilium",
@@ -422,12 +649,26 @@ func (ct *ConnectivityTest) writeJunit() error {
 		suite.TestCases = append(suite.TestCases, test)
 	}
 
-	suites := junit.TestSuites{
-		Tests:      suite.Tests,
-		Disabled:   suite.Skipped,
-		Failures:   suite.Failures,
-		Time:       suite.Time,
-		TestSuites: []*junit.TestSuite{suite},
+	return suite
+}
+
+// writeJunit writes the given test suites to the junit file, if any. Nil
+// suites are ignored.
+func (ct *ConnectivityTest) writeJunit(testSuites ...*junit.TestSuite) error {
+	if ct.Params().JunitFile == "" {
+		return nil
+	}
+
+	suites := junit.TestSuites{}
+	for _, suite := range testSuites {
+		if suite == nil {
+			continue
+		}
+		suites.Tests += suite.Tests
+		suites.Disabled += suite.Skipped
+		suites.Failures += suite.Failures
+		suites.Time += suite.Time
+		suites.TestSuites = append(suites.TestSuites, suite)
 	}
 
 	f, err := os.Create(ct.Params().JunitFile)
@@ -546,6 +787,16 @@ func (ct *ConnectivityTest) validateExternalFromCIDRsWithNodesWithoutCilium() er
 // has multiple nodes, and whether or not monitor aggregation is enabled.
 // TODO(timo): Split this up, it does a lot.
 func (ct *ConnectivityTest) initClients(ctx context.Context) error {
+	// Deploying and validating the test resources issues many requests in a
+	// row, raise the client-side rate limit so that they aren't throttled.
+	if ct.params.K8sClientQPS != 0 || ct.params.K8sClientBurst != 0 {
+		client, err := ct.client.WithRateLimit(ct.params.K8sClientQPS, ct.params.K8sClientBurst)
+		if err != nil {
+			return fmt.Errorf("unable to create rate-limited Kubernetes client: %w", err)
+		}
+		ct.client = client
+	}
+
 	c := &deploymentClients{
 		src: ct.client,
 		dst: ct.client,
@@ -598,6 +849,12 @@ func (ct *ConnectivityTest) initClients(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("unable to create Kubernetes client for remote cluster %q: %w", ct.params.MultiCluster, err)
 		}
+		if ct.params.K8sClientQPS != 0 || ct.params.K8sClientBurst != 0 {
+			dst, err = dst.WithRateLimit(ct.params.K8sClientQPS, ct.params.K8sClientBurst)
+			if err != nil {
+				return fmt.Errorf("unable to create rate-limited Kubernetes client for remote cluster %q: %w", ct.params.MultiCluster, err)
+			}
+		}
 
 		c.dst = dst
 
@@ -605,9 +862,75 @@ func (ct *ConnectivityTest) initClients(ctx context.Context) error {
 
 	ct.clients = c
 
+	if ct.params.validationIdentity() {
+		readClients, err := ct.newValidationClients(c)
+		if err != nil {
+			return err
+		}
+		ct.readClients = readClients
+	}
+
 	return nil
 }
 
+// newValidationClients returns the clients used for the read requests of the
+// deployment validation, counterparts of the given clients under the
+// configured validation identity.
+func (ct *ConnectivityTest) newValidationClients(c *deploymentClients) (*deploymentClients, error) {
+	readClient := func(client *k8s.Client) (*k8s.Client, error) {
+		var err error
+		if ct.params.ValidationKubeconfig != "" || ct.params.ValidationContext != "" {
+			client, err = k8s.NewClient(ct.params.ValidationContext, ct.params.ValidationKubeconfig)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create Kubernetes client for validation: %w", err)
+			}
+			if ct.params.K8sClientQPS != 0 || ct.params.K8sClientBurst != 0 {
+				client, err = client.WithRateLimit(ct.params.K8sClientQPS, ct.params.K8sClientBurst)
+				if err != nil {
+					return nil, fmt.Errorf("unable to create rate-limited Kubernetes client for validation: %w", err)
+				}
+			}
+		}
+		if ct.params.ValidationAs != "" {
+			client, err = client.WithImpersonation(ct.params.ValidationAs, ct.params.ValidationAsGroups)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create Kubernetes client impersonating %q for validation: %w", ct.params.ValidationAs, err)
+			}
+		}
+		return client, nil
+	}
+
+	src, err := readClient(c.src)
+	if err != nil {
+		return nil, err
+	}
+	r := &deploymentClients{src: src, dst: src}
+	if c.dst != c.src {
+		if r.dst, err = readClient(c.dst); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// readClient returns the client for the List and Get requests of the
+// deployment validation against the cluster of the given client. This is the
+// client itself unless a separate validation identity is configured. Pods
+// keep referencing the given client, as executing commands in them isn't a
+// read request.
+func (ct *ConnectivityTest) readClient(client *k8s.Client) *k8s.Client {
+	if ct.readClients == nil || ct.clients == nil {
+		return client
+	}
+	switch client {
+	case ct.clients.src:
+		return ct.readClients.src
+	case ct.clients.dst:
+		return ct.readClients.dst
+	}
+	return client
+}
+
 // initCiliumPods fetches the Cilium agent pod information from all clients
 func (ct *ConnectivityTest) initCiliumPods(ctx context.Context) error {
 	for _, client := range ct.clients.clients() {
@@ -637,6 +960,8 @@ func (ct *ConnectivityTest) getNodes(ctx context.Context) error {
 		node := node
 		if canNodeRunCilium(&node) {
 			ct.nodes[node.ObjectMeta.Name] = node.DeepCopy()
+		} else {
+			ct.externalNodes[node.ObjectMeta.Name] = node.DeepCopy()
 		}
 	}
 
@@ -666,7 +991,9 @@ func (ct *ConnectivityTest) UninstallResources(ctx context.Context, wait bool) {
 	ct.client.DeletePodCollection(ctx, ct.params.TestNamespace, metav1.DeleteOptions{}, metav1.ListOptions{})
 
 	ct.Logf("🔥 Deleting %s namespace...", ct.params.TestNamespace)
-	ct.client.DeleteNamespace(ctx, ct.params.TestNamespace, metav1.DeleteOptions{})
+	if err := ct.deleteNamespace(ctx, ct.client); err != nil {
+		ct.Warnf("Unable to delete %s namespace: %s", ct.params.TestNamespace, err)
+	}
 
 	// To avoid cases where test pods are stuck in terminating state because
 	// cni (cilium) pods were deleted sooner, wait until test pods are deleted
@@ -700,11 +1027,27 @@ func (ct *ConnectivityTest) CurlCommand(peer TestPeer, ipFam IPFamily, opts ...s
 		cmd = append(cmd, "--max-time", strconv.FormatFloat(requestTimeout, 'f', -1, 64))
 	}
 
+	path := peer.Path()
+	if peer.HasLabel("kind", kindEchoName) {
+		if ct.params.CurlMethod != "" {
+			cmd = append(cmd, "--request", ct.params.CurlMethod)
+		}
+		if ct.params.CurlUserAgent != "" {
+			cmd = append(cmd, "--user-agent", ct.params.CurlUserAgent)
+		}
+		for _, header := range ct.params.CurlHeaders {
+			cmd = append(cmd, "--header", header)
+		}
+		if ct.params.CurlPath != "" {
+			path = ct.params.CurlPath
+		}
+	}
+
 	cmd = append(cmd, opts...)
 	cmd = append(cmd, fmt.Sprintf("%s://%s%s",
 		peer.Scheme(),
 		net.JoinHostPort(peer.Address(ipFam), fmt.Sprint(peer.Port())),
-		peer.Path()))
+		path))
 	return cmd
 }
 
@@ -726,8 +1069,69 @@ func (ct *ConnectivityTest) CurlClientIPCommand(peer TestPeer, ipFam IPFamily, o
 	return cmd
 }
 
+// CurlStatusCommand returns the curl command printing the HTTP status code of
+// the response of peer. Unlike CurlCommand, it succeeds regardless of the
+// status code.
+func (ct *ConnectivityTest) CurlStatusCommand(peer TestPeer, ipFam IPFamily, opts ...string) []string {
+	cmd := []string{"curl", "--silent", "--show-error", "--output", "/dev/null", "--write-out", "%{http_code}"}
+
+	if connectTimeout := ct.params.ConnectTimeout.Seconds(); connectTimeout > 0.0 {
+		cmd = append(cmd, "--connect-timeout", strconv.FormatFloat(connectTimeout, 'f', -1, 64))
+	}
+	if requestTimeout := ct.params.RequestTimeout.Seconds(); requestTimeout > 0.0 {
+		cmd = append(cmd, "--max-time", strconv.FormatFloat(requestTimeout, 'f', -1, 64))
+	}
+
+	cmd = append(cmd, opts...)
+	cmd = append(cmd, fmt.Sprintf("%s://%s%s",
+		peer.Scheme(),
+		net.JoinHostPort(peer.Address(ipFam), fmt.Sprint(peer.Port())),
+		peer.Path()))
+	return cmd
+}
+
+// ClientShellCommand returns the command running the given script with the
+// shell of the client pods.
+func (ct *ConnectivityTest) ClientShellCommand(script string) []string {
+	return []string{ct.params.clientShell(), "-c", script}
+}
+
+// ServiceFQDN returns the fully qualified domain name of the given Service in
+// the configured cluster domain.
+func (ct *ConnectivityTest) ServiceFQDN(svc Service) string {
+	return fmt.Sprintf("%s.%s.svc.%s", svc.Service.Name, svc.Service.Namespace, ct.params.clusterDomain())
+}
+
+// CurlServiceClusterIP curls the given Service from the client pod through its
+// ClusterIP, bypassing DNS resolution, and returns the curl output.
+func (ct *ConnectivityTest) CurlServiceClusterIP(ctx context.Context, client Pod, svc Service) (string, error) {
+	clusterIP := svc.Service.Spec.ClusterIP
+	if clusterIP == "" || clusterIP == corev1.ClusterIPNone {
+		return "", fmt.Errorf("service %s has no ClusterIP", svc.Name())
+	}
+
+	peer := HTTPEndpoint(svc.Name(), fmt.Sprintf("%s://%s%s",
+		svc.Scheme(),
+		net.JoinHostPort(clusterIP, fmt.Sprint(svc.Port())),
+		svc.Path()))
+	stdout, err := client.K8sClient.ExecInPod(ctx, client.Pod.Namespace, client.Pod.Name,
+		"", ct.CurlCommand(peer, IPFamilyAny))
+	if err != nil {
+		return stdout.String(), fmt.Errorf("unable to curl service %s via ClusterIP %s from pod %s: %w",
+			svc.Name(), clusterIP, client.Name(), err)
+	}
+
+	return stdout.String(), nil
+}
+
 func (ct *ConnectivityTest) PingCommand(peer TestPeer, ipFam IPFamily) []string {
-	cmd := []string{"ping", "-c", "1"}
+	return ct.PingCountCommand(peer, ipFam, 1)
+}
+
+// PingCountCommand returns the command sending count ICMP echo requests to the
+// peer, one per second. The request timeout is extended accordingly.
+func (ct *ConnectivityTest) PingCountCommand(peer TestPeer, ipFam IPFamily, count int) []string {
+	cmd := []string{"ping", "-c", strconv.Itoa(count)}
 
 	if ipFam == IPFamilyV6 {
 		cmd = append(cmd, "-6")
@@ -737,15 +1141,27 @@ func (ct *ConnectivityTest) PingCommand(peer TestPeer, ipFam IPFamily) []string
 		cmd = append(cmd, "-W", strconv.FormatFloat(connectTimeout, 'f', -1, 64))
 	}
 	if requestTimeout := ct.params.RequestTimeout.Seconds(); requestTimeout > 0.0 {
-		cmd = append(cmd, "-w", strconv.FormatFloat(requestTimeout, 'f', -1, 64))
+		cmd = append(cmd, "-w", strconv.FormatFloat(requestTimeout+float64(count-1), 'f', -1, 64))
 	}
 
 	cmd = append(cmd, peer.Address(ipFam))
 	return cmd
 }
 
+var pingLossRegexp = regexp.MustCompile(`([0-9.]+)% packet loss`)
+
+// ParsePingLoss returns the percentage of lost packets reported in the
+// summary of the ping output.
+func ParsePingLoss(output string) (float64, error) {
+	m := pingLossRegexp.FindStringSubmatch(output)
+	if m == nil {
+		return 0, fmt.Errorf("no packet loss found in ping output %q", output)
+	}
+	return strconv.ParseFloat(m[1], 64)
+}
+
 func (ct *ConnectivityTest) RandomClientPod() *Pod {
-	for _, p := range ct.clientPods {
+	for _, p := range ct.ClientPods() {
 		return &p
 	}
 	return nil
@@ -763,36 +1179,108 @@ func (ct *ConnectivityTest) Nodes() map[string]*corev1.Node {
 	return ct.nodes
 }
 
+// lockedCopy returns a copy of one of the pod or service maps, taken under
+// validationMu, so that it can be iterated while the validation populates the
+// maps concurrently.
+func lockedCopy[V Pod | Service](mu *sync.Mutex, m map[string]V) map[string]V {
+	mu.Lock()
+	defer mu.Unlock()
+
+	c := make(map[string]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// ClientPods returns a copy of the client pods.
 func (ct *ConnectivityTest) ClientPods() map[string]Pod {
-	return ct.clientPods
+	return lockedCopy(&ct.validationMu, ct.clientPods)
+}
+
+// SourcePods returns the pods which scenarios supporting --client-source send
+// their requests from: either the client pods, or the host network namespace
+// pods indexed by node name.
+func (ct *ConnectivityTest) SourcePods() map[string]Pod {
+	if ct.params.clientSource() == kindHostNetNS {
+		return ct.HostNetNSPodsByNode()
+	}
+	return ct.ClientPods()
 }
 
+// HostNetNSPodsByNode returns a copy of the host network namespace pods,
+// indexed by the name of the node they are running on.
 func (ct *ConnectivityTest) HostNetNSPodsByNode() map[string]Pod {
-	return ct.hostNetNSPodsByNode
+	return lockedCopy(&ct.validationMu, ct.hostNetNSPodsByNode)
+}
+
+// HostNetNSPodOnNode returns the host network namespace pod running on the
+// given node, if any.
+func (ct *ConnectivityTest) HostNetNSPodOnNode(node string) (Pod, bool) {
+	ct.validationMu.Lock()
+	defer ct.validationMu.Unlock()
+
+	pod, ok := ct.hostNetNSPodsByNode[node]
+	return pod, ok
 }
 
 func (ct *ConnectivityTest) PerfServerPod() map[string]Pod {
-	return ct.perfServerPod
+	return lockedCopy(&ct.validationMu, ct.perfServerPod)
 }
 
 func (ct *ConnectivityTest) PerfClientPods() map[string]Pod {
-	return ct.perfClientPods
+	return lockedCopy(&ct.validationMu, ct.perfClientPods)
 }
 
 func (ct *ConnectivityTest) EchoPods() map[string]Pod {
-	return ct.echoPods
+	return lockedCopy(&ct.validationMu, ct.echoPods)
 }
 
 func (ct *ConnectivityTest) EchoServices() map[string]Service {
-	return ct.echoServices
+	return lockedCopy(&ct.validationMu, ct.echoServices)
 }
 
 func (ct *ConnectivityTest) ExternalEchoPods() map[string]Pod {
-	return ct.echoExternalPods
+	return lockedCopy(&ct.validationMu, ct.echoExternalPods)
 }
 
 func (ct *ConnectivityTest) IngressService() map[string]Service {
-	return ct.ingressService
+	return lockedCopy(&ct.validationMu, ct.ingressService)
+}
+
+func (ct *ConnectivityTest) ExternalNameServices() map[string]Service {
+	return lockedCopy(&ct.validationMu, ct.extNameServices)
+}
+
+func (ct *ConnectivityTest) EchoLBServices() map[string]Service {
+	return lockedCopy(&ct.validationMu, ct.echoLBServices)
+}
+
+func (ct *ConnectivityTest) EchoTopologyPods() map[string]Pod {
+	return lockedCopy(&ct.validationMu, ct.echoTopologyPods)
+}
+
+func (ct *ConnectivityTest) EchoTopologyServices() map[string]Service {
+	return lockedCopy(&ct.validationMu, ct.echoTopologyServices)
+}
+
+func (ct *ConnectivityTest) EchoTLSServices() map[string]Service {
+	return lockedCopy(&ct.validationMu, ct.echoTLSServices)
+}
+
+// EchoTLSCA returns the PEM encoded CA certificate which issued the
+// certificate of the echo-tls Service.
+func (ct *ConnectivityTest) EchoTLSCA() []byte {
+	ct.validationMu.Lock()
+	defer ct.validationMu.Unlock()
+
+	return ct.echoTLSCA
+}
+
+// KubernetesAPIServices returns the kubernetes Service fronting the API server
+// of the source cluster.
+func (ct *ConnectivityTest) KubernetesAPIServices() map[string]Service {
+	return lockedCopy(&ct.validationMu, ct.apiServices)
 }
 
 func (ct *ConnectivityTest) ExternalWorkloads() map[string]ExternalWorkload {
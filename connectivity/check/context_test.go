@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/cilium/cilium-cli/defaults"
+	"github.com/cilium/cilium-cli/k8s"
+)
+
+func TestAddPodConcurrent(t *testing.T) {
+	ct := &ConnectivityTest{clientPods: make(map[string]Pod)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("client-%d", i)
+			ct.addPod(ct.clientPods, name, Pod{})
+			ct.recordSetupStep(name, time.Now(), nil)
+		}(i)
+	}
+	wg.Wait()
+
+	if len(ct.clientPods) != 50 {
+		t.Errorf("expected 50 client pods, got %d", len(ct.clientPods))
+	}
+	if len(ct.setupSteps) != 50 {
+		t.Errorf("expected 50 setup steps, got %d", len(ct.setupSteps))
+	}
+}
+
+func TestAddService(t *testing.T) {
+	first := Service{Service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: "src"}}}
+	second := Service{Service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: "dst"}}}
+
+	for name, tt := range map[string]struct {
+		replace bool
+		want    Service
+	}{
+		"replace": {replace: true, want: second},
+		"keep":    {replace: false, want: first},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{}
+			services := map[string]Service{}
+			ct.addService(services, "echo", first, tt.replace)
+			ct.addService(services, "echo", second, tt.replace)
+			if got := services["echo"]; got.Name() != tt.want.Name() {
+				t.Errorf("expected %s, got %s", tt.want.Name(), got.Name())
+			}
+		})
+	}
+}
+
+func TestConcurrentPodMaps(t *testing.T) {
+	ct := &ConnectivityTest{clientPods: make(map[string]Pod), echoServices: make(map[string]Service)}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("pod-%d", i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			ct.addPod(ct.clientPods, name, Pod{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}})
+			ct.addService(ct.echoServices, name, Service{}, false)
+		}()
+		go func() {
+			defer wg.Done()
+			for range ct.ClientPods() {
+			}
+			for range ct.EchoServices() {
+			}
+			_ = ct.RandomClientPod()
+		}()
+	}
+	wg.Wait()
+	if got := len(ct.ClientPods()); got != 10 {
+		t.Errorf("expected 10 client pods, got %d", got)
+	}
+}
+
+func TestReadClient(t *testing.T) {
+	src, dst := &k8s.Client{}, &k8s.Client{}
+	readSrc, readDst := &k8s.Client{}, &k8s.Client{}
+	other := &k8s.Client{}
+
+	ct := &ConnectivityTest{clients: &deploymentClients{src: src, dst: dst}}
+	if got := ct.readClient(src); got != src {
+		t.Errorf("without a validation identity, expected the client itself")
+	}
+
+	ct.readClients = &deploymentClients{src: readSrc, dst: readDst}
+	for name, tt := range map[string]struct {
+		client *k8s.Client
+		want   *k8s.Client
+	}{
+		"source":      {client: src, want: readSrc},
+		"destination": {client: dst, want: readDst},
+		"other":       {client: other, want: other},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := ct.readClient(tt.client); got != tt.want {
+				t.Errorf("unexpected read client %p, want %p", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewValidationClients(t *testing.T) {
+	client, err := (&k8s.Client{Config: &rest.Config{Host: "https://127.0.0.1:6443"}}).WithRateLimit(0, 0)
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	ct := &ConnectivityTest{params: Parameters{ValidationAs: "reader", ValidationAsGroups: []string{"viewers"}}}
+
+	r, err := ct.newValidationClients(&deploymentClients{src: client, dst: client})
+	if err != nil {
+		t.Fatalf("newValidationClients() error = %s", err)
+	}
+	if r.src == client || r.src != r.dst {
+		t.Errorf("expected a single impersonating client for a single cluster")
+	}
+	if want := (rest.ImpersonationConfig{UserName: "reader", Groups: []string{"viewers"}}); !reflect.DeepEqual(r.src.Config.Impersonate, want) {
+		t.Errorf("Impersonate = %+v, want %+v", r.src.Config.Impersonate, want)
+	}
+	if client.Config.Impersonate.UserName != "" {
+		t.Errorf("the deployment client must not impersonate")
+	}
+}
+
+func TestClientShellCommand(t *testing.T) {
+	for name, tt := range map[string]struct {
+		shell string
+		want  []string
+	}{
+		"default": {want: []string{defaults.ConnectivityClientShell, "-c", "echo"}},
+		"custom":  {shell: "/bin/bash", want: []string{"/bin/bash", "-c", "echo"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{params: Parameters{ClientShell: tt.shell}}
+			if got := ct.ClientShellCommand("echo"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCurlStatusCommand(t *testing.T) {
+	ct := &ConnectivityTest{params: Parameters{ConnectTimeout: 2 * time.Second, RequestTimeout: 10 * time.Second}}
+	peer := HTTPEndpoint("echo-status", "http://[fd00::1]:8081/")
+	want := []string{"curl", "--silent", "--show-error", "--output", "/dev/null", "--write-out", "%{http_code}",
+		"--connect-timeout", "2", "--max-time", "10", "http://[fd00::1]:8081/"}
+	if got := ct.CurlStatusCommand(peer, IPFamilyAny); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPingCountCommand(t *testing.T) {
+	ct := &ConnectivityTest{params: Parameters{ConnectTimeout: 2 * time.Second, RequestTimeout: 10 * time.Second}}
+	peer := ICMPEndpoint("peer", "10.0.0.1")
+	if got, want := ct.PingCommand(peer, IPFamilyV4), []string{"ping", "-c", "1", "-W", "2", "-w", "10", "10.0.0.1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got, want := ct.PingCountCommand(peer, IPFamilyV4, 5), []string{"ping", "-c", "5", "-W", "2", "-w", "14", "10.0.0.1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParsePingLoss(t *testing.T) {
+	for name, tt := range map[string]struct {
+		output  string
+		want    float64
+		wantErr bool
+	}{
+		"busybox": {
+			output: "--- 10.0.0.1 ping statistics ---\n10 packets transmitted, 9 packets received, 10% packet loss\n",
+			want:   10,
+		},
+		"iputils": {
+			output: "--- 10.0.0.1 ping statistics ---\n3 packets transmitted, 2 received, 33.3333% packet loss, time 2003ms\n",
+			want:   33.3333,
+		},
+		"no summary": {
+			output:  "ping: bad address '10.0.0.1'\n",
+			wantErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParsePingLoss(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v%% loss, got %v%%", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEndpointAddressing(t *testing.T) {
+	pod := Pod{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "cilium-test", Name: "client"}}}
+
+	for name, tt := range map[string]struct {
+		networking *ciliumv2.EndpointNetworking
+		want       int
+	}{
+		"no networking": {networking: nil, want: 0},
+		"empty pair":    {networking: &ciliumv2.EndpointNetworking{Addressing: ciliumv2.AddressPairList{{}}}, want: 0},
+		"addressed": {networking: &ciliumv2.EndpointNetworking{Addressing: ciliumv2.AddressPairList{
+			{IPV4: "10.0.0.1"}, {IPV6: "fd00::1"},
+		}}, want: 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{}
+			ct.addEndpointAddressing(pod, &ciliumv2.CiliumEndpoint{Status: ciliumv2.EndpointStatus{Networking: tt.networking}})
+			if got := ct.EndpointAddressing(pod); len(got) != tt.want {
+				t.Errorf("expected %d address pairs, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -4,20 +4,34 @@
 package check
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/cilium/cilium/api/v1/models"
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"golang.org/x/exp/slices"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
 
 	"github.com/cilium/cilium-cli/defaults"
 	"github.com/cilium/cilium-cli/k8s"
@@ -32,9 +46,20 @@ const (
 
 	clientDeploymentName  = "client"
 	client2DeploymentName = "client2"
+	clientDaemonSetName   = "client-ds"
 
 	DNSTestServerContainerName = "dns-test-server"
 
+	ConnectionCounterContainerName = "connection-counter"
+	NetemContainerName             = "netem"
+	connectionCounterChain         = "CONNECTION_COUNTER"
+
+	// EchoStatusPort is the port of the echo status server, which answers
+	// all requests with EchoStatusCode.
+	EchoStatusPort                = 8082
+	EchoStatusServerContainerName = "status-server"
+	echoStatusPortName            = "http-status"
+
 	echoSameNodeDeploymentName     = "echo-same-node"
 	echoOtherNodeDeploymentName    = "echo-other-node"
 	echoExternalNodeDeploymentName = "echo-external-node"
@@ -42,14 +67,42 @@ const (
 	corednsConfigVolumeName        = "coredns-config-volume"
 	kindEchoName                   = "echo"
 	kindEchoExternalNodeName       = "echo-external-node"
+	echoExternalNodeHostPort       = 8080
 	kindClientName                 = "client"
 	kindPerfName                   = "perf"
+	kindExternalNameService        = "external-name"
+	externalNameServiceName        = "external-name-service"
+	kindEchoLBName                 = "echo-lb"
+	echoLBServiceName              = "echo-lb"
+	kindEchoTopologyName           = "echo-topology"
+	echoTopologyDeploymentName     = "echo-topology"
+	kindEchoTLSName                = "echo-tls"
+	echoTLSDeploymentName          = "echo-tls"
+	echoTLSProxyContainerName      = "tls-proxy"
+	echoTLSPortName                = "https"
+	echoTLSPort                    = 8443
+
+	// multusNetworksAnnotation requests Multus to attach a pod to additional
+	// networks, which it reports in multusNetworkStatusAnnotation.
+	multusNetworksAnnotation      = "k8s.v1.cni.cncf.io/networks"
+	multusNetworkStatusAnnotation = "k8s.v1.cni.cncf.io/network-status"
 
 	hostNetNSDeploymentName = "host-netns"
 	kindHostNetNS           = "host-netns"
 
+	imagePrePullDaemonSetName = "image-pre-pull"
+	kindImagePrePull          = "image-pre-pull"
+
 	EchoServerHostPort = 40000
 
+	// kubernetesServiceName is the Service fronting the API server in the
+	// default namespace.
+	kubernetesServiceName = "kubernetes"
+
+	// lbAlgorithmAnnotation selects the Cilium load-balancing algorithm
+	// used for the backends of a service.
+	lbAlgorithmAnnotation = "service.cilium.io/lb-algorithm"
+
 	IngressServiceName         = "ingress-service"
 	ingressServiceInsecurePort = "31000"
 	ingressServiceSecurePort   = "31001"
@@ -105,6 +158,10 @@ type deploymentParameters struct {
 	Labels         map[string]string
 	HostNetwork    bool
 	Tolerations    []corev1.Toleration
+	Resources      corev1.ResourceRequirements
+	DisableSAToken bool
+	SeccompProfile *corev1.SeccompProfile
+	Annotations    map[string]string
 }
 
 func newDeployment(p deploymentParameters) *appsv1.Deployment {
@@ -147,6 +204,7 @@ func newDeployment(p deploymentParameters) *appsv1.Deployment {
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Command:         p.Command,
 							ReadinessProbe:  p.ReadinessProbe,
+							Resources:       p.Resources,
 							SecurityContext: &corev1.SecurityContext{
 								Capabilities: &corev1.Capabilities{
 									Add: []corev1.Capability{"NET_RAW"},
@@ -175,10 +233,26 @@ func newDeployment(p deploymentParameters) *appsv1.Deployment {
 		dep.Spec.Template.ObjectMeta.Labels[k] = v
 	}
 
+	if len(p.Annotations) > 0 {
+		dep.Spec.Template.ObjectMeta.Annotations = make(map[string]string, len(p.Annotations))
+		for k, v := range p.Annotations {
+			dep.Spec.Template.ObjectMeta.Annotations[k] = v
+		}
+	}
+
+	if p.DisableSAToken {
+		automount := false
+		dep.Spec.Template.Spec.AutomountServiceAccountToken = &automount
+	}
+
+	if p.SeccompProfile != nil {
+		dep.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{SeccompProfile: p.SeccompProfile}
+	}
+
 	return dep
 }
 
-func newDeploymentWithDNSTestServer(p deploymentParameters, DNSTestServerImage string) *appsv1.Deployment {
+func newDeploymentWithDNSTestServer(p deploymentParameters, DNSTestServerImage, configMapName string, readinessProbe *corev1.Probe) *appsv1.Deployment {
 	dep := newDeployment(p)
 
 	dep.Spec.Template.Spec.Containers = append(
@@ -192,7 +266,7 @@ func newDeploymentWithDNSTestServer(p deploymentParameters, DNSTestServerImage s
 			},
 			Image:           DNSTestServerImage,
 			ImagePullPolicy: corev1.PullIfNotPresent,
-			ReadinessProbe:  newLocalReadinessProbe(8181, "/ready"),
+			ReadinessProbe:  readinessProbe,
 			VolumeMounts: []corev1.VolumeMount{
 				{
 					Name:      corednsConfigVolumeName,
@@ -208,7 +282,7 @@ func newDeploymentWithDNSTestServer(p deploymentParameters, DNSTestServerImage s
 			VolumeSource: corev1.VolumeSource{
 				ConfigMap: &corev1.ConfigMapVolumeSource{
 					LocalObjectReference: corev1.LocalObjectReference{
-						Name: corednsConfigMapName,
+						Name: configMapName,
 					},
 					Items: []corev1.KeyToPath{
 						{
@@ -224,6 +298,125 @@ func newDeploymentWithDNSTestServer(p deploymentParameters, DNSTestServerImage s
 	return dep
 }
 
+// connectionCounterScript counts the TCP connections opened to the given port
+// of the pod, for IPv4 and IPv6, with an iptables rule matching their SYN
+// packets and jumping to an empty chain. The rules are only added once, so
+// that the counters survive restarts of the sidecar.
+const connectionCounterScript = `for iptables in iptables ip6tables; do
+	$iptables -w -N %[1]s 2>/dev/null
+	$iptables -w -C INPUT -p tcp --syn --dport %[2]d -j %[1]s 2>/dev/null ||
+		$iptables -w -I INPUT -p tcp --syn --dport %[2]d -j %[1]s || [ $iptables = ip6tables ] || exit 1
+done
+exec sleep 10000000`
+
+// withConnectionCounter adds a sidecar to the deployment which counts the
+// connections opened to the given port of the pod, read with
+// EchoConnectionCount.
+func withConnectionCounter(dep *appsv1.Deployment, image string, port int) *appsv1.Deployment {
+	dep.Spec.Template.Spec.Containers = append(
+		dep.Spec.Template.Spec.Containers,
+		corev1.Container{
+			Name:            ConnectionCounterContainerName,
+			Image:           image,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{"/bin/sh", "-c", fmt.Sprintf(connectionCounterScript, connectionCounterChain, port)},
+			SecurityContext: &corev1.SecurityContext{
+				Capabilities: &corev1.Capabilities{
+					Add: []corev1.Capability{"NET_ADMIN"},
+				},
+			},
+		},
+	)
+
+	return dep
+}
+
+// connectionCount returns the number of connections counted by the rules
+// jumping to connectionCounterChain in the given output of iptables -L -n -v
+// -x, summed over all of them.
+func connectionCount(output string) (uint64, error) {
+	var count uint64
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[2] != connectionCounterChain {
+			continue
+		}
+		packets, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse packet count %q: %w", fields[0], err)
+		}
+		count += packets
+		found = true
+	}
+	if !found {
+		return 0, fmt.Errorf("no rule jumping to %s found", connectionCounterChain)
+	}
+	return count, nil
+}
+
+// statusServerScript is an HTTP server, run by the Node.js runtime of the
+// json-mock image, which answers each request with an empty response of the
+// given HTTP status code once the request has been read.
+const statusServerScript = `require("http").createServer((req, res) => {
+	req.resume();
+	req.on("end", () => {
+		res.writeHead(%d, {"Content-Length": "0"});
+		res.end();
+	});
+}).listen(%d);`
+
+// withStatusServer adds a sidecar running the given json-mock image to the
+// deployment, which answers all requests on EchoStatusPort with the given
+// HTTP status code.
+func withStatusServer(dep *appsv1.Deployment, image string, code int) *appsv1.Deployment {
+	dep.Spec.Template.Spec.Containers = append(
+		dep.Spec.Template.Spec.Containers,
+		corev1.Container{
+			Name:            EchoStatusServerContainerName,
+			Image:           image,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{"node", "-e", fmt.Sprintf(statusServerScript, code, EchoStatusPort)},
+			Ports: []corev1.ContainerPort{
+				{ContainerPort: EchoStatusPort, Name: echoStatusPortName},
+			},
+		},
+	)
+
+	return dep
+}
+
+// netemScript applies the given tc netem options to the egress of the pod.
+const netemScript = `tc qdisc replace dev eth0 root netem %s || exit 1
+exec sleep 10000000`
+
+// withNetem adds a sidecar to the deployment which degrades the network of
+// the pod with the configured latency and packet loss, if the deployment is
+// of the kind targeted by the netem options.
+func (ct *ConnectivityTest) withNetem(dep *appsv1.Deployment, kind string) *appsv1.Deployment {
+	args := ct.params.netemArgs()
+	if args == "" || ct.params.netemTarget() != kind {
+		return dep
+	}
+
+	dep.Spec.Template.Spec.Containers = append(
+		dep.Spec.Template.Spec.Containers,
+		corev1.Container{
+			Name:            NetemContainerName,
+			Image:           ct.params.netemImage(),
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{"/bin/sh", "-c", fmt.Sprintf(netemScript, args)},
+			SecurityContext: &corev1.SecurityContext{
+				Capabilities: &corev1.Capabilities{
+					Add: []corev1.Capability{"NET_ADMIN"},
+				},
+			},
+		},
+	)
+
+	return dep
+}
+
 type daemonSetParameters struct {
 	Name           string
 	Kind           string
@@ -236,6 +429,10 @@ type daemonSetParameters struct {
 	Labels         map[string]string
 	HostNetwork    bool
 	Tolerations    []corev1.Toleration
+	DisableSAToken bool
+	DisableNetRaw  bool
+	SeccompProfile *corev1.SeccompProfile
+	Annotations    map[string]string
 }
 
 func newDaemonSet(p daemonSetParameters) *appsv1.DaemonSet {
@@ -289,16 +486,57 @@ func newDaemonSet(p daemonSetParameters) *appsv1.DaemonSet {
 		ds.Spec.Template.ObjectMeta.Labels[k] = v
 	}
 
+	if len(p.Annotations) > 0 {
+		ds.Spec.Template.ObjectMeta.Annotations = make(map[string]string, len(p.Annotations))
+		for k, v := range p.Annotations {
+			ds.Spec.Template.ObjectMeta.Annotations[k] = v
+		}
+	}
+
+	if p.DisableSAToken {
+		automount := false
+		ds.Spec.Template.Spec.AutomountServiceAccountToken = &automount
+	}
+
+	if p.DisableNetRaw {
+		// Only drop NET_RAW, keeping the rest of the security context.
+		if sc := ds.Spec.Template.Spec.Containers[0].SecurityContext; sc != nil && sc.Capabilities != nil {
+			add := make([]corev1.Capability, 0, len(sc.Capabilities.Add))
+			for _, c := range sc.Capabilities.Add {
+				if c != "NET_RAW" {
+					add = append(add, c)
+				}
+			}
+			sc.Capabilities.Add = add
+		}
+	}
+
+	if p.SeccompProfile != nil {
+		ds.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{SeccompProfile: p.SeccompProfile}
+	}
+
 	return ds
 }
 
+// nodeWithoutCiliumSelector selects the nodes Cilium is not scheduled on, see
+// canNodeRunCilium.
+var nodeWithoutCiliumSelector = map[string]string{"cilium.io/no-schedule": "true"}
+
+// tolerateAllTaints lets a pod be scheduled on any node regardless of its
+// taints.
+var tolerateAllTaints = []corev1.Toleration{
+	{Operator: corev1.TolerationOpExists},
+}
+
 var serviceLabels = map[string]string{
 	"kind": kindEchoName,
 }
 
-func newService(name string, selector map[string]string, labels map[string]string, portName string, port int) *corev1.Service {
+// newService returns a NodePort Service, or a LoadBalancer Service handled by
+// the controller implementing lbClass if not empty.
+func newService(name string, selector map[string]string, labels map[string]string, portName string, port int, lbClass string) *corev1.Service {
 	ipFamPol := corev1.IPFamilyPolicyPreferDualStack
-	return &corev1.Service{
+	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   name,
 			Labels: labels,
@@ -312,6 +550,514 @@ func newService(name string, selector map[string]string, labels map[string]strin
 			IPFamilyPolicy: &ipFamPol,
 		},
 	}
+	if lbClass != "" {
+		// LoadBalancer Services get NodePorts allocated as well, so the
+		// NodePort tests keep working.
+		svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+		svc.Spec.LoadBalancerClass = &lbClass
+	}
+	return svc
+}
+
+// newHostNetNSDaemonSets returns the host-netns DaemonSets. Nodes of an
+// architecture listed in HostNetNSImages get a dedicated DaemonSet running
+// the image for that architecture, all other nodes run the curl image. If
+// HostNetNSNodes is set, the DaemonSets only run on the listed nodes.
+func (ct *ConnectivityTest) newHostNetNSDaemonSets() []*appsv1.DaemonSet {
+	newHostNetNS := func(name, image string, op corev1.NodeSelectorOperator, arches []string) *appsv1.DaemonSet {
+		p := daemonSetParameters{
+			Name:        name,
+			Kind:        kindHostNetNS,
+			Image:       image,
+			Port:        8080,
+			Labels:      map[string]string{"other": "host-netns"},
+			Command:     []string{ct.params.clientShell(), "-c", "sleep 10000000"},
+			HostNetwork: true,
+			Tolerations: []corev1.Toleration{
+				{Operator: corev1.TolerationOpExists},
+			},
+			DisableSAToken: ct.params.NoAutomountSAToken,
+			SeccompProfile: ct.params.seccompProfile(),
+			DisableNetRaw:  ct.params.NoHostNetNSNetRaw,
+		}
+		var term corev1.NodeSelectorTerm
+		if len(arches) > 0 {
+			term.MatchExpressions = []corev1.NodeSelectorRequirement{
+				{Key: corev1.LabelArchStable, Operator: op, Values: arches},
+			}
+		}
+		if len(ct.params.HostNetNSNodes) > 0 {
+			term.MatchFields = []corev1.NodeSelectorRequirement{
+				{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: ct.params.HostNetNSNodes},
+			}
+		}
+		if len(term.MatchExpressions) > 0 || len(term.MatchFields) > 0 {
+			p.Affinity = &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{term},
+					},
+				},
+			}
+		}
+		return newDaemonSet(p)
+	}
+
+	arches := make([]string, 0, len(ct.params.HostNetNSImages))
+	for arch := range ct.params.HostNetNSImages {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+
+	dss := []*appsv1.DaemonSet{
+		newHostNetNS(hostNetNSDeploymentName, ct.params.CurlImage, corev1.NodeSelectorOpNotIn, arches),
+	}
+	for _, arch := range arches {
+		dss = append(dss, newHostNetNS(hostNetNSDeploymentName+"-"+arch, ct.params.HostNetNSImages[arch],
+			corev1.NodeSelectorOpIn, []string{arch}))
+	}
+	return dss
+}
+
+// newExternalNameService returns a Service of type ExternalName which resolves
+// to the given external host through a CNAME record.
+func newExternalNameService(name string, labels map[string]string, externalName string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: externalName,
+		},
+	}
+}
+
+// newClientDaemonSet returns a DaemonSet running a client pod on each
+// schedulable node, so that the scenarios are run from every node.
+func (ct *ConnectivityTest) newClientDaemonSet() *appsv1.DaemonSet {
+	ds := newDaemonSet(daemonSetParameters{
+		Name:           clientDaemonSetName,
+		Kind:           kindClientName,
+		Image:          ct.params.CurlImage,
+		Port:           8080,
+		Command:        []string{ct.params.clientShell(), "-c", "sleep 10000000"},
+		DisableSAToken: ct.params.NoAutomountSAToken,
+		SeccompProfile: ct.params.seccompProfile(),
+		Annotations:    ct.params.clientPodAnnotations(),
+	})
+	ds.Spec.Template.Spec.ServiceAccountName = clientDaemonSetName
+	ds.Spec.Template.Spec.NodeSelector = ct.params.NodeSelector
+	return ds
+}
+
+// newEchoService returns the Service fronting the echo deployment of the given name.
+func (ct *ConnectivityTest) newEchoService(name string) *corev1.Service {
+	svc := newService(name, map[string]string{"name": name}, serviceLabels, "http", 8080, ct.params.EchoLBClass)
+	svc.Spec.Ports[0].TargetPort = intstr.FromInt(ct.params.echoPort())
+	ct.setLBAlgorithm(svc)
+	ct.setIPFamily(svc)
+	ct.setOwnerReferences(svc)
+	return svc
+}
+
+// newEchoTopologyService returns the Service fronting the echo-topology pods,
+// with topology-aware hints enabled.
+func (ct *ConnectivityTest) newEchoTopologyService() *corev1.Service {
+	svc := newService(echoTopologyDeploymentName, map[string]string{"name": echoTopologyDeploymentName},
+		map[string]string{"kind": kindEchoTopologyName}, "http", 8080, "")
+	svc.Spec.Ports[0].TargetPort = intstr.FromInt(ct.params.echoPort())
+	svc.Annotations = map[string]string{
+		// topology-mode supersedes topology-aware-hints as of Kubernetes 1.27.
+		"service.kubernetes.io/topology-mode":        "Auto",
+		"service.kubernetes.io/topology-aware-hints": "auto",
+	}
+	ct.setIPFamily(svc)
+	ct.setOwnerReferences(svc)
+	return svc
+}
+
+// topologyZones returns the sorted zones of the schedulable nodes.
+func topologyZones(nodes map[string]*corev1.Node, selector map[string]string) []string {
+	var zones []string
+	for _, name := range schedulableNodes(nodes, selector, nil) {
+		zone := nodes[name].Labels[corev1.LabelTopologyZone]
+		if zone != "" && !slices.Contains(zones, zone) {
+			zones = append(zones, zone)
+		}
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// deployEchoTopology deploys an echo pod in each zone, fronted by a Service
+// with topology-aware hints, so that connections to it are expected to stay
+// in the zone of the client. Nothing is deployed with less than two zones.
+func (ct *ConnectivityTest) deployEchoTopology(ctx context.Context) error {
+	zones := topologyZones(ct.nodes, ct.params.NodeSelector)
+	if len(zones) < 2 {
+		ct.Warnf("[%s] Found %d zones, not deploying %s as topology-aware hints can not be tested",
+			ct.clients.src.ClusterName(), len(zones), echoTopologyDeploymentName)
+		return nil
+	}
+
+	_, err := ct.clients.src.GetService(ctx, ct.params.TestNamespace, echoTopologyDeploymentName, metav1.GetOptions{})
+	if err != nil || ct.params.Reconcile {
+		ct.Logf("✨ [%s] Deploying %s service...", ct.clients.src.ClusterName(), echoTopologyDeploymentName)
+		if err := ct.createService(ctx, ct.clients.src, ct.newEchoTopologyService()); err != nil {
+			return err
+		}
+	}
+
+	_, err = ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, echoTopologyDeploymentName, metav1.GetOptions{})
+	if err != nil || ct.params.Reconcile {
+		ct.Logf("✨ [%s] Deploying %s deployment in zones %s...", ct.clients.src.ClusterName(), echoTopologyDeploymentName, zones)
+		containerPort := ct.params.echoPort()
+		dep := newDeployment(deploymentParameters{
+			Name:           echoTopologyDeploymentName,
+			Kind:           kindEchoTopologyName,
+			Replicas:       len(zones),
+			NamedPort:      ct.params.echoNamedPort(),
+			Port:           containerPort,
+			Image:          ct.params.JSONMockImage,
+			NodeSelector:   ct.params.NodeSelector,
+			ReadinessProbe: newLocalReadinessProbe(containerPort, "/"),
+			DisableSAToken: ct.params.NoAutomountSAToken,
+			SeccompProfile: ct.params.seccompProfile(),
+		})
+		// Spread the replicas evenly, i.e. one in each zone.
+		dep.Spec.Template.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelTopologyZone,
+			WhenUnsatisfiable: corev1.DoNotSchedule,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"name": echoTopologyDeploymentName},
+			},
+		}}
+		dep = withConnectionCounter(dep, ct.params.netemImage(), ct.params.echoPort())
+		if err := ct.createServiceAccount(ctx, ct.clients.src, echoTopologyDeploymentName); err != nil {
+			return err
+		}
+		if err := ct.createDeployment(ctx, ct.clients.src, dep); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateEchoTopology waits for the echo-topology deployment and service, if
+// deployed, and adds them to the test context.
+func (ct *ConnectivityTest) validateEchoTopology(ctx context.Context) error {
+	svc, err := ct.readClient(ct.clients.src).GetService(ctx, ct.params.TestNamespace, echoTopologyDeploymentName, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		// Not deployed for lack of zones.
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to get service %s: %w", echoTopologyDeploymentName, err)
+	}
+
+	if err := ct.waitForDeployments(ctx, ct.clients.src, []string{echoTopologyDeploymentName}); err != nil {
+		return err
+	}
+	pods, err := ct.readClient(ct.clients.src).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindEchoTopologyName})
+	if err != nil {
+		return fmt.Errorf("unable to list %s pods: %w", echoTopologyDeploymentName, err)
+	}
+	for _, pod := range pods.Items {
+		ct.addPod(ct.echoTopologyPods, pod.Name, Pod{
+			K8sClient: ct.clients.src,
+			Pod:       pod.DeepCopy(),
+			scheme:    "http",
+			port:      uint32(ct.params.echoPort()),
+		})
+	}
+
+	if err := ct.waitForEndpointSlices(ctx, ct.clients.src, svc, len(pods.Items)); err != nil {
+		return err
+	}
+	s := Service{Service: svc}
+	start := time.Now()
+	err = ct.waitForService(ctx, s)
+	ct.recordSetupStep("service "+s.Name(), start, err)
+	if err != nil {
+		return err
+	}
+	ct.addService(ct.echoTopologyServices, svc.Name, s, true)
+	return nil
+}
+
+// echoTLSProxyScript is an HTTPS server, run by the Node.js runtime of the
+// json-mock image, which reads its certificate and key from the given
+// directory, listens on the given port and forwards the requests to the echo
+// server listening on the given local port.
+const echoTLSProxyScript = `const fs = require("fs");
+const http = require("http");
+const options = {
+	cert: fs.readFileSync("%[1]s/%[2]s"),
+	key: fs.readFileSync("%[1]s/%[3]s"),
+};
+require("https").createServer(options, (req, res) => {
+	const upstream = http.request({host: "127.0.0.1", port: %[5]d, method: req.method, path: req.url, headers: req.headers}, (r) => {
+		res.writeHead(r.statusCode, r.headers);
+		r.pipe(res);
+	});
+	upstream.on("error", () => {
+		res.writeHead(502, {"Content-Length": "0"});
+		res.end();
+	});
+	req.pipe(upstream);
+}).listen(%[4]d);
+`
+
+const (
+	echoTLSProxyScriptDir  = "/etc/tls-proxy"
+	echoTLSProxyScriptName = "proxy.js"
+	echoTLSProxyCertDir    = "/etc/tls"
+)
+
+// echoTLSProxyConfig is the script of the sidecar terminating TLS in front of
+// the echo server listening on the given port.
+func echoTLSProxyConfig(echoPort int) string {
+	return fmt.Sprintf(echoTLSProxyScript, echoTLSProxyCertDir, corev1.TLSCertKey, corev1.TLSPrivateKeyKey, echoTLSPort, echoPort)
+}
+
+// withTLSProxy adds a sidecar running the given json-mock image to the echo
+// deployment, which serves the echo server over HTTPS, using the script and
+// the certificate from the echo-tls ConfigMap and Secret.
+func withTLSProxy(dep *appsv1.Deployment, image string) *appsv1.Deployment {
+	dep.Spec.Template.Spec.Containers = append(dep.Spec.Template.Spec.Containers, corev1.Container{
+		Name:            echoTLSProxyContainerName,
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"node", echoTLSProxyScriptDir + "/" + echoTLSProxyScriptName},
+		Ports: []corev1.ContainerPort{
+			{ContainerPort: echoTLSPort, Name: echoTLSPortName},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(echoTLSPort)},
+			},
+			PeriodSeconds: int32(1),
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "config", MountPath: echoTLSProxyScriptDir, ReadOnly: true},
+			{Name: "tls", MountPath: echoTLSProxyCertDir, ReadOnly: true},
+		},
+	})
+	dep.Spec.Template.Spec.Volumes = append(dep.Spec.Template.Spec.Volumes,
+		corev1.Volume{
+			Name: "config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: echoTLSDeploymentName},
+				},
+			},
+		},
+		corev1.Volume{
+			Name: "tls",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: echoTLSDeploymentName},
+			},
+		},
+	)
+	return dep
+}
+
+// newEchoTLSSecret returns the Secret holding a self-signed certificate for
+// the echo-tls Service and the CA which issued it.
+func (ct *ConnectivityTest) newEchoTLSSecret() (*corev1.Secret, error) {
+	ns := ct.params.TestNamespace
+	caCert, cert, key, err := newCertificate(
+		echoTLSDeploymentName,
+		echoTLSDeploymentName+"."+ns,
+		echoTLSDeploymentName+"."+ns+".svc",
+		fmt.Sprintf("%s.%s.svc.%s", echoTLSDeploymentName, ns, ct.params.clusterDomain()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   echoTLSDeploymentName,
+			Labels: map[string]string{"kind": kindEchoTLSName},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       cert,
+			corev1.TLSPrivateKeyKey: key,
+			"ca.crt":                caCert,
+		},
+	}
+	ct.setOwnerReferences(secret)
+	return secret, nil
+}
+
+// deployEchoTLS deploys an echo pod with a sidecar terminating TLS with a
+// self-signed certificate, and the Service fronting it.
+func (ct *ConnectivityTest) deployEchoTLS(ctx context.Context) error {
+	client := ct.clients.src
+
+	// The certificate is generated on each run, an existing secret is kept
+	// as is even with Reconcile.
+	_, err := client.GetSecret(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		ct.Logf("✨ [%s] Deploying %s secret...", client.ClusterName(), echoTLSDeploymentName)
+		secret, err := ct.newEchoTLSSecret()
+		if err != nil {
+			return fmt.Errorf("unable to create certificate for %s: %w", echoTLSDeploymentName, err)
+		}
+		release := ct.throttleCreate(ctx)
+		_, err = client.CreateSecret(ctx, ct.params.TestNamespace, secret, metav1.CreateOptions{})
+		release()
+		if err != nil {
+			return fmt.Errorf("unable to create secret %s: %w", echoTLSDeploymentName, err)
+		}
+		ct.recordCreated(client, "Secret", ct.params.TestNamespace, echoTLSDeploymentName)
+	}
+
+	_, err = client.GetConfigMap(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.GetOptions{})
+	if err != nil || ct.params.Reconcile {
+		ct.Logf("✨ [%s] Deploying %s configmap...", client.ClusterName(), echoTLSDeploymentName)
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   echoTLSDeploymentName,
+				Labels: map[string]string{"kind": kindEchoTLSName},
+			},
+			Data: map[string]string{
+				echoTLSProxyScriptName: echoTLSProxyConfig(ct.params.echoPort()),
+			},
+		}
+		ct.setOwnerReferences(cm)
+		if err := ct.createConfigMap(ctx, client, cm); err != nil {
+			return err
+		}
+	}
+
+	_, err = client.GetService(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.GetOptions{})
+	if err != nil || ct.params.Reconcile {
+		ct.Logf("✨ [%s] Deploying %s service...", client.ClusterName(), echoTLSDeploymentName)
+		if err := ct.createService(ctx, client, ct.newEchoTLSService()); err != nil {
+			return err
+		}
+	}
+
+	_, err = client.GetDeployment(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.GetOptions{})
+	if err != nil || ct.params.Reconcile {
+		ct.Logf("✨ [%s] Deploying %s deployment...", client.ClusterName(), echoTLSDeploymentName)
+		containerPort := ct.params.echoPort()
+		dep := newDeployment(deploymentParameters{
+			Name:           echoTLSDeploymentName,
+			Kind:           kindEchoTLSName,
+			NamedPort:      ct.params.echoNamedPort(),
+			Port:           containerPort,
+			Image:          ct.params.JSONMockImage,
+			NodeSelector:   ct.params.NodeSelector,
+			ReadinessProbe: newLocalReadinessProbe(containerPort, "/"),
+			DisableSAToken: ct.params.NoAutomountSAToken,
+			SeccompProfile: ct.params.seccompProfile(),
+		})
+		dep = withTLSProxy(dep, ct.params.JSONMockImage)
+		if err := ct.createServiceAccount(ctx, client, echoTLSDeploymentName); err != nil {
+			return err
+		}
+		if err := ct.createDeployment(ctx, client, dep); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateEchoTLS waits for the echo-tls deployment and service, and adds
+// them to the test context along with the CA of the echo certificate.
+func (ct *ConnectivityTest) validateEchoTLS(ctx context.Context) error {
+	client := ct.clients.src
+	if err := ct.waitForDeployments(ctx, client, []string{echoTLSDeploymentName}); err != nil {
+		return err
+	}
+
+	secret, err := ct.readClient(client).GetSecret(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get secret %s: %w", echoTLSDeploymentName, err)
+	}
+	ct.setEchoTLSCA(secret.Data["ca.crt"])
+
+	svc, err := ct.readClient(client).GetService(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get service %s: %w", echoTLSDeploymentName, err)
+	}
+	if err := ct.waitForEndpointSlices(ctx, client, svc, 1); err != nil {
+		return err
+	}
+	s := Service{Service: svc}
+	start := time.Now()
+	err = ct.waitForService(ctx, s)
+	ct.recordSetupStep("service "+s.Name(), start, err)
+	if err != nil {
+		return err
+	}
+	ct.addService(ct.echoTLSServices, svc.Name, s, true)
+	return nil
+}
+
+// newEchoTLSService returns the Service fronting the TLS sidecar of the
+// echo-tls pod.
+func (ct *ConnectivityTest) newEchoTLSService() *corev1.Service {
+	svc := newService(echoTLSDeploymentName, map[string]string{"name": echoTLSDeploymentName},
+		map[string]string{"kind": kindEchoTLSName}, echoTLSPortName, echoTLSPort, "")
+	ct.setIPFamily(svc)
+	ct.setOwnerReferences(svc)
+	return svc
+}
+
+// newEchoLBService returns a Service selecting the pods of all echo
+// deployments, to test load-balancing across backends on different nodes.
+func (ct *ConnectivityTest) newEchoLBService() *corev1.Service {
+	svc := newService(echoLBServiceName, map[string]string{"kind": kindEchoName}, map[string]string{"kind": kindEchoLBName}, "http", 8080, "")
+	svc.Spec.Ports[0].TargetPort = intstr.FromInt(ct.params.echoPort())
+	ct.setLBAlgorithm(svc)
+	ct.setIPFamily(svc)
+	ct.setOwnerReferences(svc)
+	return svc
+}
+
+// setIPFamily makes svc a single-stack service of the configured IP family,
+// if any, instead of preferring dual-stack.
+func (ct *ConnectivityTest) setIPFamily(svc *corev1.Service) {
+	var family corev1.IPFamily
+	switch ct.params.ServiceIPFamily {
+	case "ipv4":
+		family = corev1.IPv4Protocol
+	case "ipv6":
+		family = corev1.IPv6Protocol
+	default:
+		return
+	}
+
+	policy := corev1.IPFamilyPolicySingleStack
+	svc.Spec.IPFamilyPolicy = &policy
+	svc.Spec.IPFamilies = []corev1.IPFamily{family}
+}
+
+// setOwnerReferences adds the configured owner references to obj, so that
+// it gets garbage-collected together with its owners.
+func (ct *ConnectivityTest) setOwnerReferences(obj metav1.Object) {
+	if len(ct.params.OwnerReferences) > 0 {
+		obj.SetOwnerReferences(append(obj.GetOwnerReferences(), ct.params.OwnerReferences...))
+	}
+}
+
+// setLBAlgorithm initializes the annotations of svc, requesting the
+// configured Cilium load-balancing algorithm if any.
+func (ct *ConnectivityTest) setLBAlgorithm(svc *corev1.Service) {
+	if svc.ObjectMeta.Annotations == nil {
+		svc.ObjectMeta.Annotations = map[string]string{}
+	}
+	if ct.params.EchoLBAlgorithm != "" {
+		svc.ObjectMeta.Annotations[lbAlgorithmAnnotation] = ct.params.EchoLBAlgorithm
+	}
 }
 
 func newLocalReadinessProbe(port int, path string) *corev1.Probe {
@@ -375,1002 +1121,2873 @@ func newIngress() *networkingv1.Ingress {
 	}
 }
 
-// deploy ensures the test Namespace, Services and Deployments are running on the cluster.
-func (ct *ConnectivityTest) deploy(ctx context.Context) error {
-	if ct.params.ForceDeploy {
-		if err := ct.deleteDeployments(ctx, ct.clients.src); err != nil {
-			return err
-		}
+// perfResources returns the resource requirements of the perf deployments.
+// With PerfGuaranteedQoS, requests and limits are equal and the CPU count is
+// an integer, so that the pods get the Guaranteed QoS class and are eligible
+// for exclusive CPUs under the static CPU manager policy.
+func (ct *ConnectivityTest) perfResources() corev1.ResourceRequirements {
+	if !ct.params.PerfGuaranteedQoS {
+		return corev1.ResourceRequirements{}
 	}
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(defaults.ConnectivityPerformanceCPU),
+		corev1.ResourceMemory: resource.MustParse(defaults.ConnectivityPerformanceMemory),
+	}
+	return corev1.ResourceRequirements{
+		Requests: resources,
+		Limits:   resources,
+	}
+}
 
-	_, err := ct.clients.src.GetNamespace(ctx, ct.params.TestNamespace, metav1.GetOptions{})
-	if err != nil {
-		ct.Logf("✨ [%s] Creating namespace %s for connectivity check...", ct.clients.src.ClusterName(), ct.params.TestNamespace)
-		_, err = ct.clients.src.CreateNamespace(ctx, ct.params.TestNamespace, metav1.CreateOptions{})
-		if err != nil {
-			return fmt.Errorf("unable to create namespace %s: %s", ct.params.TestNamespace, err)
+// selectPerfZone returns the zone to schedule the performance workloads in,
+// preferring a zone with more than one node, in which case multiNode is true.
+// Nodes without a topology zone label are ignored, so the returned zone is
+// empty if none of the nodes has one, e.g. on bare-metal clusters.
+func selectPerfZone(nodes []corev1.Node) (zone string, multiNode bool) {
+	zones := map[string]struct{}{}
+	for _, node := range nodes {
+		z := node.Labels[corev1.LabelTopologyZone]
+		if z == "" {
+			continue
 		}
+		if _, ok := zones[z]; ok {
+			return z, true
+		}
+		zones[z] = struct{}{}
+		// No zone had > 1 node so far, use the last zone.
+		zone = z
 	}
+	return zone, false
+}
 
-	if ct.params.Perf {
-		// For performance workloads, we want to ensure the client/server are in the same zone
-		// If a zone has > 1 node, use that zone
-		zones := map[string]int{}
-		zone := ""
-		lz := ""
-		n, hasNodes := ct.client.ListNodes(ctx, metav1.ListOptions{})
-		if hasNodes != nil {
+// perfPodZones returns the sorted topology zones of the nodes the given pods
+// are running on. Nodes without a topology zone label are ignored.
+func perfPodZones(pods []corev1.Pod, nodes []corev1.Node) []string {
+	nodeZones := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		nodeZones[node.Name] = node.Labels[corev1.LabelTopologyZone]
+	}
+
+	seen := map[string]struct{}{}
+	var zones []string
+	for _, pod := range pods {
+		zone := nodeZones[pod.Spec.NodeName]
+		if zone == "" {
+			continue
+		}
+		if _, ok := seen[zone]; !ok {
+			seen[zone] = struct{}{}
+			zones = append(zones, zone)
+		}
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// checkPerfZones verifies that the performance client and server pods landed
+// in the same zone, as the zone affinity is only a scheduling preference. A
+// mismatch is reported as a warning, or as an error with StrictPerfZone.
+func (ct *ConnectivityTest) checkPerfZones(ctx context.Context, pods []corev1.Pod) error {
+	nodes, err := ct.readClient(ct.clients.src).ListNodes(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list nodes: %w", err)
+	}
+
+	zones := perfPodZones(pods, nodes.Items)
+	if len(zones) <= 1 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("performance pods are spread across zones %s, results include cross-zone latency", strings.Join(zones, ", "))
+	if ct.params.StrictPerfZone {
+		return errors.New(msg)
+	}
+	ct.Warn(msg)
+	return nil
+}
+
+// perfZoneAffinity returns the node affinity preferring the given zone with
+// the given weight for the performance workloads, or requiring it if required
+// is set. It returns nil if the zone is unknown.
+func perfZoneAffinity(zone string, weight int32, required bool) *corev1.NodeAffinity {
+	if zone == "" {
+		return nil
+	}
+	term := corev1.NodeSelectorTerm{
+		MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{zone}},
+		},
+	}
+	if required {
+		return &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{term},
+			},
+		}
+	}
+	return &corev1.NodeAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+			{Weight: weight, Preference: term},
+		},
+	}
+}
+
+// createDeployment creates the given deployment in the test namespace. With
+// VerboseDeploy, the full manifest is logged if the creation fails. With
+// Reconcile, an existing deployment is updated instead if its spec drifted.
+func (ct *ConnectivityTest) createDeployment(ctx context.Context, client *k8s.Client, dep *appsv1.Deployment) error {
+	ct.setOwnerReferences(dep)
+	if ct.params.ServiceAccount != "" {
+		dep.Spec.Template.Spec.ServiceAccountName = ct.params.ServiceAccount
+	}
+	if ct.params.Reconcile {
+		existing, err := client.GetDeployment(ctx, ct.params.TestNamespace, dep.Name, metav1.GetOptions{})
+		if err == nil {
+			if equality.Semantic.DeepDerivative(dep.Spec, existing.Spec) {
+				return nil
+			}
+			ct.Logf("🔄 [%s] Updating drifted deployment %s...", client.ClusterName(), dep.Name)
+			existing.Spec = dep.Spec
+			if _, err := client.UpdateDeployment(ctx, ct.params.TestNamespace, existing, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("unable to update deployment %s: %w", dep.Name, err)
+			}
+			return nil
+		}
+	}
+
+	release := ct.throttleCreate(ctx)
+	_, err := client.CreateDeployment(ctx, ct.params.TestNamespace, dep, metav1.CreateOptions{})
+	release()
+	ct.logCreate(client, "deployment", dep.Name, dep, err)
+	if err != nil {
+		return fmt.Errorf("unable to create deployment %s: %w", dep.Name, err)
+	}
+	ct.recordCreated(client, "Deployment", ct.params.TestNamespace, dep.Name)
+	return nil
+}
+
+// createDaemonSet creates the given daemonset in the test namespace. With
+// VerboseDeploy, the full manifest is logged if the creation fails. With
+// Reconcile, an existing daemonset is updated instead if its spec drifted.
+func (ct *ConnectivityTest) createDaemonSet(ctx context.Context, client *k8s.Client, ds *appsv1.DaemonSet) error {
+	ct.setOwnerReferences(ds)
+	if ct.params.ServiceAccount != "" {
+		ds.Spec.Template.Spec.ServiceAccountName = ct.params.ServiceAccount
+	}
+	if ct.params.Reconcile {
+		existing, err := client.GetDaemonSet(ctx, ct.params.TestNamespace, ds.Name, metav1.GetOptions{})
+		if err == nil {
+			if equality.Semantic.DeepDerivative(ds.Spec, existing.Spec) {
+				return nil
+			}
+			ct.Logf("🔄 [%s] Updating drifted daemonset %s...", client.ClusterName(), ds.Name)
+			existing.Spec = ds.Spec
+			if _, err := client.UpdateDaemonSet(ctx, ct.params.TestNamespace, existing, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("unable to update daemonset %s: %w", ds.Name, err)
+			}
+			return nil
+		}
+	}
+
+	release := ct.throttleCreate(ctx)
+	_, err := client.CreateDaemonSet(ctx, ct.params.TestNamespace, ds, metav1.CreateOptions{})
+	release()
+	ct.logCreate(client, "daemonset", ds.Name, ds, err)
+	if err != nil {
+		return fmt.Errorf("unable to create daemonset %s: %w", ds.Name, err)
+	}
+	ct.recordCreated(client, "DaemonSet", ct.params.TestNamespace, ds.Name)
+	return nil
+}
+
+// createService creates the given service in the test namespace. With
+// Reconcile, an existing service is updated instead if its spec, labels or
+// annotations drifted.
+func (ct *ConnectivityTest) createService(ctx context.Context, client *k8s.Client, svc *corev1.Service) error {
+	if ct.params.Reconcile {
+		existing, err := client.GetService(ctx, ct.params.TestNamespace, svc.Name, metav1.GetOptions{})
+		if err == nil {
+			reconciled := reconciledService(existing, svc)
+			if metadataInSync(svc.ObjectMeta, existing.ObjectMeta) && equality.Semantic.DeepDerivative(reconciled.Spec, existing.Spec) {
+				return nil
+			}
+			ct.Logf("🔄 [%s] Updating drifted service %s...", client.ClusterName(), svc.Name)
+			if _, err := client.UpdateService(ctx, ct.params.TestNamespace, reconciled, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("unable to update service %s: %w", svc.Name, err)
+			}
+			return nil
+		}
+	}
+
+	release := ct.throttleCreate(ctx)
+	_, err := client.CreateService(ctx, ct.params.TestNamespace, svc, metav1.CreateOptions{})
+	release()
+	if err != nil {
+		return fmt.Errorf("unable to create service %s: %w", svc.Name, err)
+	}
+	ct.recordCreated(client, "Service", ct.params.TestNamespace, svc.Name)
+	return nil
+}
+
+// reconciledService returns a copy of the existing service with the labels,
+// annotations and spec of svc, keeping the cluster IPs and NodePorts
+// allocated to it as well as its IP families if svc doesn't set them.
+func reconciledService(existing, svc *corev1.Service) *corev1.Service {
+	out := existing.DeepCopy()
+	reconcileMetadata(&out.ObjectMeta, svc.ObjectMeta)
+	out.Spec = *svc.Spec.DeepCopy()
+	out.Spec.ClusterIP = existing.Spec.ClusterIP
+	out.Spec.ClusterIPs = existing.Spec.ClusterIPs
+	if out.Spec.IPFamilies == nil {
+		out.Spec.IPFamilies = existing.Spec.IPFamilies
+	}
+	if out.Spec.IPFamilyPolicy == nil {
+		out.Spec.IPFamilyPolicy = existing.Spec.IPFamilyPolicy
+	}
+	for i, port := range out.Spec.Ports {
+		if port.NodePort != 0 {
+			continue
+		}
+		for _, p := range existing.Spec.Ports {
+			if p.Name == port.Name {
+				out.Spec.Ports[i].NodePort = p.NodePort
+			}
+		}
+	}
+	return out
+}
+
+// createConfigMap creates the given configmap in the test namespace. With
+// Reconcile, an existing configmap is updated instead if its data or labels
+// drifted.
+func (ct *ConnectivityTest) createConfigMap(ctx context.Context, client *k8s.Client, cm *corev1.ConfigMap) error {
+	if ct.params.Reconcile {
+		existing, err := client.GetConfigMap(ctx, ct.params.TestNamespace, cm.Name, metav1.GetOptions{})
+		if err == nil {
+			if metadataInSync(cm.ObjectMeta, existing.ObjectMeta) && equality.Semantic.DeepEqual(cm.Data, existing.Data) {
+				return nil
+			}
+			ct.Logf("🔄 [%s] Updating drifted configmap %s...", client.ClusterName(), cm.Name)
+			reconcileMetadata(&existing.ObjectMeta, cm.ObjectMeta)
+			existing.Data = cm.Data
+			if _, err := client.UpdateConfigMap(ctx, existing, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("unable to update configmap %s: %w", cm.Name, err)
+			}
+			return nil
+		}
+	}
+
+	release := ct.throttleCreate(ctx)
+	_, err := client.CreateConfigMap(ctx, ct.params.TestNamespace, cm, metav1.CreateOptions{})
+	release()
+	if err != nil {
+		return fmt.Errorf("unable to create configmap %s: %w", cm.Name, err)
+	}
+	ct.recordCreated(client, "ConfigMap", ct.params.TestNamespace, cm.Name)
+	return nil
+}
+
+// createIngress creates the given ingress in the test namespace. With
+// Reconcile, an existing ingress is updated instead if its spec, labels or
+// annotations drifted.
+func (ct *ConnectivityTest) createIngress(ctx context.Context, client *k8s.Client, ingress *networkingv1.Ingress) error {
+	if ct.params.Reconcile {
+		existing, err := client.GetIngress(ctx, ct.params.TestNamespace, ingress.Name, metav1.GetOptions{})
+		if err == nil {
+			if metadataInSync(ingress.ObjectMeta, existing.ObjectMeta) && equality.Semantic.DeepDerivative(ingress.Spec, existing.Spec) {
+				return nil
+			}
+			ct.Logf("🔄 [%s] Updating drifted ingress %s...", client.ClusterName(), ingress.Name)
+			reconcileMetadata(&existing.ObjectMeta, ingress.ObjectMeta)
+			existing.Spec = ingress.Spec
+			if _, err := client.UpdateIngress(ctx, ct.params.TestNamespace, existing, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("unable to update ingress %s: %w", ingress.Name, err)
+			}
+			return nil
+		}
+	}
+
+	release := ct.throttleCreate(ctx)
+	_, err := client.CreateIngress(ctx, ct.params.TestNamespace, ingress, metav1.CreateOptions{})
+	release()
+	if err != nil {
+		return fmt.Errorf("unable to create ingress %s: %w", ingress.Name, err)
+	}
+	ct.recordCreated(client, "Ingress", ct.params.TestNamespace, ingress.Name)
+	return nil
+}
+
+// metadataInSync returns whether the existing object has all the labels and
+// annotations of the desired one. Labels and annotations added by others are
+// ignored.
+func metadataInSync(desired, existing metav1.ObjectMeta) bool {
+	return equality.Semantic.DeepDerivative(desired.Labels, existing.Labels) &&
+		equality.Semantic.DeepDerivative(desired.Annotations, existing.Annotations)
+}
+
+// reconcileMetadata sets the labels and annotations of desired on existing,
+// keeping the ones added by others.
+func reconcileMetadata(existing *metav1.ObjectMeta, desired metav1.ObjectMeta) {
+	for k, v := range desired.Labels {
+		if existing.Labels == nil {
+			existing.Labels = map[string]string{}
+		}
+		existing.Labels[k] = v
+	}
+	for k, v := range desired.Annotations {
+		if existing.Annotations == nil {
+			existing.Annotations = map[string]string{}
+		}
+		existing.Annotations[k] = v
+	}
+}
+
+// logCreate logs the outcome of creating a test resource if VerboseDeploy is
+// set: a one line summary on success, the manifest as YAML and the full API
+// error on failure.
+func (ct *ConnectivityTest) logCreate(client *k8s.Client, kind, name string, obj interface{}, err error) {
+	if !ct.params.VerboseDeploy {
+		return
+	}
+
+	if err == nil {
+		ct.Infof("[%s] Created %s %s/%s", client.ClusterName(), kind, ct.params.TestNamespace, name)
+		return
+	}
+
+	ct.Failf("[%s] Failed to create %s %s/%s: %s", client.ClusterName(), kind, ct.params.TestNamespace, name, err)
+	var apiStatus k8sErrors.APIStatus
+	if errors.As(err, &apiStatus) {
+		ct.Logf("API status: %+v", apiStatus.Status())
+	}
+	manifest, yamlErr := yaml.Marshal(obj)
+	if yamlErr != nil {
+		ct.Debugf("Unable to marshal %s %s to YAML: %s", kind, name, yamlErr)
+		return
+	}
+	ct.Logf("Manifest of %s %s:\n%s", kind, name, manifest)
+}
+
+// throttleCreate waits until fewer than MaxParallelDeployments create requests
+// are in flight, and returns the function releasing the slot it took. If ctx
+// is done first, no slot is taken and the create request issued with the same
+// ctx fails right away.
+func (ct *ConnectivityTest) throttleCreate(ctx context.Context) func() {
+	if ct.createSem == nil {
+		return func() {}
+	}
+	select {
+	case ct.createSem <- struct{}{}:
+		return func() { <-ct.createSem }
+	case <-ctx.Done():
+		return func() {}
+	}
+}
+
+// ensureNamespace creates the test namespace in the cluster of the given
+// client if needed, and checks the ServiceAccount the test pods run with.
+func (ct *ConnectivityTest) ensureNamespace(ctx context.Context, client *k8s.Client) error {
+	_, err := client.GetNamespace(ctx, ct.params.TestNamespace, metav1.GetOptions{})
+	if err != nil {
+		ct.Logf("✨ [%s] Creating namespace %s for connectivity check...", client.ClusterName(), ct.params.TestNamespace)
+		start := time.Now()
+		err = ct.createNamespace(ctx, client)
+		ct.recordSetupStep(fmt.Sprintf("[%s] create namespace %s", client.ClusterName(), ct.params.TestNamespace), start, err)
+		if err != nil {
+			return newSetupError(ErrNamespaceCreate, fmt.Errorf("unable to create namespace %s: %w", ct.params.TestNamespace, err))
+		}
+	}
+	return ct.checkServiceAccount(ctx, client)
+}
+
+// forEachCluster runs f against each of the given clients. In multi-cluster
+// mode, up to MultiClusterDeployConcurrency clusters are handled at once, all
+// of them if unset, each bounded by MultiClusterDeployTimeout if set, and the
+// errors of all clusters are returned. The steps within a cluster are up to
+// f, so that their ordering is preserved.
+func (ct *ConnectivityTest) forEachCluster(ctx context.Context, clients []*k8s.Client, f func(context.Context, *k8s.Client) error) error {
+	if len(clients) == 1 {
+		return f(ctx, clients[0])
+	}
+
+	concurrency := ct.params.MultiClusterDeployConcurrency
+	if concurrency < 1 {
+		concurrency = len(clients)
+	}
+	sem := make(chan struct{}, concurrency)
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		i, client := i, client
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			ctx := ctx
+			if ct.params.MultiClusterDeployTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, ct.params.MultiClusterDeployTimeout)
+				defer cancel()
+			}
+			if err := f(ctx, client); err != nil {
+				errs[i] = fmt.Errorf("[%s] %w", client.ClusterName(), err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// dnsTestServerCorefile is the Corefile of the DNS test server configmap
+// deployed by the tests if no DNSConfigMap is given.
+const dnsTestServerCorefile = `. {
+				local
+				ready
+				log
+			}`
+
+// ensureDNSConfigMap creates the DNS test server configmap in the test
+// namespace if needed, and waits until it can be retrieved. The Corefile of
+// DNSConfigMap is copied if it lives in another namespace, and only checked
+// if it lives in the test namespace.
+func (ct *ConnectivityTest) ensureDNSConfigMap(ctx context.Context, client *k8s.Client) error {
+	corefile := dnsTestServerCorefile
+	if ct.params.DNSConfigMap != "" {
+		var err error
+		corefile, err = ct.getDNSConfigMapCorefile(ctx, client)
+		if err != nil {
+			return err
+		}
+		if ct.params.dnsConfigMapNamespace() == ct.params.TestNamespace {
+			return nil
+		}
+	}
+
+	existing, err := client.GetConfigMap(ctx, ct.params.TestNamespace, corednsConfigMapName, metav1.GetOptions{})
+	if err == nil {
+		if existing.Data["Corefile"] == corefile {
+			return nil
+		}
+		// The configmap was left over by a previous run with another Corefile.
+		ct.Logf("🔄 [%s] Updating DNS test server configmap...", client.ClusterName())
+		existing.Data = map[string]string{"Corefile": corefile}
+		if _, err := client.UpdateConfigMap(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("unable to update configmap %s: %w", corednsConfigMapName, err)
+		}
+		return nil
+	}
+
+	if ct.params.DNSConfigMap != "" {
+		ct.Logf("✨ [%s] Copying DNS configmap %s/%s...", client.ClusterName(), ct.params.dnsConfigMapNamespace(), ct.params.DNSConfigMap)
+	} else {
+		ct.Logf("✨ [%s] Deploying DNS test server configmap...", client.ClusterName())
+	}
+	dnsConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: corednsConfigMapName,
+		},
+		Data: map[string]string{
+			"Corefile": corefile,
+		},
+	}
+	ct.setOwnerReferences(dnsConfigMap)
+	release := ct.throttleCreate(ctx)
+	_, err = client.CreateConfigMap(ctx, ct.params.TestNamespace, dnsConfigMap, metav1.CreateOptions{})
+	release()
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return fmt.Errorf("unable to create configmap %s: %s", corednsConfigMapName, err)
+	}
+	if err == nil {
+		ct.recordCreated(client, "ConfigMap", ct.params.TestNamespace, corednsConfigMapName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ct.params.serviceReadyTimeout())
+	defer cancel()
+	for {
+		_, err = client.GetConfigMap(ctx, ct.params.TestNamespace, corednsConfigMapName, metav1.GetOptions{})
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-time.After(ct.params.pollInterval()):
+		case <-ctx.Done():
+			return fmt.Errorf("timeout reached waiting for configmap %s (last error: %w)", corednsConfigMapName, err)
+		}
+	}
+}
+
+// getDNSConfigMapCorefile returns the Corefile of the existing DNSConfigMap
+// the DNS test server is configured with. It is neither created nor deleted by
+// the tests.
+func (ct *ConnectivityTest) getDNSConfigMapCorefile(ctx context.Context, client *k8s.Client) (string, error) {
+	namespace := ct.params.dnsConfigMapNamespace()
+	cm, err := client.GetConfigMap(ctx, namespace, ct.params.DNSConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("[%s] unable to get DNS configmap %s/%s: %w", client.ClusterName(), namespace, ct.params.DNSConfigMap, err)
+	}
+	corefile, ok := cm.Data["Corefile"]
+	if !ok {
+		return "", fmt.Errorf("[%s] DNS configmap %s/%s has no Corefile", client.ClusterName(), namespace, ct.params.DNSConfigMap)
+	}
+	return corefile, nil
+}
+
+// prePullContainer returns an init container of the image pre-pull pods which
+// pulls the given image and exits right away.
+func prePullContainer(image string) corev1.Container {
+	return corev1.Container{Image: image, Command: []string{"true"}}
+}
+
+// newPrePullDaemonSet returns the DaemonSet pulling the images of the given
+// containers. They are run to completion as init containers, before a
+// sleeping curl container keeps the pods running until the DaemonSet is
+// deleted.
+func (ct *ConnectivityTest) newPrePullDaemonSet(containers []corev1.Container) *appsv1.DaemonSet {
+	ds := newDaemonSet(daemonSetParameters{
+		Name:           imagePrePullDaemonSetName,
+		Kind:           kindImagePrePull,
+		Image:          ct.params.CurlImage,
+		Command:        []string{ct.params.clientShell(), "-c", "sleep 10000000"},
+		DisableSAToken: ct.params.NoAutomountSAToken,
+		DisableNetRaw:  true,
+		SeccompProfile: ct.params.seccompProfile(),
+	})
+	for i, c := range containers {
+		c.Name = fmt.Sprintf("%s-%d", imagePrePullDaemonSetName, i)
+		c.ImagePullPolicy = corev1.PullIfNotPresent
+		ds.Spec.Template.Spec.InitContainers = append(ds.Spec.Template.Spec.InitContainers, c)
+	}
+	ct.setOwnerReferences(ds)
+	if ct.params.ServiceAccount != "" {
+		ds.Spec.Template.Spec.ServiceAccountName = ct.params.ServiceAccount
+	}
+	return ds
+}
+
+// prePullImages pulls the images of the given containers onto the nodes by
+// deploying a short-lived DaemonSet running them, so that the readiness of the
+// test deployments doesn't depend on the registry. The DaemonSet is deleted
+// once every pod ran all of them, and isn't recorded as a created resource.
+func (ct *ConnectivityTest) prePullImages(ctx context.Context, client *k8s.Client, containers []corev1.Container) error {
+	images := make([]string, 0, len(containers))
+	for _, c := range containers {
+		images = append(images, c.Image)
+	}
+	ct.Logf("✨ [%s] Pre-pulling test images %s...", client.ClusterName(), images)
+
+	ds := ct.newPrePullDaemonSet(containers)
+	release := ct.throttleCreate(ctx)
+	_, err := client.CreateDaemonSet(ctx, ct.params.TestNamespace, ds, metav1.CreateOptions{})
+	release()
+	ct.logCreate(client, "daemonset", ds.Name, ds, err)
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return fmt.Errorf("unable to create daemonset %s: %w", ds.Name, err)
+	}
+	defer func() {
+		if err := client.DeleteDaemonSet(ctx, ct.params.TestNamespace, imagePrePullDaemonSetName, metav1.DeleteOptions{}); err != nil {
+			ct.Warnf("[%s] Unable to delete daemonset %s: %s", client.ClusterName(), imagePrePullDaemonSetName, err)
+		}
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, ct.params.podReadyTimeout())
+	defer cancel()
+	for {
+		err := ct.checkImagesPulled(waitCtx, client, len(containers))
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-time.After(ct.params.pollInterval()):
+		case <-waitCtx.Done():
+			return fmt.Errorf("timeout reached pre-pulling images %s (last error: %w)", images, err)
+		}
+	}
+}
+
+// checkImagesPulled returns nil if all the pods of the image pre-pull
+// DaemonSet have been scheduled and have run all their init containers, which
+// implies that the images are present on their node.
+func (ct *ConnectivityTest) checkImagesPulled(ctx context.Context, client *k8s.Client, containers int) error {
+	ds, err := ct.readClient(client).GetDaemonSet(ctx, ct.params.TestNamespace, imagePrePullDaemonSetName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	pods, err := ct.readClient(client).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindImagePrePull})
+	if err != nil {
+		return err
+	}
+
+	pulled := 0
+	for i := range pods.Items {
+		if initContainersCompleted(&pods.Items[i], containers) {
+			pulled++
+		}
+	}
+
+	desired := int(ds.Status.DesiredNumberScheduled)
+	if desired == 0 || pulled < desired {
+		return fmt.Errorf("images pulled on %d of %d nodes", pulled, desired)
+	}
+	return nil
+}
+
+// initContainersCompleted returns true if the given number of init containers
+// of the pod have run successfully.
+func initContainersCompleted(pod *corev1.Pod, containers int) bool {
+	completed := 0
+	for _, status := range pod.Status.InitContainerStatuses {
+		if t := status.State.Terminated; t != nil && t.ExitCode == 0 {
+			completed++
+		}
+	}
+	return completed == containers
+}
+
+// OptionalDeployment identifies a group of test workloads which are only
+// deployed if one of the enabled scenarios makes use of them, see
+// DeploymentScenario.
+type OptionalDeployment int
+
+const (
+	// DeployEchoOtherNode is the echo-other-node deployment and service.
+	DeployEchoOtherNode OptionalDeployment = iota
+	// DeployNodeWithoutCilium is the echo-external-node deployment and the
+	// host-netns pods on the nodes without Cilium.
+	DeployNodeWithoutCilium
+	// DeployIngress is the Ingress in front of the echo-same-node service.
+	DeployIngress
+	// DeployExternalNameService is the ExternalName service.
+	DeployExternalNameService
+	// DeployEchoLBService is the service selecting all echo pods.
+	DeployEchoLBService
+	// DeployEchoTopology is the echo-topology deployment and service.
+	DeployEchoTopology
+	// DeployEchoTLS is the echo-tls deployment and service.
+	DeployEchoTLS
+)
+
+// requiredOptionalDeployments returns the optional deployments needed by the
+// scenarios which will run. If no tests are registered, all of them are needed.
+func (ct *ConnectivityTest) requiredOptionalDeployments() map[OptionalDeployment]bool {
+	all := map[OptionalDeployment]bool{
+		DeployEchoOtherNode:       true,
+		DeployNodeWithoutCilium:   true,
+		DeployIngress:             true,
+		DeployExternalNameService: true,
+		DeployEchoLBService:       true,
+		DeployEchoTopology:        true,
+		DeployEchoTLS:             true,
+	}
+	if len(ct.tests) == 0 {
+		return all
+	}
+
+	required := map[OptionalDeployment]bool{}
+	for _, t := range ct.tests {
+		if !t.willRun() {
+			continue
+		}
+		for s := range t.scenarios {
+			if !t.scenarioEnabled(s) {
+				continue
+			}
+			ds, ok := s.(DeploymentScenario)
+			if !ok {
+				ct.Debugf("Scenario %s doesn't declare its required deployments, deploying all of them", s.Name())
+				return all
+			}
+			for _, d := range ds.RequiredDeployments() {
+				required[d] = true
+			}
+		}
+	}
+
+	return required
+}
+
+// deploy ensures the test Namespace, Services and Deployments are running on the cluster.
+func (ct *ConnectivityTest) deploy(ctx context.Context) error {
+	ct.optionalDeployments = ct.requiredOptionalDeployments()
+
+	// The performance tests only deploy to the local cluster.
+	clients := ct.clients.clients()
+	if ct.params.Perf {
+		clients = []*k8s.Client{ct.clients.src}
+	}
+
+	if ct.params.ForceDeploy {
+		if err := ct.forEachCluster(ctx, clients, ct.deleteDeployments); err != nil {
+			return err
+		}
+	}
+
+	if err := ct.forEachCluster(ctx, ct.clients.clients(), ct.checkLeftoverResources); err != nil {
+		return err
+	}
+
+	if err := ct.checkSchedulableNodes(); err != nil {
+		return err
+	}
+
+	if err := ct.forEachCluster(ctx, clients, ct.ensureNamespace); err != nil {
+		return err
+	}
+
+	var err error
+
+	if ct.params.Perf {
+		// For performance workloads, we want to ensure the client/server are in the same zone
+		n, hasNodes := ct.client.ListNodes(ctx, metav1.ListOptions{})
+		if hasNodes != nil {
 			return fmt.Errorf("unable to query nodes")
 		}
-		for _, l := range n.Items {
-			if _, ok := zones[l.GetLabels()[corev1.LabelTopologyZone]]; ok {
-				zone = l.GetLabels()[corev1.LabelTopologyZone]
-				break
+		zone, multiNode := selectPerfZone(n.Items)
+		switch {
+		case zone == "":
+			ct.Info("Nodes have no topology zone label, not pinning the performance workloads to a zone")
+		case !multiNode:
+			ct.Warn("Each zone only has a single node - could impact the performance test results")
+		}
+
+		if ct.params.PerfHostNet {
+			ct.Info("Deploying Perf deployments using host networking")
+		}
+
+		if ct.params.PrePullImages {
+			if err := ct.prePullImages(ctx, ct.clients.src, []corev1.Container{prePullContainer(ct.params.PerformanceImage)}); err != nil {
+				return err
+			}
+		}
+
+		nm := newPerfDeploymentNameManager(&ct.params)
+
+		// Need to capture the IP of the Server Deployment, and pass to the client to execute benchmark
+		_, err = ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, nm.ClientName(), metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying %s deployment...", ct.clients.src.ClusterName(), nm.ClientName())
+			perfClientDeployment := newDeployment(deploymentParameters{
+				Name:      nm.ClientName(),
+				Kind:      kindPerfName,
+				NamedPort: "http-80",
+				Port:      80,
+				Image:     ct.params.PerformanceImage,
+				Labels: map[string]string{
+					"client": "role",
+				},
+				Command: []string{ct.params.perfShell(), "-c", "sleep 10000000"},
+				Affinity: &corev1.Affinity{
+					NodeAffinity: perfZoneAffinity(zone, ct.params.perfZoneWeight(), ct.params.PerfZoneRequired),
+				},
+				NodeSelector:   ct.params.NodeSelector,
+				HostNetwork:    ct.params.PerfHostNet,
+				Resources:      ct.perfResources(),
+				DisableSAToken: ct.params.NoAutomountSAToken,
+				SeccompProfile: ct.params.seccompProfile(),
+			})
+			if err := ct.createServiceAccount(ctx, ct.clients.src, nm.ClientName()); err != nil {
+				return err
+			}
+			if err := ct.createDeployment(ctx, ct.clients.src, perfClientDeployment); err != nil {
+				return err
+			}
+		}
+
+		_, err = ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, nm.ServerName(), metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying %s deployment...", ct.clients.src.ClusterName(), nm.ServerName())
+			perfServerDeployment := newDeployment(deploymentParameters{
+				Name: nm.ServerName(),
+				Kind: kindPerfName,
+				Labels: map[string]string{
+					"server": "role",
+				},
+				Port:    5001,
+				Image:   ct.params.PerformanceImage,
+				Command: []string{ct.params.perfShell(), "-c", "netserver;sleep 10000000"},
+				Affinity: &corev1.Affinity{
+					NodeAffinity: perfZoneAffinity(zone, ct.params.perfZoneWeight(), ct.params.PerfZoneRequired),
+					PodAffinity: &corev1.PodAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+							{
+								LabelSelector: &metav1.LabelSelector{
+									MatchExpressions: []metav1.LabelSelectorRequirement{
+										{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{nm.ClientName()}},
+									},
+								},
+								TopologyKey: corev1.LabelHostname,
+							},
+						},
+					},
+				},
+				NodeSelector:   ct.params.NodeSelector,
+				HostNetwork:    ct.params.PerfHostNet,
+				Resources:      ct.perfResources(),
+				DisableSAToken: ct.params.NoAutomountSAToken,
+				SeccompProfile: ct.params.seccompProfile(),
+			})
+			if err := ct.createServiceAccount(ctx, ct.clients.src, nm.ServerName()); err != nil {
+				return err
+			}
+
+			if err := ct.createDeployment(ctx, ct.clients.src, perfServerDeployment); err != nil {
+				return err
+			}
+		}
+
+		// Deploy second client on a different node
+		if !ct.params.SingleNode {
+			_, err := ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, nm.ClientAcrossName(), metav1.GetOptions{})
+			if err != nil || ct.params.Reconcile {
+				ct.Logf("✨ [%s] Deploying %s deployment...", ct.clients.src.ClusterName(), nm.ClientAcrossName())
+				perfOtherClientDeployment := newDeployment(deploymentParameters{
+					Name: nm.ClientAcrossName(),
+					Kind: kindPerfName,
+					Port: 5001,
+					Labels: map[string]string{
+						"client": "role",
+					},
+					Image:   ct.params.PerformanceImage,
+					Command: []string{ct.params.perfShell(), "-c", "sleep 10000000"},
+					Affinity: &corev1.Affinity{
+						NodeAffinity: perfZoneAffinity(zone, ct.params.perfZoneWeight(), ct.params.PerfZoneRequired),
+						PodAntiAffinity: &corev1.PodAntiAffinity{
+							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+								{Weight: 100, PodAffinityTerm: corev1.PodAffinityTerm{
+									LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+										{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{nm.ClientName()}}}},
+									TopologyKey: corev1.LabelHostname}}}},
+					},
+					NodeSelector:   ct.params.NodeSelector,
+					HostNetwork:    ct.params.PerfHostNet,
+					Resources:      ct.perfResources(),
+					DisableSAToken: ct.params.NoAutomountSAToken,
+					SeccompProfile: ct.params.seccompProfile(),
+				})
+				if err := ct.createServiceAccount(ctx, ct.clients.src, nm.ClientAcrossName()); err != nil {
+					return err
+				}
+
+				if err := ct.createDeployment(ctx, ct.clients.src, perfOtherClientDeployment); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if ct.params.PrePullImages {
+		images := []string{ct.params.CurlImage, ct.params.JSONMockImage}
+		for _, image := range []string{ct.params.echoSameNodeImage(), ct.params.echoOtherNodeImage()} {
+			if !slices.Contains(images, image) {
+				images = append(images, image)
+			}
+		}
+		if ct.params.netemArgs() != "" || ct.params.EchoConnectionCounter {
+			images = append(images, ct.params.netemImage())
+		}
+		containers := make([]corev1.Container, 0, len(images)+1)
+		for _, image := range images {
+			containers = append(containers, prePullContainer(image))
+		}
+		if !ct.params.Minimal {
+			// The DNS test server image only ships coredns, which exits after
+			// printing its version.
+			containers = append(containers, corev1.Container{Image: ct.params.DNSTestServerImage, Args: []string{"-version"}})
+		}
+		err := ct.forEachCluster(ctx, clients, func(ctx context.Context, client *k8s.Client) error {
+			return ct.prePullImages(ctx, client, containers)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := ct.checkNodePortAvailability(ctx); err != nil {
+		return err
+	}
+
+	// The steps of each cluster are independent of the other clusters, but
+	// run in order within a cluster.
+	return ct.forEachCluster(ctx, clients, ct.deployCluster)
+}
+
+// deployCluster deploys the test workloads to the cluster of the given client.
+// The DNS test server sidecar is not deployed in the minimal profile.
+// Otherwise, the configmap it mounts must exist before any echo deployment is
+// created, or its pods get stuck creating.
+func (ct *ConnectivityTest) deployCluster(ctx context.Context, client *k8s.Client) error {
+	if !ct.params.Minimal {
+		if err := ct.ensureDNSConfigMap(ctx, client); err != nil {
+			return err
+		}
+	}
+	if client == ct.clients.src {
+		if err := ct.deploySrc(ctx, client); err != nil {
+			return err
+		}
+	}
+	if client == ct.clients.dst {
+		return ct.deployDst(ctx, client)
+	}
+	return nil
+}
+
+// deploySrc deploys the echo-same-node and client workloads, along with the
+// optional deployments, to the source cluster.
+func (ct *ConnectivityTest) deploySrc(ctx context.Context, client *k8s.Client) error {
+	_, err := client.GetService(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.GetOptions{})
+	if err != nil || ct.params.Reconcile {
+		ct.Logf("✨ [%s] Deploying %s service...", client.ClusterName(), echoSameNodeDeploymentName)
+		svc := ct.newEchoService(echoSameNodeDeploymentName)
+		if ct.params.MultiClusterBidirectional {
+			svc.ObjectMeta.Annotations["service.cilium.io/global"] = "true"
+			svc.ObjectMeta.Annotations["io.cilium/global-service"] = "true"
+		}
+		if err := ct.createService(ctx, client, svc); err != nil {
+			return err
+		}
+	}
+
+	if ct.params.MultiCluster != "" && ct.optionalDeployments[DeployEchoOtherNode] {
+		_, err = client.GetService(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying %s service...", client.ClusterName(), echoOtherNodeDeploymentName)
+			svc := ct.newEchoService(echoOtherNodeDeploymentName)
+			svc.ObjectMeta.Annotations["service.cilium.io/global"] = "true"
+			svc.ObjectMeta.Annotations["io.cilium/global-service"] = "true"
+
+			if err := ct.createService(ctx, client, svc); err != nil {
+				return err
+			}
+		}
+	}
+
+	hostPort := 0
+	if ct.features[FeatureHostPort].Enabled {
+		hostPort = EchoServerHostPort
+	}
+
+	_, err = client.GetDeployment(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.GetOptions{})
+	if err != nil || ct.params.Reconcile {
+		ct.Logf("✨ [%s] Deploying same-node deployment...", client.ClusterName())
+		containerPort := ct.params.echoPort()
+		echoParams := deploymentParameters{
+			Name:      echoSameNodeDeploymentName,
+			Kind:      kindEchoName,
+			Port:      containerPort,
+			NamedPort: ct.params.echoNamedPort(),
+			HostPort:  hostPort,
+			Image:     ct.params.echoSameNodeImage(),
+			Labels:    map[string]string{"other": "echo"},
+			Affinity: &corev1.Affinity{
+				PodAffinity: &corev1.PodAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+						{
+							LabelSelector: &metav1.LabelSelector{
+								MatchExpressions: []metav1.LabelSelectorRequirement{
+									{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{clientDeploymentName}},
+								},
+							},
+							TopologyKey: corev1.LabelHostname,
+						},
+					},
+				},
+			},
+			ReadinessProbe: newLocalReadinessProbe(containerPort, "/"),
+			DisableSAToken: ct.params.NoAutomountSAToken,
+			SeccompProfile: ct.params.seccompProfile(),
+		}
+		var echoDeployment *appsv1.Deployment
+		if ct.params.Minimal {
+			echoDeployment = newDeployment(echoParams)
+		} else {
+			echoDeployment = newDeploymentWithDNSTestServer(echoParams, ct.params.DNSTestServerImage, ct.params.dnsConfigMapName(), ct.params.dnsTestServerReadinessProbe())
+		}
+		if ct.params.EchoConnectionCounter {
+			echoDeployment = withConnectionCounter(echoDeployment, ct.params.netemImage(), ct.params.echoPort())
+		}
+		if ct.params.EchoStatusCode != 0 {
+			echoDeployment = withStatusServer(echoDeployment, ct.params.JSONMockImage, ct.params.EchoStatusCode)
+		}
+		echoDeployment = ct.withNetem(echoDeployment, kindEchoName)
+		if err := ct.createServiceAccount(ctx, client, echoSameNodeDeploymentName); err != nil {
+			return err
+		}
+		if err := ct.createDeployment(ctx, client, echoDeployment); err != nil {
+			return err
+		}
+	}
+
+	_, err = client.GetDeployment(ctx, ct.params.TestNamespace, clientDeploymentName, metav1.GetOptions{})
+	if err != nil || ct.params.Reconcile {
+		ct.Logf("✨ [%s] Deploying %s deployment...", client.ClusterName(), clientDeploymentName)
+		clientDeployment := newDeployment(deploymentParameters{
+			Name:           clientDeploymentName,
+			Kind:           kindClientName,
+			NamedPort:      "http-8080",
+			Port:           8080,
+			Image:          ct.params.CurlImage,
+			Command:        []string{ct.params.clientShell(), "-c", "sleep 10000000"},
+			NodeSelector:   ct.params.NodeSelector,
+			DisableSAToken: ct.params.NoAutomountSAToken,
+			SeccompProfile: ct.params.seccompProfile(),
+			Annotations:    ct.params.clientPodAnnotations(),
+		})
+		clientDeployment = ct.withNetem(clientDeployment, kindClientName)
+		if err := ct.createServiceAccount(ctx, client, clientDeploymentName); err != nil {
+			return err
+		}
+		if err := ct.createDeployment(ctx, client, clientDeployment); err != nil {
+			return err
+		}
+	}
+
+	if !ct.params.Minimal {
+		// 2nd client with label other=client
+		_, err = client.GetDeployment(ctx, ct.params.TestNamespace, client2DeploymentName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying %s deployment...", client.ClusterName(), client2DeploymentName)
+			clientDeployment := newDeployment(deploymentParameters{
+				Name:      client2DeploymentName,
+				Kind:      kindClientName,
+				NamedPort: "http-8080",
+				Port:      8080,
+				Image:     ct.params.CurlImage,
+				Command:   []string{ct.params.clientShell(), "-c", "sleep 10000000"},
+				Labels:    map[string]string{"other": "client"},
+				Affinity: &corev1.Affinity{
+					PodAffinity: &corev1.PodAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+							{
+								LabelSelector: &metav1.LabelSelector{
+									MatchExpressions: []metav1.LabelSelectorRequirement{
+										{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{clientDeploymentName}},
+									},
+								},
+								TopologyKey: corev1.LabelHostname,
+							},
+						},
+					},
+				},
+				NodeSelector:   ct.params.NodeSelector,
+				DisableSAToken: ct.params.NoAutomountSAToken,
+				SeccompProfile: ct.params.seccompProfile(),
+				Annotations:    ct.params.clientPodAnnotations(),
+			})
+			clientDeployment = ct.withNetem(clientDeployment, kindClientName)
+			if err := ct.createServiceAccount(ctx, client, client2DeploymentName); err != nil {
+				return err
+			}
+			if err := ct.createDeployment(ctx, client, clientDeployment); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ct.params.ClientDaemonSet {
+		_, err = client.GetDaemonSet(ctx, ct.params.TestNamespace, clientDaemonSetName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying %s daemonset...", client.ClusterName(), clientDaemonSetName)
+			if err := ct.createServiceAccount(ctx, client, clientDaemonSetName); err != nil {
+				return err
+			}
+			if err := ct.createDaemonSet(ctx, client, ct.newClientDaemonSet()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !ct.params.Minimal && (!ct.params.SingleNode || ct.params.MultiCluster != "") &&
+		ct.features[FeatureNodeWithoutCilium].Enabled && ct.optionalDeployments[DeployNodeWithoutCilium] {
+		_, err = client.GetDeployment(ctx, ct.params.TestNamespace, echoExternalNodeDeploymentName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying echo-external-node deployment...", client.ClusterName())
+			containerPort := 8080
+			echoExternalDeployment := newDeployment(deploymentParameters{
+				Name:           echoExternalNodeDeploymentName,
+				Kind:           kindEchoExternalNodeName,
+				Port:           containerPort,
+				NamedPort:      "http-8080",
+				HostPort:       echoExternalNodeHostPort,
+				Image:          ct.params.JSONMockImage,
+				Labels:         map[string]string{"external": "echo"},
+				NodeSelector:   nodeWithoutCiliumSelector,
+				ReadinessProbe: newLocalReadinessProbe(containerPort, "/"),
+				HostNetwork:    true,
+				Tolerations:    tolerateAllTaints,
+				DisableSAToken: ct.params.NoAutomountSAToken,
+				SeccompProfile: ct.params.seccompProfile(),
+			})
+			if err := ct.createServiceAccount(ctx, client, echoExternalNodeDeploymentName); err != nil {
+				return err
+			}
+			if err := ct.createDeployment(ctx, client, echoExternalDeployment); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ct.hostNetNSRequired() {
+		if err := ct.checkHostNetNSImages(ctx, client); err != nil {
+			return err
+		}
+		for _, ds := range ct.newHostNetNSDaemonSets() {
+			_, err = client.GetDaemonSet(ctx, ct.params.TestNamespace, ds.Name, metav1.GetOptions{})
+			if err != nil || ct.params.Reconcile {
+				ct.Logf("✨ [%s] Deploying %s daemonset...", client.ClusterName(), ds.Name)
+				if err := ct.createDaemonSet(ctx, client, ds); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if ct.params.EchoLBService && ct.optionalDeployments[DeployEchoOtherNode] && ct.optionalDeployments[DeployEchoLBService] {
+		_, err = client.GetService(ctx, ct.params.TestNamespace, echoLBServiceName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying %s service...", client.ClusterName(), echoLBServiceName)
+			if err := ct.createService(ctx, client, ct.newEchoLBService()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ct.params.EchoTopologyHints && ct.optionalDeployments[DeployEchoTopology] {
+		if err := ct.deployEchoTopology(ctx); err != nil {
+			return err
+		}
+	}
+
+	if ct.params.EchoTLS && ct.optionalDeployments[DeployEchoTLS] {
+		if err := ct.deployEchoTLS(ctx); err != nil {
+			return err
+		}
+	}
+
+	if ct.params.ExternalNameService && ct.optionalDeployments[DeployExternalNameService] {
+		_, err = client.GetService(ctx, ct.params.TestNamespace, externalNameServiceName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying %s service...", client.ClusterName(), externalNameServiceName)
+			svc := newExternalNameService(externalNameServiceName, map[string]string{"kind": kindExternalNameService}, ct.params.ExternalTarget)
+			ct.setOwnerReferences(svc)
+			if err := ct.createService(ctx, client, svc); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Create one Ingress service for echo deployment
+	if ct.features[FeatureIngressController].Enabled && !ct.params.Minimal && ct.optionalDeployments[DeployIngress] {
+		_, err = client.GetIngress(ctx, ct.params.TestNamespace, IngressServiceName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying Ingress resource...", client.ClusterName())
+			ingress := newIngress()
+			ct.setOwnerReferences(ingress)
+			if err := ct.createIngress(ctx, client, ingress); err != nil {
+				return err
+			}
+
+			ingressServiceName := fmt.Sprintf("cilium-ingress-%s", IngressServiceName)
+			ct.addService(ct.ingressService, ingressServiceName, Service{
+				Service: &corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: ingressServiceName,
+					},
+					Spec: corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{
+							{
+								Name:     "http",
+								Protocol: corev1.ProtocolTCP,
+								Port:     80,
+							},
+							{
+								Name:     "https",
+								Protocol: corev1.ProtocolTCP,
+								Port:     443,
+							},
+						},
+					},
+				},
+			}, true)
+		}
+	}
+	return nil
+}
+
+// deployDst deploys the echo-other-node workloads to the destination cluster,
+// as well as the client of the bidirectional multi-cluster tests.
+func (ct *ConnectivityTest) deployDst(ctx context.Context, client *k8s.Client) error {
+	var err error
+
+	// The clients in the remote cluster resolve the echo-same-node service
+	// locally, a global service must exist in each cluster.
+	if ct.params.MultiClusterBidirectional {
+		_, err = client.GetService(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying %s service...", client.ClusterName(), echoSameNodeDeploymentName)
+			svc := ct.newEchoService(echoSameNodeDeploymentName)
+			svc.ObjectMeta.Annotations["service.cilium.io/global"] = "true"
+			svc.ObjectMeta.Annotations["io.cilium/global-service"] = "true"
+
+			if err := ct.createService(ctx, client, svc); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ct.params.MultiClusterBidirectional {
+		_, err = client.GetDeployment(ctx, ct.params.TestNamespace, clientDeploymentName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying %s deployment...", client.ClusterName(), clientDeploymentName)
+			clientDeployment := newDeployment(deploymentParameters{
+				Name:           clientDeploymentName,
+				Kind:           kindClientName,
+				NamedPort:      "http-8080",
+				Port:           8080,
+				Image:          ct.params.CurlImage,
+				Command:        []string{ct.params.clientShell(), "-c", "sleep 10000000"},
+				NodeSelector:   ct.params.NodeSelector,
+				DisableSAToken: ct.params.NoAutomountSAToken,
+				SeccompProfile: ct.params.seccompProfile(),
+				Annotations:    ct.params.clientPodAnnotations(),
+			})
+			clientDeployment = ct.withNetem(clientDeployment, kindClientName)
+			if err := ct.createServiceAccount(ctx, client, clientDeploymentName); err != nil {
+				return err
+			}
+			if err := ct.createDeployment(ctx, client, clientDeployment); err != nil {
+				return err
+			}
+		}
+	}
+
+	hostPort := 0
+	if ct.features[FeatureHostPort].Enabled {
+		hostPort = EchoServerHostPort
+	}
+	if !ct.params.Minimal && (!ct.params.SingleNode || ct.params.MultiCluster != "") && ct.optionalDeployments[DeployEchoOtherNode] {
+		_, err = client.GetService(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying echo-other-node service...", client.ClusterName())
+			svc := ct.newEchoService(echoOtherNodeDeploymentName)
+
+			if ct.params.MultiCluster != "" {
+				svc.ObjectMeta.Annotations["service.cilium.io/global"] = "true"
+				svc.ObjectMeta.Annotations["io.cilium/global-service"] = "true"
+			}
+
+			if err := ct.createService(ctx, client, svc); err != nil {
+				return err
+			}
+		}
+
+		_, err = client.GetDeployment(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.GetOptions{})
+		if err != nil || ct.params.Reconcile {
+			ct.Logf("✨ [%s] Deploying other-node deployment...", client.ClusterName())
+			containerPort := ct.params.echoPort()
+			echoOtherNodeDeployment := newDeploymentWithDNSTestServer(deploymentParameters{
+				Name:      echoOtherNodeDeploymentName,
+				Kind:      kindEchoName,
+				NamedPort: ct.params.echoNamedPort(),
+				Port:      containerPort,
+				HostPort:  hostPort,
+				Image:     ct.params.echoOtherNodeImage(),
+				Labels:    map[string]string{"first": "echo"},
+				Affinity: &corev1.Affinity{
+					PodAntiAffinity: &corev1.PodAntiAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+							{
+								LabelSelector: &metav1.LabelSelector{
+									MatchExpressions: []metav1.LabelSelectorRequirement{
+										{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{clientDeploymentName}},
+									},
+								},
+								TopologyKey: corev1.LabelHostname,
+							},
+						},
+					},
+				},
+				NodeSelector:   ct.params.NodeSelector,
+				ReadinessProbe: newLocalReadinessProbe(containerPort, "/"),
+				DisableSAToken: ct.params.NoAutomountSAToken,
+				SeccompProfile: ct.params.seccompProfile(),
+			}, ct.params.DNSTestServerImage, ct.params.dnsConfigMapName(), ct.params.dnsTestServerReadinessProbe())
+			if ct.params.EchoConnectionCounter {
+				echoOtherNodeDeployment = withConnectionCounter(echoOtherNodeDeployment, ct.params.netemImage(), ct.params.echoPort())
+			}
+			if ct.params.EchoStatusCode != 0 {
+				echoOtherNodeDeployment = withStatusServer(echoOtherNodeDeployment, ct.params.JSONMockImage, ct.params.EchoStatusCode)
+			}
+			echoOtherNodeDeployment = ct.withNetem(echoOtherNodeDeployment, kindEchoName)
+			if err := ct.createServiceAccount(ctx, client, echoOtherNodeDeploymentName); err != nil {
+				return err
+			}
+			if err := ct.createDeployment(ctx, client, echoOtherNodeDeployment); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hostNetNSRequired returns true if the host-netns DaemonSets are deployed:
+// on multi-node runs for the tests targeting the nodes without Cilium, and
+// whenever the scenarios send requests from them with --client-source.
+func (ct *ConnectivityTest) hostNetNSRequired() bool {
+	if ct.params.clientSource() == kindHostNetNS {
+		return true
+	}
+	return !ct.params.Minimal && (!ct.params.SingleNode || ct.params.MultiCluster != "") &&
+		ct.features[FeatureNodeWithoutCilium].Enabled && ct.optionalDeployments[DeployNodeWithoutCilium]
+}
+
+// deploymentList returns 2 lists of Deployments to be used for running tests with.
+func (ct *ConnectivityTest) deploymentList() (srcList []string, dstList []string) {
+	if ct.params.Minimal {
+		return []string{clientDeploymentName, echoSameNodeDeploymentName}, nil
+	}
+
+	if !ct.params.Perf {
+		srcList = []string{clientDeploymentName, client2DeploymentName, echoSameNodeDeploymentName}
+	} else {
+		perfNm := newPerfDeploymentNameManager(&ct.params)
+		srcList = []string{perfNm.ClientName(), perfNm.ServerName()}
+		if !ct.params.SingleNode {
+			srcList = append(srcList, perfNm.ClientAcrossName())
+		}
+	}
+
+	if (ct.params.MultiCluster != "" || !ct.params.SingleNode) && !ct.params.Perf && ct.optionalDeployments[DeployEchoOtherNode] {
+		dstList = append(dstList, echoOtherNodeDeploymentName)
+	}
+
+	if ct.features[FeatureNodeWithoutCilium].Enabled && ct.optionalDeployments[DeployNodeWithoutCilium] {
+		dstList = append(dstList, echoExternalNodeDeploymentName)
+	}
+
+	if ct.params.MultiClusterBidirectional {
+		dstList = append(dstList, clientDeploymentName)
+	}
+
+	return srcList, dstList
+}
+
+func (ct *ConnectivityTest) deleteDeployments(ctx context.Context, client *k8s.Client) error {
+	ct.Logf("🔥 [%s] Deleting connectivity check deployments...", client.ClusterName())
+	// Deleting the namespace garbage collects all the resources in it, deleting
+	// them one by one first is slower but easier to follow when troubleshooting.
+	if !ct.params.DeleteNamespaceFirst {
+		_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, clientDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, client2DeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, echoTopologyDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteDaemonSet(ctx, ct.params.TestNamespace, clientDaemonSetName, metav1.DeleteOptions{})
+		_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, clientDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, client2DeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, clientDaemonSetName, metav1.DeleteOptions{})
+		_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, echoTopologyDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteService(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteService(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteService(ctx, ct.params.TestNamespace, echoTopologyDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteService(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteService(ctx, ct.params.TestNamespace, echoLBServiceName, metav1.DeleteOptions{})
+		_ = client.DeleteService(ctx, ct.params.TestNamespace, externalNameServiceName, metav1.DeleteOptions{})
+		_ = client.DeleteConfigMap(ctx, ct.params.TestNamespace, corednsConfigMapName, metav1.DeleteOptions{})
+		_ = client.DeleteConfigMap(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.DeleteOptions{})
+		_ = client.DeleteSecret(ctx, ct.params.TestNamespace, echoTLSDeploymentName, metav1.DeleteOptions{})
+		ct.deletePerfDeployments(ctx, client)
+	}
+	_ = ct.deleteNamespace(ctx, client)
+
+	_, err := client.GetNamespace(ctx, ct.params.TestNamespace, metav1.GetOptions{})
+	if err == nil {
+		ct.Logf("⌛ [%s] Waiting for namespace %s to disappear", client.ClusterName(), ct.params.TestNamespace)
+		waitCtx := ctx
+		if ct.params.NamespaceDeleteTimeout > 0 {
+			var cancel context.CancelFunc
+			waitCtx, cancel = context.WithTimeout(ctx, ct.params.NamespaceDeleteTimeout)
+			defer cancel()
+		}
+		for attempt := 1; err == nil; attempt++ {
+			select {
+			case <-time.After(ct.params.namespaceDeleteBackoff(attempt)):
+			case <-waitCtx.Done():
+				return fmt.Errorf("timeout reached waiting for namespace %s to disappear: %w", ct.params.TestNamespace, waitCtx.Err())
+			}
+			// Retry the namespace deletion in-case the previous delete was
+			// rejected, i.e. by yahoo/k8s-namespace-guard
+			if err := ct.deleteNamespace(waitCtx, client); isAdmissionRejection(err) {
+				return fmt.Errorf("unable to delete namespace %s: %w", ct.params.TestNamespace, err)
+			}
+			_, err = client.GetNamespace(waitCtx, ct.params.TestNamespace, metav1.GetOptions{})
+		}
+	}
+
+	if ct.params.VerifyCleanup {
+		return ct.verifyEndpointsCleanup(ctx, client)
+	}
+	return nil
+}
+
+// verifyEndpointsCleanup waits until neither CiliumEndpoints nor Cilium agent
+// endpoints remain for the pods of the deleted test namespace, as leaked
+// endpoints hold on to their identities and IPs.
+func (ct *ConnectivityTest) verifyEndpointsCleanup(ctx context.Context, client *k8s.Client) error {
+	ct.Logf("⌛ [%s] Waiting for the endpoints of namespace %s to be deleted...", client.ClusterName(), ct.params.TestNamespace)
+
+	ctx, cancel := context.WithTimeout(ctx, ct.params.ciliumEndpointTimeout())
+	defer cancel()
+
+	for {
+		leaked, err := ct.leftoverEndpoints(ctx, client)
+		if err == nil && len(leaked) == 0 {
+			return nil
+		}
+		if err == nil {
+			err = fmt.Errorf("endpoints of namespace %s not deleted: %s", ct.params.TestNamespace, strings.Join(leaked, ", "))
+		}
+
+		ct.Debugf("[%s] %s", client.ClusterName(), err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("[%s] timeout reached waiting for endpoints to be deleted (last error: %w)", client.ClusterName(), err)
+		case <-time.After(ct.params.pollInterval()):
+		}
+	}
+}
+
+// leftoverEndpoints returns the CiliumEndpoints in the test namespace and the
+// endpoints of the Cilium agents of the given cluster belonging to it.
+func (ct *ConnectivityTest) leftoverEndpoints(ctx context.Context, client *k8s.Client) ([]string, error) {
+	var leaked []string
+
+	ceps, err := client.ListCiliumEndpoints(ctx, ct.params.TestNamespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list CiliumEndpoints: %w", err)
+	}
+	for _, cep := range ceps.Items {
+		leaked = append(leaked, "CiliumEndpoint "+cep.Name)
+	}
+
+	for _, ciliumPod := range podsInCluster(ct.ciliumPods, client) {
+		stdout, err := client.ExecInPod(ctx, ciliumPod.Pod.Namespace, ciliumPod.Pod.Name,
+			defaults.AgentContainerName, []string{"cilium", "endpoint", "list", "-o", "json"})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list endpoints of %s: %w", ciliumPod.Name(), err)
+		}
+
+		var endpoints []*models.Endpoint
+		if err := json.Unmarshal(stdout.Bytes(), &endpoints); err != nil {
+			return nil, fmt.Errorf("unmarshaling endpoints of %s: %w", ciliumPod.Name(), err)
+		}
+		leaked = append(leaked, namespaceEndpoints(ciliumPod.Pod.Spec.NodeName, endpoints, ct.params.TestNamespace)...)
+	}
+
+	return leaked, nil
+}
+
+// namespaceEndpoints returns the endpoints of the Cilium agent on the given
+// node which belong to pods of the given namespace.
+func namespaceEndpoints(node string, endpoints []*models.Endpoint, namespace string) []string {
+	var out []string
+	for _, ep := range endpoints {
+		if ep == nil || ep.Status == nil || ep.Status.ExternalIdentifiers == nil {
+			continue
+		}
+		ids := ep.Status.ExternalIdentifiers
+		if ids.K8sNamespace == namespace {
+			out = append(out, fmt.Sprintf("endpoint %d of pod %s/%s on node %s", ep.ID, ids.K8sNamespace, ids.K8sPodName, node))
+		}
+	}
+	return out
+}
+
+// deletePerfDeployments deletes the perf deployments and their
+// ServiceAccounts, both with and without host networking, as the previous run
+// might have used the other variant.
+func (ct *ConnectivityTest) deletePerfDeployments(ctx context.Context, client *k8s.Client) {
+	for _, hostNet := range []bool{false, true} {
+		nm := newPerfDeploymentNameManager(&Parameters{PerfHostNet: hostNet})
+		for _, name := range []string{nm.ClientName(), nm.ClientAcrossName(), nm.ServerName()} {
+			_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, name, metav1.DeleteOptions{})
+			_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, name, metav1.DeleteOptions{})
+		}
+	}
+}
+
+// isAdmissionRejection returns true if err is the rejection of a request by
+// an admission webhook, such as a namespace guard. Webhooks deny requests
+// as Forbidden or Invalid, or with a reason-less BadRequest status when
+// the webhook does not set a status code.
+func isAdmissionRejection(err error) bool {
+	var apiStatus k8sErrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		return false
+	}
+	if k8sErrors.IsForbidden(err) || k8sErrors.IsInvalid(err) {
+		return true
+	}
+	status := apiStatus.Status()
+	return status.Code == http.StatusBadRequest && status.Reason == metav1.StatusReasonUnknown
+}
+
+// namespaceMetadataHint is appended to namespace errors caused by admission
+// webhooks when no namespace labels nor annotations are configured.
+const namespaceMetadataHint = "set --namespace-labels or --namespace-annotations to satisfy the admission webhook"
+
+// createNamespace creates the test namespace. If an admission webhook rejects
+// the creation, it is retried with the configured namespace labels and
+// annotations.
+func (ct *ConnectivityTest) createNamespace(ctx context.Context, client *k8s.Client) error {
+	err := ct.retryTransient(ctx, client, "namespace creation", func() error {
+		_, err := client.CreateNamespace(ctx, ct.params.TestNamespace, metav1.CreateOptions{})
+		return err
+	})
+	// A previous attempt may have succeeded despite the error.
+	if err == nil || k8sErrors.IsAlreadyExists(err) {
+		ct.recordCreated(client, "Namespace", "", ct.params.TestNamespace)
+		return nil
+	}
+	if !isAdmissionRejection(err) {
+		return err
+	}
+	if len(ct.params.NamespaceLabels) == 0 && len(ct.params.NamespaceAnnotations) == 0 {
+		return fmt.Errorf("%w (%s)", err, namespaceMetadataHint)
+	}
+
+	ct.Logf("🔄 [%s] Namespace creation rejected by an admission webhook, retrying with the configured labels and annotations...", client.ClusterName())
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ct.params.TestNamespace,
+			Labels:      ct.params.NamespaceLabels,
+			Annotations: ct.params.NamespaceAnnotations,
+		},
+	}
+	err = ct.retryTransient(ctx, client, "namespace creation", func() error {
+		_, err := client.CreateNamespaceObject(ctx, ns, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return fmt.Errorf("namespace creation still rejected with the configured labels and annotations: %w", err)
+	}
+	ct.recordCreated(client, "Namespace", "", ct.params.TestNamespace)
+	return nil
+}
+
+// retryTransient calls f until it succeeds, fails with an error which is not
+// transient, or NamespaceRetries retries have been made, waiting a bit longer
+// before each retry.
+func (ct *ConnectivityTest) retryTransient(ctx context.Context, client *k8s.Client, what string, f func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if err == nil || !isTransientError(err) || attempt >= ct.params.NamespaceRetries {
+			return err
+		}
+
+		ct.Logf("🔄 [%s] Transient error during %s, retrying (%d/%d): %s", client.ClusterName(), what, attempt+1, ct.params.NamespaceRetries, err)
+		select {
+		case <-time.After(time.Duration(attempt+1) * ct.params.pollInterval()):
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %s)", ctx.Err(), err)
+		}
+	}
+}
+
+// isTransientError returns true if err is likely to go away on retry, e.g. a
+// timeout of the API server or of an admission webhook it called, as opposed
+// to a rejection of the request.
+func isTransientError(err error) bool {
+	return k8sErrors.IsServerTimeout(err) || k8sErrors.IsTimeout(err) || k8sErrors.IsTooManyRequests(err) ||
+		k8sErrors.IsInternalError(err) || k8sErrors.IsServiceUnavailable(err) || k8sErrors.IsUnexpectedServerError(err)
+}
+
+// createServiceAccount creates the service account of the given name in the
+// test namespace, unless it already exists.
+func (ct *ConnectivityTest) createServiceAccount(ctx context.Context, client *k8s.Client, name string) error {
+	if ct.params.ServiceAccount != "" {
+		return nil
+	}
+
+	release := ct.throttleCreate(ctx)
+	_, err := client.CreateServiceAccount(ctx, ct.params.TestNamespace, k8s.NewServiceAccount(name), metav1.CreateOptions{})
+	release()
+	if k8sErrors.IsAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create service account %s: %s", name, err)
+	}
+	ct.recordCreated(client, "ServiceAccount", ct.params.TestNamespace, name)
+	return nil
+}
+
+// deleteNamespace deletes the test namespace. If an admission webhook rejects
+// the deletion, the configured namespace labels and annotations are added to
+// the namespace before retrying.
+func (ct *ConnectivityTest) deleteNamespace(ctx context.Context, client *k8s.Client) error {
+	err := client.DeleteNamespace(ctx, ct.params.TestNamespace, metav1.DeleteOptions{})
+	if !isAdmissionRejection(err) {
+		return err
+	}
+	if len(ct.params.NamespaceLabels) == 0 && len(ct.params.NamespaceAnnotations) == 0 {
+		return fmt.Errorf("%w (%s)", err, namespaceMetadataHint)
+	}
+
+	ct.Logf("🔄 [%s] Namespace deletion rejected by an admission webhook, retrying with the configured labels and annotations...", client.ClusterName())
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"labels":      ct.params.NamespaceLabels,
+			"annotations": ct.params.NamespaceAnnotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := client.PatchNamespace(ctx, ct.params.TestNamespace, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("unable to add labels and annotations to namespace %s: %w", ct.params.TestNamespace, err)
+	}
+	if err := client.DeleteNamespace(ctx, ct.params.TestNamespace, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("namespace deletion still rejected with the configured labels and annotations: %w", err)
+	}
+	return nil
+}
+
+// phaseTimer measures the cumulated time spent in each phase of a multi-step
+// operation, in the order the phases were first entered.
+type phaseTimer struct {
+	phases    []string
+	durations map[string]time.Duration
+
+	current string
+	started time.Time
+}
+
+// start ends the current phase, if any, and starts measuring the given one.
+func (t *phaseTimer) start(phase string) {
+	t.stop()
+	if t.durations == nil {
+		t.durations = make(map[string]time.Duration)
+	}
+	if _, ok := t.durations[phase]; !ok {
+		t.phases = append(t.phases, phase)
+		t.durations[phase] = 0
+	}
+	t.current = phase
+	t.started = time.Now()
+}
+
+// stop ends the current phase, if any.
+func (t *phaseTimer) stop() {
+	if t.current == "" {
+		return
+	}
+	t.durations[t.current] += time.Since(t.started)
+	t.current = ""
+}
+
+func (t *phaseTimer) String() string {
+	parts := make([]string, 0, len(t.phases))
+	for _, phase := range t.phases {
+		parts = append(parts, fmt.Sprintf("%s took %s", phase, t.durations[phase].Round(time.Millisecond)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// validateDeployment checks if the Deployments we created have the expected Pods in them.
+func (ct *ConnectivityTest) validateDeployment(ctx context.Context) error {
+	// Log how long each phase took, to help tuning the matching timeout if
+	// the validation is slow or fails.
+	var timer phaseTimer
+	defer func() {
+		timer.stop()
+		ct.Infof("Deployment validation phases: %s", &timer)
+		ct.setValidationPhases(&timer)
+	}()
+
+	ct.Debug("Validating Deployments...")
+
+	timer.start("deployment-ready")
+	srcDeployments, dstDeployments := ct.deploymentList()
+	if len(srcDeployments) > 0 {
+		if err := ct.waitForDeployments(ctx, ct.clients.src, srcDeployments); err != nil {
+			return err
+		}
+	}
+	if len(dstDeployments) > 0 {
+		if err := ct.waitForDeployments(ctx, ct.clients.dst, dstDeployments); err != nil {
+			return err
+		}
+	}
+	if ct.params.ClientDaemonSet && !ct.params.Perf {
+		if err := ct.waitForDaemonSet(ctx, ct.clients.src, clientDaemonSetName); err != nil {
+			return err
+		}
+	}
+	if ct.params.clientSource() == kindHostNetNS {
+		if err := ct.waitForHostNetNSDaemonSets(ctx); err != nil {
+			return err
+		}
+	}
+
+	if ct.params.Perf {
+		timer.start("cilium-endpoint")
+		perfPods, err := ct.readClient(ct.client).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindPerfName})
+		if err != nil {
+			return fmt.Errorf("unable to list perf pods: %w", err)
+		}
+		var scenarioPods []corev1.Pod
+		for _, perfPod := range perfPods.Items {
+			// Filter out existing perf pods in cilium-test based on scenario
+			if ct.params.PerfHostNet != perfPod.Spec.HostNetwork {
+				continue
+			}
+			scenarioPods = append(scenarioPods, perfPod)
+
+			// Individual endpoints will not be created for pods using node's network stack
+			if !ct.params.PerfHostNet {
+				ctx, cancel := context.WithTimeout(ctx, ct.params.ciliumEndpointTimeout())
+				defer cancel()
+				if _, err := ct.waitForCiliumEndpoint(ctx, ct.clients.src, ct.params.TestNamespace, perfPod.Name); err != nil {
+					return err
+				}
+			}
+			if err := validateServiceAccount(&perfPod, ct.params.ServiceAccount); err != nil {
+				return err
+			}
+			_, hasLabel := perfPod.GetLabels()["server"]
+			if hasLabel {
+				ct.addPod(ct.perfServerPod, perfPod.Name, Pod{
+					K8sClient: ct.client,
+					Pod:       perfPod.DeepCopy(),
+					port:      5201,
+				})
+			} else {
+				ct.addPod(ct.perfClientPods, perfPod.Name, Pod{
+					K8sClient: ct.client,
+					Pod:       perfPod.DeepCopy(),
+				})
+			}
+		}
+
+		timer.start("perf-zone")
+		return ct.checkPerfZones(ctx, scenarioPods)
+	}
+
+	timer.start("cilium-endpoint")
+	clientPods, err := ct.readClient(ct.client).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: ct.params.clientSelector()})
+	if err != nil {
+		return fmt.Errorf("unable to list client pods: %s", err)
+	}
+	if len(clientPods.Items) == 0 {
+		return fmt.Errorf("no client pods match selector %q", ct.params.clientSelector())
+	}
+
+	for _, pod := range clientPods.Items {
+		ctx, cancel := context.WithTimeout(ctx, ct.params.ciliumEndpointTimeout())
+		defer cancel()
+		cep, err := ct.waitForCiliumEndpoint(ctx, ct.clients.src, ct.params.TestNamespace, pod.Name)
+		if err != nil {
+			return err
+		}
+		// Pods matched by a custom --client-selector may have been deployed
+		// by the user, with their own ServiceAccount and image.
+		if isSuiteClientPod(&pod) {
+			if err := validateServiceAccount(&pod, ct.params.ServiceAccount); err != nil {
+				return err
+			}
+			if err := ct.checkPodImage(ct.client, &pod, ct.params.CurlImage); err != nil {
+				return err
 			}
-			zones[l.GetLabels()[corev1.LabelTopologyZone]] = 1
-			lz = l.GetLabels()[corev1.LabelTopologyZone]
 		}
-		// No zone had > 1, use the last zone.
-		if zone == "" {
-			ct.Warn("Each zone only has a single node - could impact the performance test results")
-			zone = lz
+
+		clientPod := Pod{
+			K8sClient: ct.client,
+			Pod:       pod.DeepCopy(),
 		}
+		ct.addPod(ct.clientPods, pod.Name, clientPod)
+		ct.addEndpointAddressing(clientPod, cep)
+	}
 
-		if ct.params.PerfHostNet {
-			ct.Info("Deploying Perf deployments using host networking")
+	if ct.params.MultiClusterBidirectional {
+		remoteClientPods, err := ct.readClient(ct.clients.dst).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "name=" + clientDeploymentName})
+		if err != nil {
+			return fmt.Errorf("unable to list remote client pods: %w", err)
 		}
+		for _, pod := range remoteClientPods.Items {
+			ctx, cancel := context.WithTimeout(ctx, ct.params.ciliumEndpointTimeout())
+			defer cancel()
+			cep, err := ct.waitForCiliumEndpoint(ctx, ct.clients.dst, ct.params.TestNamespace, pod.Name)
+			if err != nil {
+				return err
+			}
+			if err := validateServiceAccount(&pod, ct.params.ServiceAccount); err != nil {
+				return err
+			}
+			if err := ct.checkPodImage(ct.clients.dst, &pod, ct.params.CurlImage); err != nil {
+				return err
+			}
 
-		nm := newPerfDeploymentNameManager(&ct.params)
+			clientPod := Pod{
+				K8sClient: ct.clients.dst,
+				Pod:       pod.DeepCopy(),
+			}
+			ct.addPod(ct.clientPods, pod.Name, clientPod)
+			ct.addEndpointAddressing(clientPod, cep)
+		}
+	}
 
-		// Need to capture the IP of the Server Deployment, and pass to the client to execute benchmark
-		_, err = ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, nm.ClientName(), metav1.GetOptions{})
+	sameNodePods, err := ct.readClient(ct.clients.src).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "name=" + echoSameNodeDeploymentName})
+	if err != nil {
+		return fmt.Errorf("unable to list same node pods: %w", err)
+	}
+	if len(sameNodePods.Items) != 1 {
+		return fmt.Errorf("unexpected number of same node pods: %d", len(sameNodePods.Items))
+	}
+	sameNodePod := Pod{
+		Pod: sameNodePods.Items[0].DeepCopy(),
+	}
+
+	if ct.features[FeatureNodeWithoutCilium].Enabled && !ct.params.Minimal && ct.optionalDeployments[DeployNodeWithoutCilium] {
+		echoExternalNodePods, err := ct.readClient(ct.clients.dst).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "name=" + echoExternalNodeDeploymentName})
 		if err != nil {
-			ct.Logf("✨ [%s] Deploying %s deployment...", ct.clients.src.ClusterName(), nm.ClientName())
-			perfClientDeployment := newDeployment(deploymentParameters{
-				Name:      nm.ClientName(),
-				Kind:      kindPerfName,
-				NamedPort: "http-80",
-				Port:      80,
-				Image:     ct.params.PerformanceImage,
-				Labels: map[string]string{
-					"client": "role",
-				},
-				Command: []string{"/bin/bash", "-c", "sleep 10000000"},
-				Affinity: &corev1.Affinity{
-					NodeAffinity: &corev1.NodeAffinity{
-						PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
-							{
-								Weight: 100,
-								Preference: corev1.NodeSelectorTerm{
-									MatchExpressions: []corev1.NodeSelectorRequirement{
-										{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{zone}},
-									},
-								},
-							},
-						},
-					},
-				},
-				NodeSelector: ct.params.NodeSelector,
-				HostNetwork:  ct.params.PerfHostNet,
+			return fmt.Errorf("unable to list other node pods: %w", err)
+		}
+
+		for _, pod := range echoExternalNodePods.Items {
+			ct.addPod(ct.echoExternalPods, pod.Name, Pod{
+				K8sClient: ct.client,
+				Pod:       pod.DeepCopy(),
+				scheme:    "http",
+				port:      8080, // listen port of the echo server inside the container
 			})
-			_, err = ct.clients.src.CreateServiceAccount(ctx, ct.params.TestNamespace, k8s.NewServiceAccount(nm.ClientName()), metav1.CreateOptions{})
+		}
+	}
+
+	timer.start("dns")
+	if !ct.params.Minimal {
+		sameNodeDNSCtx, sameNodeDNSCancel := context.WithTimeout(ctx, ct.params.dnsLookupTimeout())
+		defer sameNodeDNSCancel()
+		for _, cp := range ct.ClientPods() {
+			err := ct.waitForPodDNS(sameNodeDNSCtx, cp, sameNodePod)
 			if err != nil {
-				return fmt.Errorf("unable to create service account %s: %s", nm.ClientName(), err)
+				return err
 			}
-			_, err = ct.clients.src.CreateDeployment(ctx, ct.params.TestNamespace, perfClientDeployment, metav1.CreateOptions{})
+		}
+	}
+
+	if !ct.params.Minimal && (!ct.params.SingleNode || ct.params.MultiCluster != "") && ct.optionalDeployments[DeployEchoOtherNode] {
+		otherNodePods, err := ct.readClient(ct.clients.dst).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "name=" + echoOtherNodeDeploymentName})
+		if err != nil {
+			return fmt.Errorf("unable to list other node pods: %w", err)
+		}
+		if len(otherNodePods.Items) != 1 {
+			return fmt.Errorf("unexpected number of other node pods: %d", len(otherNodePods.Items))
+		}
+		otherNodePod := Pod{
+			Pod: otherNodePods.Items[0].DeepCopy(),
+		}
+
+		otherNodeDNSCtx, otherNodeDNSCancel := context.WithTimeout(ctx, ct.params.dnsLookupTimeout())
+		defer otherNodeDNSCancel()
+		for _, cp := range ct.ClientPods() {
+			err := ct.waitForPodDNS(otherNodeDNSCtx, cp, otherNodePod)
 			if err != nil {
-				return fmt.Errorf("unable to create deployment %s: %w", perfClientDeployment, err)
+				return err
 			}
 		}
+	}
 
-		_, err = ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, nm.ServerName(), metav1.GetOptions{})
+	svcDNSCtx, svcDNSCancel := context.WithTimeout(ctx, ct.params.dnsLookupTimeout())
+	defer svcDNSCancel()
+	for _, cp := range ct.ClientPods() {
+		start := time.Now()
+		err := ct.waitForServiceDNS(svcDNSCtx, cp)
+		ct.recordSetupStep(fmt.Sprintf("[%s] service DNS from %s", cp.K8sClient.ClusterName(), cp.Name()), start, err)
 		if err != nil {
-			ct.Logf("✨ [%s] Deploying %s deployment...", ct.clients.src.ClusterName(), nm.ServerName())
-			perfServerDeployment := newDeployment(deploymentParameters{
-				Name: nm.ServerName(),
-				Kind: kindPerfName,
-				Labels: map[string]string{
-					"server": "role",
-				},
-				Port:    5001,
-				Image:   ct.params.PerformanceImage,
-				Command: []string{"/bin/bash", "-c", "netserver;sleep 10000000"},
-				Affinity: &corev1.Affinity{
-					NodeAffinity: &corev1.NodeAffinity{
-						PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
-							{
-								Weight: 100,
-								Preference: corev1.NodeSelectorTerm{
-									MatchExpressions: []corev1.NodeSelectorRequirement{
-										{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{zone}},
-									},
-								},
-							},
-						},
-					},
-					PodAffinity: &corev1.PodAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
-							{
-								LabelSelector: &metav1.LabelSelector{
-									MatchExpressions: []metav1.LabelSelectorRequirement{
-										{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{nm.ClientName()}},
-									},
-								},
-								TopologyKey: corev1.LabelHostname,
-							},
-						},
-					},
-				},
-				NodeSelector: ct.params.NodeSelector,
-				HostNetwork:  ct.params.PerfHostNet,
-			})
-			_, err = ct.clients.src.CreateServiceAccount(ctx, ct.params.TestNamespace, k8s.NewServiceAccount(nm.ServerName()), metav1.CreateOptions{})
+			return err
+		}
+	}
+
+	timer.start("echo-endpoint")
+	for _, client := range ct.clients.clients() {
+		echoPods, err := ct.readClient(client).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindEchoName})
+		if err != nil {
+			return fmt.Errorf("unable to list echo pods: %w", err)
+		}
+		var endpoints []*ciliumv2.CiliumEndpoint
+		for _, echoPod := range echoPods.Items {
+			ctx, cancel := context.WithTimeout(ctx, ct.params.ciliumEndpointTimeout())
+			defer cancel()
+			cep, err := ct.waitForCiliumEndpoint(ctx, client, ct.params.TestNamespace, echoPod.Name)
 			if err != nil {
-				return fmt.Errorf("unable to create service account %s: %s", nm.ServerName(), err)
+				return err
+			}
+			endpoints = append(endpoints, cep)
+			if err := validateServiceAccount(&echoPod, ct.params.ServiceAccount); err != nil {
+				return err
+			}
+			if err := ct.checkPodImage(client, &echoPod, ct.params.echoImage(echoPod.Labels["name"])); err != nil {
+				return err
 			}
 
-			_, err = ct.clients.src.CreateDeployment(ctx, ct.params.TestNamespace, perfServerDeployment, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("unable to create deployment %s: %w", perfServerDeployment, err)
+			pod := Pod{
+				K8sClient: client,
+				Pod:       echoPod.DeepCopy(),
+				scheme:    "http",
+				port:      uint32(ct.params.echoPort()), // listen port of the echo server inside the container
 			}
+			ct.addPod(ct.echoPods, echoPod.Name, pod)
+			ct.addEndpointAddressing(pod, cep)
 		}
+		if err := validateUniqueEndpointIPs(endpoints); err != nil {
+			return fmt.Errorf("[%s] %w", client.ClusterName(), err)
+		}
+	}
 
-		// Deploy second client on a different node
-		if !ct.params.SingleNode {
-			_, err := ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, nm.ClientAcrossName(), metav1.GetOptions{})
-			if err != nil {
-				ct.Logf("✨ [%s] Deploying %s deployment...", ct.clients.src.ClusterName(), nm.ClientAcrossName())
-				perfOtherClientDeployment := newDeployment(deploymentParameters{
-					Name: nm.ClientAcrossName(),
-					Kind: kindPerfName,
-					Port: 5001,
-					Labels: map[string]string{
-						"client": "role",
-					},
-					Image:   ct.params.PerformanceImage,
-					Command: []string{"/bin/bash", "-c", "sleep 10000000"},
-					Affinity: &corev1.Affinity{
-						NodeAffinity: &corev1.NodeAffinity{
-							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
-								{
-									Weight: 100,
-									Preference: corev1.NodeSelectorTerm{
-										MatchExpressions: []corev1.NodeSelectorRequirement{
-											{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{zone}},
-										},
-									},
-								},
-							},
-						},
-						PodAntiAffinity: &corev1.PodAntiAffinity{
-							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
-								{Weight: 100, PodAffinityTerm: corev1.PodAffinityTerm{
-									LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
-										{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{nm.ClientName()}}}},
-									TopologyKey: corev1.LabelHostname}}}},
-					},
-					NodeSelector: ct.params.NodeSelector,
-					HostNetwork:  ct.params.PerfHostNet,
-				})
-				_, err = ct.clients.src.CreateServiceAccount(ctx, ct.params.TestNamespace, k8s.NewServiceAccount(nm.ClientAcrossName()), metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("unable to create service account %s: %s", nm.ClientAcrossName(), err)
-				}
+	if len(ct.params.ExpectedPodCIDRs) > 0 {
+		if err := ct.validatePodCIDRs(); err != nil {
+			return err
+		}
+	}
+
+	timer.start("service")
+	for _, client := range ct.clients.clients() {
+		echoServices, err := ct.readClient(client).ListServices(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindEchoName})
+		if err != nil {
+			return fmt.Errorf("unable to list echo services: %w", err)
+		}
+
+		for _, echoService := range echoServices.Items {
+			if err := ct.waitForServiceEndpoints(ctx, client, &echoService); err != nil {
+				return err
+			}
+			if err := ct.waitForEndpointSlices(ctx, client, &echoService, ct.echoBackends(client, &echoService)); err != nil {
+				return err
+			}
+
+			// In multi-cluster mode, ct.clients.clients() lists the client cluster
+			// first. If we already have this service (for the client cluster), keep
+			// it so that we can rely on the service's ClusterIP being valid for the
+			// client pods.
+			ct.addService(ct.echoServices, echoService.Name, Service{
+				Service: echoService.DeepCopy(),
+			}, ct.params.MultiCluster == "")
+		}
+	}
+
+	for _, s := range ct.EchoServices() {
+		start := time.Now()
+		err := ct.waitForService(ctx, s)
+		ct.recordSetupStep("service "+s.Name(), start, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ct.params.ExternalNameService && ct.optionalDeployments[DeployExternalNameService] {
+		svc, err := ct.readClient(ct.clients.src).GetService(ctx, ct.params.TestNamespace, externalNameServiceName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("unable to get service %s: %w", externalNameServiceName, err)
+		}
+		s := Service{Service: svc}
+		start := time.Now()
+		err = ct.waitForService(ctx, s)
+		ct.recordSetupStep("service "+s.Name(), start, err)
+		if err != nil {
+			return err
+		}
+		ct.addService(ct.extNameServices, svc.Name, s, true)
+	}
+
+	if ct.params.EchoLBService && ct.optionalDeployments[DeployEchoOtherNode] && ct.optionalDeployments[DeployEchoLBService] {
+		svc, err := ct.readClient(ct.clients.src).GetService(ctx, ct.params.TestNamespace, echoLBServiceName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("unable to get service %s: %w", echoLBServiceName, err)
+		}
+		s := Service{Service: svc}
+		start := time.Now()
+		err = ct.waitForService(ctx, s)
+		ct.recordSetupStep("service "+s.Name(), start, err)
+		if err != nil {
+			return err
+		}
+		ct.addService(ct.echoLBServices, svc.Name, s, true)
+	}
+
+	if ct.params.EchoTopologyHints && ct.optionalDeployments[DeployEchoTopology] {
+		if err := ct.validateEchoTopology(ctx); err != nil {
+			return err
+		}
+	}
+
+	if ct.params.EchoTLS && ct.optionalDeployments[DeployEchoTLS] {
+		if err := ct.validateEchoTLS(ctx); err != nil {
+			return err
+		}
+	}
+
+	apiService, err := ct.readClient(ct.clients.src).GetService(ctx, metav1.NamespaceDefault, kubernetesServiceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get service %s/%s: %w", metav1.NamespaceDefault, kubernetesServiceName, err)
+	}
+	ct.addService(ct.apiServices, apiService.Name, Service{Service: apiService}, true)
+
+	if ct.features[FeatureIngressController].Enabled && !ct.params.Minimal && ct.optionalDeployments[DeployIngress] {
+		ingressServices, err := ct.readClient(ct.clients.src).ListServices(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "cilium.io/ingress=true"})
+		if err != nil {
+			return fmt.Errorf("unable to list ingress services: %w", err)
+		}
+
+		for _, ingressService := range ingressServices.Items {
+			// The Cilium ingress services have no selector, their single
+			// endpoint is managed by the operator and redirects to the
+			// proxy, which forwards to the echo service waited for above.
+			if err := ct.waitForEndpointSlices(ctx, ct.clients.src, &ingressService, 1); err != nil {
+				return err
+			}
+			ct.addService(ct.ingressService, ingressService.Name, Service{
+				Service: ingressService.DeepCopy(),
+			}, true)
+		}
+	}
+
+	echoServices, ingressServices := ct.EchoServices(), ct.IngressService()
+	services := make([]Service, 0, len(echoServices)+len(ingressServices))
+	for _, m := range []map[string]Service{echoServices, ingressServices} {
+		for _, s := range m {
+			services = append(services, s)
+		}
+	}
+	if err := validateUniqueNodePorts(services); err != nil {
+		return fmt.Errorf("[%s] %w", ct.clients.src.ClusterName(), err)
+	}
 
-				_, err = ct.clients.src.CreateDeployment(ctx, ct.params.TestNamespace, perfOtherClientDeployment, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("unable to create deployment %s: %s", perfOtherClientDeployment, err)
+	timer.start("nodeport")
+	if ct.params.MultiCluster == "" {
+		for _, ciliumPod := range ct.ciliumPods {
+			hostIP := ciliumPod.Pod.Status.HostIP
+			for _, s := range ct.EchoServices() {
+				nodeIPs := []string{hostIP}
+				if ct.params.NodePortAllAddresses {
+					nodeIPs = nodePortAddresses(hostIP, ct.nodes[ciliumPod.Pod.Spec.NodeName], s.Service.Spec.IPFamilies)
+				}
+				for _, nodeIP := range nodeIPs {
+					if err := ct.waitForNodePorts(ctx, nodeIP, s); err != nil {
+						return err
+					}
 				}
 			}
 		}
-		return nil
 	}
 
-	if ct.params.MultiCluster != "" {
-		if ct.params.ForceDeploy {
-			if err := ct.deleteDeployments(ctx, ct.clients.dst); err != nil {
+	timer.start("hostport")
+	if ct.params.MultiCluster == "" && ct.features[FeatureHostPort].Enabled {
+		for _, echoPod := range ct.EchoPods() {
+			if err := ct.waitForHostPort(ctx, echoPod, EchoServerHostPort); err != nil {
 				return err
 			}
 		}
-
-		_, err = ct.clients.dst.GetNamespace(ctx, ct.params.TestNamespace, metav1.GetOptions{})
+	}
+	// The external scenarios connect to the echo pods on the nodes without
+	// Cilium through their HostPort, regardless of the HostPort support of
+	// Cilium, so make sure it's reachable before running them.
+	for _, echoPod := range ct.ExternalEchoPods() {
+		start := time.Now()
+		err := ct.waitForHostPort(ctx, echoPod, echoExternalNodeHostPort)
+		ct.recordSetupStep(fmt.Sprintf("[%s] HostPort of %s", ct.client.ClusterName(), echoPod.Name()), start, err)
 		if err != nil {
-			ct.Logf("✨ [%s] Creating namespace %s for connectivity check...", ct.clients.dst.ClusterName(), ct.params.TestNamespace)
-			_, err = ct.clients.dst.CreateNamespace(ctx, ct.params.TestNamespace, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("unable to create namespace %s: %s", ct.params.TestNamespace, err)
-			}
+			return err
 		}
 	}
 
-	_, err = ct.clients.src.GetService(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.GetOptions{})
-	if err != nil {
-		ct.Logf("✨ [%s] Deploying %s service...", ct.clients.src.ClusterName(), echoSameNodeDeploymentName)
-		svc := newService(echoSameNodeDeploymentName, map[string]string{"name": echoSameNodeDeploymentName}, serviceLabels, "http", 8080)
-		_, err = ct.clients.src.CreateService(ctx, ct.params.TestNamespace, svc, metav1.CreateOptions{})
+	// The readiness probe of the echo pods isn't necessarily checked through
+	// the path taken by the tests, so make sure the echo server is actually
+	// serving on the port the tests connect to.
+	timer.start("echo-http")
+	for _, echoPod := range ct.EchoPods() {
+		start := time.Now()
+		err := ct.waitForEchoHTTP(ctx, echoPod)
+		ct.recordSetupStep(fmt.Sprintf("[%s] echo HTTP of %s", echoPod.K8sClient.ClusterName(), echoPod.Name()), start, err)
 		if err != nil {
 			return err
 		}
 	}
 
-	if ct.params.MultiCluster != "" {
-		_, err = ct.clients.src.GetService(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.GetOptions{})
-		if err != nil {
-			ct.Logf("✨ [%s] Deploying %s service...", ct.clients.src.ClusterName(), echoOtherNodeDeploymentName)
-			svc := newService(echoOtherNodeDeploymentName, map[string]string{"name": echoOtherNodeDeploymentName}, serviceLabels, "http", 8080)
-			svc.ObjectMeta.Annotations = map[string]string{}
-			svc.ObjectMeta.Annotations["service.cilium.io/global"] = "true"
-			svc.ObjectMeta.Annotations["io.cilium/global-service"] = "true"
+	timer.stop()
+
+	hostNetNSPods, err := ct.readClient(ct.client).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindHostNetNS})
+	if err != nil {
+		return fmt.Errorf("unable to list host netns pods: %w", err)
+	}
 
-			_, err = ct.clients.src.CreateService(ctx, ct.params.TestNamespace, svc, metav1.CreateOptions{})
+	for _, pod := range hostNetNSPods.Items {
+		ct.addPod(ct.hostNetNSPodsByNode, pod.Spec.NodeName, Pod{
+			K8sClient: ct.client,
+			Pod:       pod.DeepCopy(),
+		})
+	}
+
+	if err := ct.initExternalWorkloads(ctx); err != nil {
+		return err
+	}
+
+	// TODO: unconditionally re-enable the IPCache check once
+	// https://github.com/cilium/cilium-cli/issues/361 is resolved.
+	if ct.params.SkipIPCacheCheck {
+		ct.Infof("Skipping IPCache check")
+	} else {
+		timer.start("ipcache")
+		// Set the timeout for all IP cache lookup retries
+		ipCacheCtx, cancel := context.WithTimeout(ctx, ct.params.ipCacheTimeout())
+		defer cancel()
+		for _, cp := range ct.ciliumPods {
+			start := time.Now()
+			err := ct.waitForIPCache(ipCacheCtx, cp)
+			ct.recordSetupStep(fmt.Sprintf("[%s] ipcache of %s", cp.K8sClient.ClusterName(), cp.Name()), start, err)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	hostPort := 0
-	if ct.features[FeatureHostPort].Enabled {
-		hostPort = EchoServerHostPort
-	}
-	dnsConfigMap := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: corednsConfigMapName,
-		},
-		Data: map[string]string{
-			"Corefile": `. {
-				local
-				ready
-				log
-			}`,
-		},
+	return nil
+}
+
+// isSuiteClientPod returns whether the given pod was deployed by the tests,
+// as part of one of the client deployments or of the client daemonset.
+func isSuiteClientPod(pod *corev1.Pod) bool {
+	if pod.Labels["kind"] != kindClientName {
+		return false
 	}
-	_, err = ct.clients.src.GetConfigMap(ctx, ct.params.TestNamespace, corednsConfigMapName, metav1.GetOptions{})
-	if err != nil {
-		ct.Logf("✨ [%s] Deploying DNS test server configmap...", ct.clients.src.ClusterName())
-		_, err = ct.clients.src.CreateConfigMap(ctx, ct.params.TestNamespace, dnsConfigMap, metav1.CreateOptions{})
-		if err != nil {
-			return fmt.Errorf("unable to create configmap %s: %s", corednsConfigMapName, err)
+	for _, owner := range pod.OwnerReferences {
+		switch owner.Kind {
+		case "ReplicaSet":
+			// The ReplicaSets of a deployment are named after it.
+			for _, name := range []string{clientDeploymentName, client2DeploymentName} {
+				if strings.HasPrefix(owner.Name, name+"-") && pod.Labels["name"] == name {
+					return true
+				}
+			}
+		case "DaemonSet":
+			if owner.Name == clientDaemonSetName {
+				return true
+			}
 		}
 	}
-	if ct.params.MultiCluster != "" {
-		_, err = ct.clients.dst.GetConfigMap(ctx, ct.params.TestNamespace, corednsConfigMapName, metav1.GetOptions{})
-		if err != nil {
-			ct.Logf("✨ [%s] Deploying DNS test server configmap...", ct.clients.dst.ClusterName())
-			_, err = ct.clients.dst.CreateConfigMap(ctx, ct.params.TestNamespace, dnsConfigMap, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("unable to create configmap %s: %s", corednsConfigMapName, err)
+	return false
+}
+
+// validateServiceAccount checks that the pod runs with the ServiceAccount
+// created for its deployment, which is named after the deployment itself, or
+// with the given ServiceAccount if not empty.
+func validateServiceAccount(pod *corev1.Pod, serviceAccount string) error {
+	expected := pod.Labels["name"]
+	if serviceAccount != "" {
+		expected = serviceAccount
+	}
+	if pod.Spec.ServiceAccountName != expected {
+		return fmt.Errorf("pod %s uses ServiceAccount %q instead of the expected %q, "+
+			"an admission webhook might be mutating the pod spec", pod.Name, pod.Spec.ServiceAccountName, expected)
+	}
+	return nil
+}
+
+// initExternalWorkloads fetches the CiliumExternalWorkloads from all clients,
+// unless the user asked to skip them.
+func (ct *ConnectivityTest) initExternalWorkloads(ctx context.Context) error {
+	if ct.params.SkipExternalWorkloads {
+		ct.Info("Skipping CiliumExternalWorkloads listing, disabling external workload tests")
+		return nil
+	}
+
+	var logOnce sync.Once
+	for _, client := range ct.clients.clients() {
+		externalWorkloads, err := ct.readClient(client).ListCiliumExternalWorkloads(ctx, metav1.ListOptions{})
+		if k8sErrors.IsNotFound(err) {
+			logOnce.Do(func() {
+				ct.Log("ciliumexternalworkloads.cilium.io is not defined. Disabling external workload tests")
+			})
+			continue
+		} else if err != nil {
+			return fmt.Errorf("unable to list external workloads: %w", err)
+		}
+		for _, externalWorkload := range externalWorkloads.Items {
+			ct.externalWorkloads[externalWorkload.Name] = ExternalWorkload{
+				workload: externalWorkload.DeepCopy(),
 			}
 		}
 	}
 
-	_, err = ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.GetOptions{})
+	return nil
+}
+
+// EchoConnectionCount returns the number of TCP connections opened so far to
+// the echo server port of the given echo pod, as counted by its connection
+// counter sidecar.
+func (ct *ConnectivityTest) EchoConnectionCount(ctx context.Context, pod Pod) (uint64, error) {
+	if !ct.params.EchoConnectionCounter {
+		return 0, fmt.Errorf("connection counter sidecar is not enabled")
+	}
+
+	// ip6tables fails if IPv6 is disabled, in which case only the IPv4
+	// counter is listed.
+	stdout, err := pod.K8sClient.ExecInPod(ctx, pod.Pod.Namespace, pod.Pod.Name, ConnectionCounterContainerName,
+		[]string{"/bin/sh", "-c", "iptables -w -L INPUT -n -v -x; ip6tables -w -L INPUT -n -v -x 2>/dev/null; true"})
 	if err != nil {
-		ct.Logf("✨ [%s] Deploying same-node deployment...", ct.clients.src.ClusterName())
-		containerPort := 8080
-		echoDeployment := newDeploymentWithDNSTestServer(deploymentParameters{
-			Name:      echoSameNodeDeploymentName,
-			Kind:      kindEchoName,
-			Port:      containerPort,
-			NamedPort: "http-8080",
-			HostPort:  hostPort,
-			Image:     ct.params.JSONMockImage,
-			Labels:    map[string]string{"other": "echo"},
-			Affinity: &corev1.Affinity{
-				PodAffinity: &corev1.PodAffinity{
-					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
-						{
-							LabelSelector: &metav1.LabelSelector{
-								MatchExpressions: []metav1.LabelSelectorRequirement{
-									{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{clientDeploymentName}},
-								},
-							},
-							TopologyKey: corev1.LabelHostname,
-						},
-					},
-				},
-			},
-			ReadinessProbe: newLocalReadinessProbe(containerPort, "/"),
-		}, ct.params.DNSTestServerImage)
-		_, err = ct.clients.src.CreateServiceAccount(ctx, ct.params.TestNamespace, k8s.NewServiceAccount(echoSameNodeDeploymentName), metav1.CreateOptions{})
-		if err != nil {
-			return fmt.Errorf("unable to create service account %s: %s", echoSameNodeDeploymentName, err)
-		}
-		_, err = ct.clients.src.CreateDeployment(ctx, ct.params.TestNamespace, echoDeployment, metav1.CreateOptions{})
-		if err != nil {
-			return fmt.Errorf("unable to create deployment %s: %s", echoSameNodeDeploymentName, err)
-		}
+		return 0, fmt.Errorf("unable to read connection count of pod %s: %w", pod.Name(), err)
 	}
 
-	_, err = ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, clientDeploymentName, metav1.GetOptions{})
+	count, err := connectionCount(stdout.String())
 	if err != nil {
-		ct.Logf("✨ [%s] Deploying %s deployment...", ct.clients.src.ClusterName(), clientDeploymentName)
-		clientDeployment := newDeployment(deploymentParameters{
-			Name:         clientDeploymentName,
-			Kind:         kindClientName,
-			NamedPort:    "http-8080",
-			Port:         8080,
-			Image:        ct.params.CurlImage,
-			Command:      []string{"/bin/ash", "-c", "sleep 10000000"},
-			NodeSelector: ct.params.NodeSelector,
-		})
-		_, err = ct.clients.src.CreateServiceAccount(ctx, ct.params.TestNamespace, k8s.NewServiceAccount(clientDeploymentName), metav1.CreateOptions{})
-		if err != nil {
-			return fmt.Errorf("unable to create service account %s: %s", clientDeploymentName, err)
+		return 0, fmt.Errorf("unable to parse connection count of pod %s: %w", pod.Name(), err)
+	}
+	return count, nil
+}
+
+// Validate that srcPod can query the DNS server on dstPod successfully
+func (ct *ConnectivityTest) waitForPodDNS(ctx context.Context, srcPod, dstPod Pod) error {
+	ct.Logf("⌛ [%s] Waiting for pod %s to reach DNS server on %s pod...", ct.client.ClusterName(), srcPod.Name(), dstPod.Name())
+
+	for {
+		// Don't retry lookups more often than the configured poll interval.
+		r := time.After(ct.params.pollInterval())
+
+		// We don't care about the actual response content, we just want to check the DNS operativity.
+		// Since the coreDNS test server has been deployed with the "local" plugin enabled,
+		// we query it with a so-called "local request" (e.g. "localhost") to get a response.
+		// See https://coredns.io/plugins/local/ for more info.
+		target := "localhost"
+		stdout, err := srcPod.K8sClient.ExecInPod(ctx, srcPod.Pod.Namespace, srcPod.Pod.Name,
+			"", []string{"nslookup", target, dstPod.Address(IPFamilyAny)})
+
+		if err == nil {
+			return nil
 		}
-		_, err = ct.clients.src.CreateDeployment(ctx, ct.params.TestNamespace, clientDeployment, metav1.CreateOptions{})
-		if err != nil {
-			return fmt.Errorf("unable to create deployment %s: %s", clientDeploymentName, err)
+
+		ct.Debugf("Error looking up %s from pod %s to server on pod %s: %s: %s", target, srcPod.Name(), dstPod.Name(), err, stdout.String())
+
+		select {
+		case <-ctx.Done():
+			return newSetupError(ErrDNSTimeout, fmt.Errorf("timeout reached waiting lookup for %s from pod %s to server on pod %s to succeed (last error: %w)",
+				target, srcPod.Name(), dstPod.Name(), err,
+			))
+		default:
 		}
+
+		// Wait for the pace timer to avoid busy polling.
+		<-r
 	}
+}
 
-	// 2nd client with label other=client
-	_, err = ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, client2DeploymentName, metav1.GetOptions{})
-	if err != nil {
-		ct.Logf("✨ [%s] Deploying %s deployment...", ct.clients.src.ClusterName(), client2DeploymentName)
-		clientDeployment := newDeployment(deploymentParameters{
-			Name:      client2DeploymentName,
-			Kind:      kindClientName,
-			NamedPort: "http-8080",
-			Port:      8080,
-			Image:     ct.params.CurlImage,
-			Command:   []string{"/bin/ash", "-c", "sleep 10000000"},
-			Labels:    map[string]string{"other": "client"},
-			Affinity: &corev1.Affinity{
-				PodAffinity: &corev1.PodAffinity{
-					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
-						{
-							LabelSelector: &metav1.LabelSelector{
-								MatchExpressions: []metav1.LabelSelectorRequirement{
-									{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{clientDeploymentName}},
-								},
-							},
-							TopologyKey: corev1.LabelHostname,
-						},
-					},
-				},
-			},
-			NodeSelector: ct.params.NodeSelector,
-		})
-		_, err = ct.clients.src.CreateServiceAccount(ctx, ct.params.TestNamespace, k8s.NewServiceAccount(client2DeploymentName), metav1.CreateOptions{})
-		if err != nil {
-			return fmt.Errorf("unable to create service account %s: %s", client2DeploymentName, err)
+// Validate that kube-dns responds and knows about cluster services
+func (ct *ConnectivityTest) waitForServiceDNS(ctx context.Context, pod Pod) error {
+	target := ct.params.serviceDNSTarget()
+	ct.Logf("⌛ [%s] Waiting for pod %s to resolve %s...", ct.client.ClusterName(), pod.Name(), target)
+
+	for {
+		// Don't retry lookups more often than the configured poll interval.
+		r := time.After(ct.params.pollInterval())
+
+		stdout, err := pod.K8sClient.ExecInPod(ctx, pod.Pod.Namespace, pod.Pod.Name,
+			"", []string{"nslookup", target})
+		if err == nil {
+			return nil
 		}
-		_, err = ct.clients.src.CreateDeployment(ctx, ct.params.TestNamespace, clientDeployment, metav1.CreateOptions{})
-		if err != nil {
-			return fmt.Errorf("unable to create deployment %s: %s", client2DeploymentName, err)
+
+		ct.Debugf("Error looking up %s from pod %s: %s: %s", target, pod.Name(), err, stdout.String())
+
+		select {
+		case <-ctx.Done():
+			return newSetupError(ErrDNSTimeout, fmt.Errorf("timeout reached waiting lookup for %s from pod %s to succeed (last error: %w)", target, pod.Name(), err))
+		default:
 		}
+
+		// Wait for the pace timer to avoid busy polling.
+		<-r
 	}
+}
 
-	if !ct.params.SingleNode || ct.params.MultiCluster != "" {
-		_, err = ct.clients.dst.GetService(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.GetOptions{})
-		if err != nil {
-			ct.Logf("✨ [%s] Deploying echo-other-node service...", ct.clients.dst.ClusterName())
-			svc := newService(echoOtherNodeDeploymentName, map[string]string{"name": echoOtherNodeDeploymentName}, serviceLabels, "http", 8080)
+func (ct *ConnectivityTest) waitForIPCache(ctx context.Context, pod Pod) error {
+	ct.Logf("⌛ [%s] Waiting for Cilium pod %s to have all the pod IPs in eBPF ipcache...", pod.K8sClient.ClusterName(), pod.Name())
 
-			if ct.params.MultiCluster != "" {
-				svc.ObjectMeta.Annotations = map[string]string{}
-				svc.ObjectMeta.Annotations["service.cilium.io/global"] = "true"
-				svc.ObjectMeta.Annotations["io.cilium/global-service"] = "true"
-			}
+	for {
+		// Don't retry lookups more often than the configured interval.
+		r := time.After(ct.params.ipCacheInterval())
 
-			_, err = ct.clients.dst.CreateService(ctx, ct.params.TestNamespace, svc, metav1.CreateOptions{})
-			if err != nil {
-				return err
-			}
+		err := ct.validateIPCache(ctx, pod)
+		if err == nil {
+			ct.Debug("Successfully validated all podIDs in ipcache")
+			return nil
 		}
 
-		_, err = ct.clients.dst.GetDeployment(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.GetOptions{})
-		if err != nil {
-			ct.Logf("✨ [%s] Deploying other-node deployment...", ct.clients.dst.ClusterName())
-			containerPort := 8080
-			echoOtherNodeDeployment := newDeploymentWithDNSTestServer(deploymentParameters{
-				Name:      echoOtherNodeDeploymentName,
-				Kind:      kindEchoName,
-				NamedPort: "http-8080",
-				Port:      containerPort,
-				HostPort:  hostPort,
-				Image:     ct.params.JSONMockImage,
-				Labels:    map[string]string{"first": "echo"},
-				Affinity: &corev1.Affinity{
-					PodAntiAffinity: &corev1.PodAntiAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
-							{
-								LabelSelector: &metav1.LabelSelector{
-									MatchExpressions: []metav1.LabelSelectorRequirement{
-										{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{clientDeploymentName}},
-									},
-								},
-								TopologyKey: corev1.LabelHostname,
-							},
-						},
-					},
-				},
-				NodeSelector:   ct.params.NodeSelector,
-				ReadinessProbe: newLocalReadinessProbe(containerPort, "/"),
-			}, ct.params.DNSTestServerImage)
-			_, err = ct.clients.dst.CreateServiceAccount(ctx, ct.params.TestNamespace, k8s.NewServiceAccount(echoOtherNodeDeploymentName), metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("unable to create service account %s: %s", echoOtherNodeDeploymentName, err)
-			}
-			_, err = ct.clients.dst.CreateDeployment(ctx, ct.params.TestNamespace, echoOtherNodeDeployment, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("unable to create deployment %s: %w", echoOtherNodeDeploymentName, err)
-			}
+		if errors.Is(err, errIPCacheList) {
+			ct.Debugf("Unable to retrieve ipcache: %s, retrying...", err)
+		} else {
+			ct.Debugf("Error validating all podIDs in ipcache: %s, retrying...", err)
 		}
 
-		if ct.features[FeatureNodeWithoutCilium].Enabled {
-			_, err = ct.clients.src.GetDaemonSet(ctx, ct.params.TestNamespace, hostNetNSDeploymentName, metav1.GetOptions{})
-			if err != nil {
-				ct.Logf("✨ [%s] Deploying host-netns daemonset...", ct.clients.src.ClusterName())
-				ds := newDaemonSet(daemonSetParameters{
-					Name:        hostNetNSDeploymentName,
-					Kind:        kindHostNetNS,
-					Image:       ct.params.CurlImage,
-					Port:        8080,
-					Labels:      map[string]string{"other": "host-netns"},
-					Command:     []string{"/bin/ash", "-c", "sleep 10000000"},
-					HostNetwork: true,
-					Tolerations: []corev1.Toleration{
-						{Operator: corev1.TolerationOpExists},
-					},
-				})
-				_, err = ct.clients.src.CreateDaemonSet(ctx, ct.params.TestNamespace, ds, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("unable to create daemonset %s: %w", hostNetNSDeploymentName, err)
-				}
-			}
-
-			_, err = ct.clients.src.GetDeployment(ctx, ct.params.TestNamespace, echoExternalNodeDeploymentName, metav1.GetOptions{})
-			if err != nil {
-				ct.Logf("✨ [%s] Deploying echo-external-node deployment...", ct.clients.src.ClusterName())
-				containerPort := 8080
-				echoExternalDeployment := newDeployment(deploymentParameters{
-					Name:           echoExternalNodeDeploymentName,
-					Kind:           kindEchoExternalNodeName,
-					Port:           containerPort,
-					NamedPort:      "http-8080",
-					HostPort:       8080,
-					Image:          ct.params.JSONMockImage,
-					Labels:         map[string]string{"external": "echo"},
-					NodeSelector:   map[string]string{"cilium.io/no-schedule": "true"},
-					ReadinessProbe: newLocalReadinessProbe(containerPort, "/"),
-					HostNetwork:    true,
-					Tolerations: []corev1.Toleration{
-						{Operator: corev1.TolerationOpExists},
-					},
-				})
-				_, err = ct.clients.src.CreateServiceAccount(ctx, ct.params.TestNamespace, k8s.NewServiceAccount(echoExternalNodeDeploymentName), metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("unable to create service account %s: %s", echoExternalNodeDeploymentName, err)
-				}
-				_, err = ct.clients.src.CreateDeployment(ctx, ct.params.TestNamespace, echoExternalDeployment, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("unable to create deployment %s: %s", echoExternalNodeDeploymentName, err)
-				}
-			}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout reached waiting for pod IDs in ipcache of Cilium pod %s (last error: %w)", pod.Name(), err)
+		default:
 		}
-	}
 
-	// Create one Ingress service for echo deployment
-	if ct.features[FeatureIngressController].Enabled {
-		_, err = ct.clients.src.GetIngress(ctx, ct.params.TestNamespace, IngressServiceName, metav1.GetOptions{})
-		if err != nil {
-			ct.Logf("✨ [%s] Deploying Ingress resource...", ct.clients.src.ClusterName())
-			_, err = ct.clients.src.CreateIngress(ctx, ct.params.TestNamespace, newIngress(), metav1.CreateOptions{})
-			if err != nil {
-				return err
-			}
+		// Wait for the pace timer to avoid busy polling.
+		<-r
+	}
+}
 
-			ingressServiceName := fmt.Sprintf("cilium-ingress-%s", IngressServiceName)
-			ct.ingressService[ingressServiceName] = Service{
-				Service: &corev1.Service{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: ingressServiceName,
-					},
-					Spec: corev1.ServiceSpec{
-						Ports: []corev1.ServicePort{
-							{
-								Name:     "http",
-								Protocol: corev1.ProtocolTCP,
-								Port:     80,
-							},
-							{
-								Name:     "https",
-								Protocol: corev1.ProtocolTCP,
-								Port:     443,
-							},
-						},
-					},
-				},
+// errIPCacheList is returned by validateIPCache if the ipcache could not be
+// retrieved from the agent, as opposed to pods missing from it.
+var errIPCacheList = errors.New("failed to list ipcache bpf map")
+
+// listIPCache dumps the ipcache of the given agent. Each exec is bounded by a
+// timeout and retried a few times, as it can be slow or transiently fail on a
+// busy agent.
+func (ct *ConnectivityTest) listIPCache(ctx context.Context, agentPod Pod) (ipCache, error) {
+	var err error
+	for i := 1; i <= defaults.IPCacheExecRetries; i++ {
+		execCtx, cancel := context.WithTimeout(ctx, defaults.IPCacheExecTimeout)
+		var stdout bytes.Buffer
+		stdout, err = agentPod.K8sClient.ExecInPod(execCtx, agentPod.Pod.Namespace, agentPod.Pod.Name,
+			defaults.AgentContainerName, []string{"cilium", "bpf", "ipcache", "list", "-o", "json"})
+		cancel()
+		if err == nil {
+			var ic ipCache
+			if err := json.Unmarshal(stdout.Bytes(), &ic); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal Cilium ipcache stdout json: %w", err)
 			}
+			return ic, nil
 		}
+		if ctx.Err() != nil {
+			break
+		}
+		ct.Debugf("Attempt %d/%d to list ipcache of Cilium pod %s failed: %s", i, defaults.IPCacheExecRetries, agentPod.Name(), err)
 	}
-	return nil
+
+	return nil, fmt.Errorf("%w: %w", errIPCacheList, err)
 }
 
-// deploymentList returns 2 lists of Deployments to be used for running tests with.
-func (ct *ConnectivityTest) deploymentList() (srcList []string, dstList []string) {
-	if !ct.params.Perf {
-		srcList = []string{clientDeploymentName, client2DeploymentName, echoSameNodeDeploymentName}
-	} else {
-		perfNm := newPerfDeploymentNameManager(&ct.params)
-		srcList = []string{perfNm.ClientName(), perfNm.ServerName()}
-		if !ct.params.SingleNode {
-			srcList = append(srcList, perfNm.ClientAcrossName())
+// podsInCluster returns the pods of the given cluster, sorted by name.
+func podsInCluster(pods map[string]Pod, client *k8s.Client) []Pod {
+	var out []Pod
+	for _, pod := range pods {
+		if pod.K8sClient == client {
+			out = append(out, pod)
 		}
 	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
 
-	if (ct.params.MultiCluster != "" || !ct.params.SingleNode) && !ct.params.Perf {
-		dstList = append(dstList, echoOtherNodeDeploymentName)
+func (ct *ConnectivityTest) validateIPCache(ctx context.Context, agentPod Pod) error {
+	ic, err := ct.listIPCache(ctx, agentPod)
+	if err != nil {
+		return err
 	}
 
-	if ct.features[FeatureNodeWithoutCilium].Enabled {
-		dstList = append(dstList, echoExternalNodeDeploymentName)
+	// In multi-cluster mode, each agent is only expected to know about the
+	// pods of its own cluster.
+	for _, p := range podsInCluster(ct.ClientPods(), agentPod.K8sClient) {
+		if _, err := ic.findPodID(p); err != nil {
+			return fmt.Errorf("couldn't find client Pod %v in ipcache: %w", p, err)
+		}
 	}
 
-	return srcList, dstList
-}
-
-func (ct *ConnectivityTest) deleteDeployments(ctx context.Context, client *k8s.Client) error {
-	ct.Logf("🔥 [%s] Deleting connectivity check deployments...", client.ClusterName())
-	_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.DeleteOptions{})
-	_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.DeleteOptions{})
-	_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, clientDeploymentName, metav1.DeleteOptions{})
-	_ = client.DeleteDeployment(ctx, ct.params.TestNamespace, client2DeploymentName, metav1.DeleteOptions{})
-	_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.DeleteOptions{})
-	_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.DeleteOptions{})
-	_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, clientDeploymentName, metav1.DeleteOptions{})
-	_ = client.DeleteServiceAccount(ctx, ct.params.TestNamespace, client2DeploymentName, metav1.DeleteOptions{})
-	_ = client.DeleteService(ctx, ct.params.TestNamespace, echoSameNodeDeploymentName, metav1.DeleteOptions{})
-	_ = client.DeleteService(ctx, ct.params.TestNamespace, echoOtherNodeDeploymentName, metav1.DeleteOptions{})
-	_ = client.DeleteConfigMap(ctx, ct.params.TestNamespace, corednsConfigMapName, metav1.DeleteOptions{})
-	_ = client.DeleteNamespace(ctx, ct.params.TestNamespace, metav1.DeleteOptions{})
-
-	_, err := client.GetNamespace(ctx, ct.params.TestNamespace, metav1.GetOptions{})
-	if err == nil {
-		ct.Logf("⌛ [%s] Waiting for namespace %s to disappear", client.ClusterName(), ct.params.TestNamespace)
-		for err == nil {
-			time.Sleep(time.Second)
-			// Retry the namespace deletion in-case the previous delete was
-			// rejected, i.e. by yahoo/k8s-namespace-guard
-			_ = client.DeleteNamespace(ctx, ct.params.TestNamespace, metav1.DeleteOptions{})
-			_, err = client.GetNamespace(ctx, ct.params.TestNamespace, metav1.GetOptions{})
+	for _, p := range podsInCluster(ct.EchoPods(), agentPod.K8sClient) {
+		if _, err := ic.findPodID(p); err != nil {
+			return fmt.Errorf("couldn't find echo Pod %v in ipcache: %w", p, err)
 		}
 	}
 
 	return nil
 }
 
-// validateDeployment checks if the Deployments we created have the expected Pods in them.
-func (ct *ConnectivityTest) validateDeployment(ctx context.Context) error {
-
-	ct.Debug("Validating Deployments...")
+// checkDeploymentRollout checks that the given test deployment is ready and
+// that its rollout is complete.
+func (ct *ConnectivityTest) checkDeploymentRollout(ctx context.Context, client *k8s.Client, name string) error {
+	if err := ct.readClient(client).CheckDeploymentStatus(ctx, ct.params.TestNamespace, name); err != nil {
+		return err
+	}
+	d, err := ct.readClient(client).GetDeployment(ctx, ct.params.TestNamespace, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return deploymentRolloutComplete(d)
+}
 
-	srcDeployments, dstDeployments := ct.deploymentList()
-	if len(srcDeployments) > 0 {
-		if err := ct.waitForDeployments(ctx, ct.clients.src, srcDeployments); err != nil {
-			return err
-		}
+// deploymentRolloutComplete returns an error if a rollout of the deployment is
+// still in progress. Old and new replicas may then both be counted in the
+// status, so the up-to-date replicas are compared to the desired count.
+func deploymentRolloutComplete(d *appsv1.Deployment) error {
+	if d.Spec.Replicas != nil && d.Status.UpdatedReplicas != *d.Spec.Replicas {
+		return fmt.Errorf("rollout in progress: %d of %d desired replicas are up-to-date", d.Status.UpdatedReplicas, *d.Spec.Replicas)
 	}
-	if len(dstDeployments) > 0 {
-		if err := ct.waitForDeployments(ctx, ct.clients.dst, dstDeployments); err != nil {
-			return err
-		}
+	if d.Status.UnavailableReplicas != 0 {
+		return fmt.Errorf("rollout in progress: %d replicas are unavailable", d.Status.UnavailableReplicas)
 	}
+	return nil
+}
 
-	if ct.params.Perf {
-		perfPods, err := ct.client.ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindPerfName})
-		if err != nil {
-			return fmt.Errorf("unable to list perf pods: %w", err)
-		}
-		for _, perfPod := range perfPods.Items {
-			// Filter out existing perf pods in cilium-test based on scenario
-			if ct.params.PerfHostNet != perfPod.Spec.HostNetwork {
-				continue
-			}
+func (ct *ConnectivityTest) waitForDeployments(ctx context.Context, client *k8s.Client, deployments []string) error {
+	ct.Logf("⌛ [%s] Waiting for deployments %s to become ready...", client.ClusterName(), deployments)
 
-			// Individual endpoints will not be created for pods using node's network stack
-			if !ct.params.PerfHostNet {
-				ctx, cancel := context.WithTimeout(ctx, ct.params.ciliumEndpointTimeout())
-				defer cancel()
-				if err := ct.waitForCiliumEndpoint(ctx, ct.clients.src, ct.params.TestNamespace, perfPod.Name); err != nil {
-					return err
+	waitCtx, cancel := context.WithTimeout(ctx, ct.params.podReadyTimeout())
+	defer cancel()
+	for _, name := range deployments {
+		start := time.Now()
+		step := fmt.Sprintf("[%s] deployment %s/%s ready", client.ClusterName(), ct.params.TestNamespace, name)
+		for {
+			err := ct.checkDeploymentRollout(waitCtx, client, name)
+			if err == nil && ct.params.DeploymentRolloutGrace > 0 {
+				// Make sure the rollout is settled, and not just momentarily
+				// reporting all replicas ready, before testing against it.
+				select {
+				case <-time.After(ct.params.DeploymentRolloutGrace):
+					err = ct.checkDeploymentRollout(waitCtx, client, name)
+				case <-waitCtx.Done():
+					err = waitCtx.Err()
 				}
 			}
-			_, hasLabel := perfPod.GetLabels()["server"]
-			if hasLabel {
-				ct.perfServerPod[perfPod.Name] = Pod{
-					K8sClient: ct.client,
-					Pod:       perfPod.DeepCopy(),
-					port:      5201,
-				}
-			} else {
-				ct.perfClientPods[perfPod.Name] = Pod{
-					K8sClient: ct.client,
-					Pod:       perfPod.DeepCopy(),
+			if err == nil {
+				err = ct.checkPodReadinessCondition(waitCtx, client, name)
+			}
+			if err == nil {
+				ct.recordSetupStep(step, start, nil)
+				break
+			}
+			select {
+			case <-time.After(ct.params.pollInterval()):
+			case <-waitCtx.Done():
+				if hpErr := ct.checkHostPortConflict(ctx, client, name); hpErr != nil {
+					err = fmt.Errorf("waiting for deployment %s to become ready has been interrupted: %w", name, hpErr)
+				} else {
+					err = fmt.Errorf("waiting for deployment %s to become ready has been interrupted: %w (last error: %s)", name, waitCtx.Err(), err)
 				}
+				ct.recordSetupStep(step, start, err)
+				return newSetupError(ErrDeploymentTimeout, err)
 			}
 		}
+	}
+
+	return nil
+}
+
+// checkPodReadinessCondition returns an error unless all pods of the given
+// deployment or daemonset have the PodReadinessCondition set to True.
+func (ct *ConnectivityTest) checkPodReadinessCondition(ctx context.Context, client *k8s.Client, name string) error {
+	if ct.params.PodReadinessCondition == "" {
 		return nil
 	}
 
-	clientPods, err := ct.client.ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindClientName})
+	pods, err := ct.readClient(client).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "name=" + name})
 	if err != nil {
-		return fmt.Errorf("unable to list client pods: %s", err)
+		return fmt.Errorf("unable to list pods of %s: %w", name, err)
 	}
+	return podsConditionTrue(pods.Items, corev1.PodConditionType(ct.params.PodReadinessCondition))
+}
 
-	for _, pod := range clientPods.Items {
-		ctx, cancel := context.WithTimeout(ctx, ct.params.ciliumEndpointTimeout())
-		defer cancel()
-		if err := ct.waitForCiliumEndpoint(ctx, ct.clients.src, ct.params.TestNamespace, pod.Name); err != nil {
-			return err
+// podsConditionTrue returns an error listing the pods whose given condition
+// is missing or not True.
+func podsConditionTrue(pods []corev1.Pod, condition corev1.PodConditionType) error {
+	var pending []string
+	for _, pod := range pods {
+		ready := false
+		for _, c := range pod.Status.Conditions {
+			if c.Type == condition {
+				ready = c.Status == corev1.ConditionTrue
+				break
+			}
 		}
-
-		ct.clientPods[pod.Name] = Pod{
-			K8sClient: ct.client,
-			Pod:       pod.DeepCopy(),
+		if !ready {
+			pending = append(pending, pod.Name)
 		}
 	}
-
-	sameNodePods, err := ct.clients.src.ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "name=" + echoSameNodeDeploymentName})
-	if err != nil {
-		return fmt.Errorf("unable to list same node pods: %w", err)
-	}
-	if len(sameNodePods.Items) != 1 {
-		return fmt.Errorf("unexpected number of same node pods: %d", len(sameNodePods.Items))
-	}
-	sameNodePod := Pod{
-		Pod: sameNodePods.Items[0].DeepCopy(),
+	if len(pending) > 0 {
+		sort.Strings(pending)
+		return fmt.Errorf("condition %s of pods %s is not True", condition, strings.Join(pending, ", "))
 	}
+	return nil
+}
 
-	sameNodeDNSCtx, sameNodeDNSCancel := context.WithTimeout(ctx, ct.params.ipCacheTimeout())
-	defer sameNodeDNSCancel()
-	for _, cp := range ct.clientPods {
-		err := ct.waitForPodDNS(sameNodeDNSCtx, cp, sameNodePod)
-		if err != nil {
-			return err
-		}
-	}
+func (ct *ConnectivityTest) waitForDaemonSet(ctx context.Context, client *k8s.Client, name string) error {
+	ct.Logf("⌛ [%s] Waiting for daemonset %s to become ready...", client.ClusterName(), name)
 
-	if !ct.params.SingleNode || ct.params.MultiCluster != "" {
-		otherNodePods, err := ct.clients.dst.ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "name=" + echoOtherNodeDeploymentName})
-		if err != nil {
-			return fmt.Errorf("unable to list other node pods: %w", err)
-		}
-		if len(otherNodePods.Items) != 1 {
-			return fmt.Errorf("unexpected number of other node pods: %d", len(otherNodePods.Items))
+	waitCtx, cancel := context.WithTimeout(ctx, ct.params.podReadyTimeout())
+	defer cancel()
+
+	start := time.Now()
+	step := fmt.Sprintf("[%s] daemonset %s/%s ready", client.ClusterName(), ct.params.TestNamespace, name)
+	for {
+		err := ct.readClient(client).CheckDaemonSetStatus(waitCtx, ct.params.TestNamespace, name)
+		if err == nil {
+			err = ct.checkPodReadinessCondition(waitCtx, client, name)
 		}
-		otherNodePod := Pod{
-			Pod: otherNodePods.Items[0].DeepCopy(),
+		if err == nil {
+			ct.recordSetupStep(step, start, nil)
+			return nil
 		}
-
-		otherNodeDNSCtx, otherNodeDNSCancel := context.WithTimeout(ctx, ct.params.ipCacheTimeout())
-		defer otherNodeDNSCancel()
-		for _, cp := range ct.clientPods {
-			err := ct.waitForPodDNS(otherNodeDNSCtx, cp, otherNodePod)
-			if err != nil {
-				return err
-			}
+		select {
+		case <-time.After(ct.params.pollInterval()):
+		case <-waitCtx.Done():
+			err = fmt.Errorf("waiting for daemonset %s to become ready has been interrupted: %w (last error: %s)", name, waitCtx.Err(), err)
+			ct.recordSetupStep(step, start, err)
+			return newSetupError(ErrDeploymentTimeout, err)
 		}
 	}
+}
 
-	if ct.features[FeatureNodeWithoutCilium].Enabled {
-		echoExternalNodePods, err := ct.clients.dst.ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "name=" + echoExternalNodeDeploymentName})
-		if err != nil {
-			return fmt.Errorf("unable to list other node pods: %w", err)
-		}
-
-		for _, pod := range echoExternalNodePods.Items {
-			ct.echoExternalPods[pod.Name] = Pod{
-				K8sClient: ct.client,
-				Pod:       pod.DeepCopy(),
-				scheme:    "http",
-				port:      8080, // listen port of the echo server inside the container
-			}
-		}
+// waitForHostNetNSDaemonSets waits for the host-netns DaemonSets which are
+// expected to schedule pods, i.e. those matching the architecture of at least
+// one node, as the scenarios send requests from them with --client-source.
+func (ct *ConnectivityTest) waitForHostNetNSDaemonSets(ctx context.Context) error {
+	nodes, err := ct.readClient(ct.clients.src).ListNodes(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list nodes: %w", err)
 	}
 
-	svcDNSCtx, svcDNSCancel := context.WithTimeout(ctx, ct.params.ipCacheTimeout())
-	defer svcDNSCancel()
-	for _, cp := range ct.clientPods {
-		err := ct.waitForServiceDNS(svcDNSCtx, cp)
-		if err != nil {
-			return err
+	names := map[string]struct{}{}
+	for _, node := range nodes.Items {
+		arch := node.Labels[corev1.LabelArchStable]
+		if _, ok := ct.params.HostNetNSImages[arch]; ok {
+			names[hostNetNSDeploymentName+"-"+arch] = struct{}{}
+		} else {
+			names[hostNetNSDeploymentName] = struct{}{}
 		}
 	}
 
-	for _, client := range ct.clients.clients() {
-		echoPods, err := client.ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindEchoName})
-		if err != nil {
-			return fmt.Errorf("unable to list echo pods: %w", err)
+	for _, ds := range ct.newHostNetNSDaemonSets() {
+		if _, ok := names[ds.Name]; !ok {
+			continue
 		}
-		for _, echoPod := range echoPods.Items {
-			ctx, cancel := context.WithTimeout(ctx, ct.params.ciliumEndpointTimeout())
-			defer cancel()
-			if err := ct.waitForCiliumEndpoint(ctx, client, ct.params.TestNamespace, echoPod.Name); err != nil {
-				return err
-			}
-
-			ct.echoPods[echoPod.Name] = Pod{
-				K8sClient: client,
-				Pod:       echoPod.DeepCopy(),
-				scheme:    "http",
-				port:      8080, // listen port of the echo server inside the container
-			}
+		if err := ct.waitForDaemonSet(ctx, ct.clients.src, ds.Name); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	for _, client := range ct.clients.clients() {
-		echoServices, err := client.ListServices(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindEchoName})
-		if err != nil {
-			return fmt.Errorf("unable to list echo services: %w", err)
-		}
-
-		for _, echoService := range echoServices.Items {
-			if ct.params.MultiCluster != "" {
-				if _, exists := ct.echoServices[echoService.Name]; exists {
-					// ct.clients.clients() lists the client cluster first.
-					// If we already have this service (for the client cluster), keep it
-					// so that we can rely on the service's ClusterIP being valid for the
-					// client pods.
-					continue
-				}
-			}
-
-			ct.echoServices[echoService.Name] = Service{
-				Service: echoService.DeepCopy(),
-			}
-		}
+// checkHostPortConflict inspects the pods of the given deployment which are
+// stuck in Pending and returns a descriptive error if the scheduler refused
+// to place them because one of their HostPorts is already in use. It returns
+// nil if no such conflict could be identified.
+func (ct *ConnectivityTest) checkHostPortConflict(ctx context.Context, client *k8s.Client, deployment string) error {
+	pods, err := ct.readClient(client).ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "name=" + deployment})
+	if err != nil {
+		ct.Debugf("Unable to list pods of deployment %s: %s", deployment, err)
+		return nil
 	}
 
-	for _, s := range ct.echoServices {
-		if err := ct.waitForService(ctx, s); err != nil {
-			return err
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		hostPorts := podHostPorts(&pod)
+		if len(hostPorts) == 0 {
+			continue
 		}
-	}
 
-	if ct.features[FeatureIngressController].Enabled {
-		ingressServices, err := ct.clients.src.ListServices(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "cilium.io/ingress=true"})
+		events, err := ct.readClient(client).ListEvents(ctx, metav1.ListOptions{
+			FieldSelector: fmt.Sprintf("involvedObject.kind=Pod,involvedObject.namespace=%s,involvedObject.name=%s,reason=FailedScheduling",
+				pod.Namespace, pod.Name),
+		})
 		if err != nil {
-			return fmt.Errorf("unable to list ingress services: %w", err)
+			ct.Debugf("Unable to list events of pod %s: %s", pod.Name, err)
+			return nil
 		}
 
-		for _, ingressService := range ingressServices.Items {
-			ct.ingressService[ingressService.Name] = Service{
-				Service: ingressService.DeepCopy(),
+		portConflict := false
+		for _, event := range events.Items {
+			if strings.Contains(event.Message, "free ports") {
+				portConflict = true
+				break
 			}
 		}
-	}
+		if !portConflict {
+			continue
+		}
 
-	if ct.params.MultiCluster == "" {
-		for _, ciliumPod := range ct.ciliumPods {
-			hostIP := ciliumPod.Pod.Status.HostIP
-			for _, s := range ct.echoServices {
-				if err := ct.waitForNodePorts(ctx, hostIP, s); err != nil {
-					return err
+		// Try to find which pod is holding the port on a node the pending pod
+		// could otherwise be scheduled on.
+		nodes, err := ct.readClient(client).ListNodes(ctx, metav1.ListOptions{})
+		if err == nil {
+			others, err := ct.readClient(client).ListPods(ctx, "", metav1.ListOptions{
+				FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+			})
+			if err == nil {
+				if port, other := hostPortHolder(&pod, hostPorts, nodes.Items, others.Items); other != nil {
+					return fmt.Errorf("HostPort %d already in use on node %s by pod %s/%s, pod %s cannot be scheduled",
+						port, other.Spec.NodeName, other.Namespace, other.Name, pod.Name)
 				}
 			}
 		}
-	}
 
-	hostNetNSPods, err := ct.client.ListPods(ctx, ct.params.TestNamespace, metav1.ListOptions{LabelSelector: "kind=" + kindHostNetNS})
-	if err != nil {
-		return fmt.Errorf("unable to list host netns pods: %w", err)
+		ports := make([]string, 0, len(hostPorts))
+		for _, port := range sortedHostPorts(hostPorts) {
+			ports = append(ports, strconv.Itoa(int(port)))
+		}
+		return fmt.Errorf("HostPort(s) %s already in use on all eligible nodes, pod %s cannot be scheduled",
+			strings.Join(ports, ","), pod.Name)
 	}
 
-	for _, pod := range hostNetNSPods.Items {
-		ct.hostNetNSPodsByNode[pod.Spec.NodeName] = Pod{
-			K8sClient: ct.client,
-			Pod:       pod.DeepCopy(),
+	return nil
+}
+
+// hostPortHolder returns the first of the given pods holding one of hostPorts
+// on a node eligible for pod, along with the conflicting port. Pods holding
+// the port on nodes pod can't be scheduled on anyway, such as its siblings
+// spread across other nodes, are not to blame and are ignored.
+func hostPortHolder(pod *corev1.Pod, hostPorts map[int32]struct{}, nodes []corev1.Node, others []corev1.Pod) (int32, *corev1.Pod) {
+	eligible := map[string]struct{}{}
+	for i := range nodes {
+		if isSchedulable(&nodes[i], pod.Spec.NodeSelector, pod.Spec.Tolerations) {
+			eligible[nodes[i].Name] = struct{}{}
 		}
 	}
 
-	var logOnce sync.Once
-	for _, client := range ct.clients.clients() {
-		externalWorkloads, err := client.ListCiliumExternalWorkloads(ctx, metav1.ListOptions{})
-		if k8sErrors.IsNotFound(err) {
-			logOnce.Do(func() {
-				ct.Log("ciliumexternalworkloads.cilium.io is not defined. Disabling external workload tests")
-			})
+	for i := range others {
+		other := &others[i]
+		if other.Namespace == pod.Namespace && other.Name == pod.Name {
+			continue
+		}
+		if _, ok := eligible[other.Spec.NodeName]; !ok {
 			continue
-		} else if err != nil {
-			return fmt.Errorf("unable to list external workloads: %w", err)
 		}
-		for _, externalWorkload := range externalWorkloads.Items {
-			ct.externalWorkloads[externalWorkload.Name] = ExternalWorkload{
-				workload: externalWorkload.DeepCopy(),
+		otherPorts := podHostPorts(other)
+		for _, port := range sortedHostPorts(hostPorts) {
+			if _, ok := otherPorts[port]; ok {
+				return port, other
 			}
 		}
 	}
+	return 0, nil
+}
 
-	// TODO: unconditionally re-enable the IPCache check once
-	// https://github.com/cilium/cilium-cli/issues/361 is resolved.
-	if ct.params.SkipIPCacheCheck {
-		ct.Infof("Skipping IPCache check")
-	} else {
-		// Set the timeout for all IP cache lookup retries
-		ipCacheCtx, cancel := context.WithTimeout(ctx, ct.params.ipCacheTimeout())
-		defer cancel()
-		for _, cp := range ct.ciliumPods {
-			if err := ct.waitForIPCache(ipCacheCtx, cp); err != nil {
-				return err
+// sortedHostPorts returns the given set of HostPorts in ascending order.
+func sortedHostPorts(hostPorts map[int32]struct{}) []int32 {
+	ports := make([]int32, 0, len(hostPorts))
+	for port := range hostPorts {
+		ports = append(ports, port)
+	}
+	slices.Sort(ports)
+	return ports
+}
+
+// podHostPorts returns the set of HostPorts requested by the containers of the given pod.
+func podHostPorts(pod *corev1.Pod) map[int32]struct{} {
+	ports := map[int32]struct{}{}
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.HostPort != 0 {
+				ports[p.HostPort] = struct{}{}
 			}
 		}
 	}
-
-	return nil
+	return ports
 }
 
-// Validate that srcPod can query the DNS server on dstPod successfully
-func (ct *ConnectivityTest) waitForPodDNS(ctx context.Context, srcPod, dstPod Pod) error {
-	ct.Logf("⌛ [%s] Waiting for pod %s to reach DNS server on %s pod...", ct.client.ClusterName(), srcPod.Name(), dstPod.Name())
-
-	for {
-		// Don't retry lookups more often than once per second.
-		r := time.After(time.Second)
+// waitForServiceEndpoints waits for the given echo Service to have ready
+// endpoints in the cluster of client. It fails early if its selector doesn't
+// match any of the echo pods of that cluster, e.g. because their labels were
+// mutated, as the Service would never get any endpoints.
+func (ct *ConnectivityTest) waitForServiceEndpoints(ctx context.Context, client *k8s.Client, svc *corev1.Service) error {
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+	matches := ct.echoBackends(client, svc)
+	if matches == 0 {
+		if ct.params.MultiCluster != "" {
+			// The backends of global services may live in the other cluster only.
+			return nil
+		}
+		return fmt.Errorf("[%s] selector %q of service %s doesn't match any echo pod, check for mutated pod labels",
+			client.ClusterName(), selector, svc.Name)
+	}
 
-		// We don't care about the actual response content, we just want to check the DNS operativity.
-		// Since the coreDNS test server has been deployed with the "local" plugin enabled,
-		// we query it with a so-called "local request" (e.g. "localhost") to get a response.
-		// See https://coredns.io/plugins/local/ for more info.
-		target := "localhost"
-		stdout, err := srcPod.K8sClient.ExecInPod(ctx, srcPod.Pod.Namespace, srcPod.Pod.Name,
-			"", []string{"nslookup", target, dstPod.Address(IPFamilyAny)})
+	ctx, cancel := context.WithTimeout(ctx, ct.params.serviceReadyTimeout())
+	defer cancel()
 
+	for {
+		endpoints, err := ct.readClient(client).GetEndpoints(ctx, svc.Namespace, svc.Name, metav1.GetOptions{})
 		if err == nil {
-			return nil
+			for _, subset := range endpoints.Subsets {
+				if len(subset.Addresses) > 0 {
+					return nil
+				}
+			}
+			err = fmt.Errorf("no ready endpoints")
 		}
 
-		ct.Debugf("Error looking up %s from pod %s to server on pod %s: %s: %s", target, srcPod.Name(), dstPod.Name(), err, stdout.String())
+		ct.Debugf("[%s] Service %s has no endpoints yet: %s", client.ClusterName(), svc.Name, err)
 
 		select {
+		case <-time.After(ct.params.pollInterval()):
 		case <-ctx.Done():
-			return fmt.Errorf("timeout reached waiting lookup for %s from pod %s to server on pod %s to succeed (last error: %w)",
-				target, srcPod.Name(), dstPod.Name(), err,
-			)
-		default:
+			return fmt.Errorf("[%s] timeout reached waiting for endpoints of service %s matching %d echo pods (last error: %w)",
+				client.ClusterName(), svc.Name, matches, err)
 		}
-
-		// Wait for the pace timer to avoid busy polling.
-		<-r
 	}
 }
 
-// Validate that kube-dns responds and knows about cluster services
-func (ct *ConnectivityTest) waitForServiceDNS(ctx context.Context, pod Pod) error {
-	ct.Logf("⌛ [%s] Waiting for pod %s to reach default/kubernetes service...", ct.client.ClusterName(), pod.Name())
-
-	for {
-		// Don't retry lookups more often than once per second.
-		r := time.After(time.Second)
-
-		target := "kubernetes.default"
-		stdout, err := pod.K8sClient.ExecInPod(ctx, pod.Pod.Namespace, pod.Pod.Name,
-			"", []string{"nslookup", target})
-		if err == nil {
-			return nil
+// echoBackends returns the number of echo pods in the given cluster which are
+// selected by the service.
+func (ct *ConnectivityTest) echoBackends(client *k8s.Client, svc *corev1.Service) int {
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+	matches := 0
+	for _, pod := range ct.EchoPods() {
+		if pod.K8sClient == client && selector.Matches(labels.Set(pod.Pod.Labels)) {
+			matches++
 		}
+	}
+	return matches
+}
 
-		ct.Debugf("Error looking up %s from pod %s: %s: %s", target, pod.Name(), err, stdout.String())
-
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("timeout reached waiting lookup for %s from pod %s to succeed (last error: %w)", target, pod.Name(), err)
-		default:
+// readyEndpoints returns the number of distinct ready endpoints listed in the
+// given EndpointSlices. Endpoints are identified by their target pod if set,
+// as dual-stack services have a separate slice for each address family.
+func readyEndpoints(slices []discoveryv1.EndpointSlice) int {
+	ready := map[string]struct{}{}
+	for _, slice := range slices {
+		for _, ep := range slice.Endpoints {
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+			switch {
+			case ep.TargetRef != nil:
+				ready[ep.TargetRef.Namespace+"/"+ep.TargetRef.Name] = struct{}{}
+			case len(ep.Addresses) > 0:
+				ready[ep.Addresses[0]] = struct{}{}
+			}
 		}
-
-		// Wait for the pace timer to avoid busy polling.
-		<-r
 	}
+	return len(ready)
 }
 
-func (ct *ConnectivityTest) waitForIPCache(ctx context.Context, pod Pod) error {
-	ct.Logf("⌛ [%s] Waiting for Cilium pod %s to have all the pod IPs in eBPF ipcache...", ct.client.ClusterName(), pod.Name())
+// waitForEndpointSlices waits until the EndpointSlices of the service list the
+// expected number of ready endpoints, as Cilium programs the service backends
+// from the EndpointSlices rather than from the Endpoints object.
+func (ct *ConnectivityTest) waitForEndpointSlices(ctx context.Context, client *k8s.Client, svc *corev1.Service, expected int) error {
+	if expected == 0 {
+		return nil
+	}
 
-	for {
-		// Don't retry lookups more often than once per second.
-		r := time.After(time.Second)
+	ctx, cancel := context.WithTimeout(ctx, ct.params.serviceReadyTimeout())
+	defer cancel()
 
-		err := ct.validateIPCache(ctx, pod)
+	for {
+		slices, err := ct.readClient(client).ListEndpointSlices(ctx, svc.Namespace, metav1.ListOptions{
+			LabelSelector: discoveryv1.LabelServiceName + "=" + svc.Name,
+		})
 		if err == nil {
-			ct.Debug("Successfully validated all podIDs in ipcache")
-			return nil
+			ready := readyEndpoints(slices.Items)
+			if ready >= expected {
+				return nil
+			}
+			err = fmt.Errorf("%d of %d endpoints ready", ready, expected)
 		}
 
-		ct.Debugf("Error validating all podIDs in ipcache: %s, retrying...", err)
+		ct.Debugf("[%s] EndpointSlices of service %s are not ready yet: %s", client.ClusterName(), svc.Name, err)
 
 		select {
+		case <-time.After(ct.params.pollInterval()):
 		case <-ctx.Done():
-			return fmt.Errorf("timeout reached waiting for pod IDs in ipcache of Cilium pod %s (last error: %w)", pod.Name(), err)
-		default:
+			return fmt.Errorf("[%s] timeout reached waiting for EndpointSlices of service %s (last error: %w)",
+				client.ClusterName(), svc.Name, err)
 		}
-
-		// Wait for the pace timer to avoid busy polling.
-		<-r
 	}
 }
 
-func (ct *ConnectivityTest) validateIPCache(ctx context.Context, agentPod Pod) error {
-	stdout, err := agentPod.K8sClient.ExecInPod(ctx, agentPod.Pod.Namespace, agentPod.Pod.Name,
-		defaults.AgentContainerName, []string{"cilium", "bpf", "ipcache", "list", "-o", "json"})
-	if err != nil {
-		return fmt.Errorf("failed to list ipcache bpf map: %w", err)
+// readinessProbePods returns the client pods the readiness of services,
+// NodePorts and echo pods is probed from: a random one, or all of them sorted
+// by name with ProbeFromAllClients. Unless client is nil, only the client pods
+// of its cluster are considered, or the ones of all clusters if it has none,
+// e.g. in multi-cluster mode without MultiClusterBidirectional.
+func (ct *ConnectivityTest) readinessProbePods(client *k8s.Client) ([]Pod, error) {
+	var pods, others []Pod
+	for _, pod := range ct.ClientPods() {
+		if client == nil || pod.K8sClient == client {
+			pods = append(pods, pod)
+		} else {
+			others = append(others, pod)
+		}
 	}
-
-	var ic ipCache
-
-	if err := json.Unmarshal(stdout.Bytes(), &ic); err != nil {
-		return fmt.Errorf("failed to unmarshal Cilium ipcache stdout json: %w", err)
+	if len(pods) == 0 {
+		pods = others
 	}
-
-	for _, p := range ct.clientPods {
-		if _, err := ic.findPodID(p); err != nil {
-			return fmt.Errorf("couldn't find client Pod %v in ipcache: %w", p, err)
-		}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no client pod available")
 	}
-
-	for _, p := range ct.echoPods {
-		if _, err := ic.findPodID(p); err != nil {
-			return fmt.Errorf("couldn't find echo Pod %v in ipcache: %w", p, err)
-		}
+	if !ct.params.ProbeFromAllClients {
+		// The map iteration order makes the first pod a random one.
+		return pods[:1], nil
 	}
-
-	return nil
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name() < pods[j].Name() })
+	return pods, nil
 }
 
-func (ct *ConnectivityTest) waitForDeployments(ctx context.Context, client *k8s.Client, deployments []string) error {
-	ct.Logf("⌛ [%s] Waiting for deployments %s to become ready...", client.ClusterName(), deployments)
+// probeFromPods calls probe for each of the readiness probe pods of the
+// cluster of client, or of all clusters if client is nil, and returns the
+// failures of all of them, naming the pods they failed from.
+func (ct *ConnectivityTest) probeFromPods(client *k8s.Client, probe func(pod Pod) error) error {
+	pods, err := ct.readinessProbePods(client)
+	if err != nil {
+		return err
+	}
 
-	ctx, cancel := context.WithTimeout(ctx, ct.params.podReadyTimeout())
-	defer cancel()
-	for _, name := range deployments {
-		for {
-			err := client.CheckDeploymentStatus(ctx, ct.params.TestNamespace, name)
-			if err == nil {
-				break
-			}
-			select {
-			case <-time.After(time.Second):
-			case <-ctx.Done():
-				return fmt.Errorf("waiting for deployment %s to become ready has been interrupted: %w (last error: %s)", name, ctx.Err(), err)
-			}
+	var errs []error
+	for _, pod := range pods {
+		if err := probe(pod); err != nil {
+			errs = append(errs, fmt.Errorf("from pod %s: %w", pod.Name(), err))
 		}
 	}
-
-	return nil
+	return errors.Join(errs...)
 }
 
 func (ct *ConnectivityTest) waitForService(ctx context.Context, service Service) error {
-	ct.Logf("⌛ [%s] Waiting for Service %s to become ready...", ct.client.ClusterName(), service.Name())
+	return ct.probeFromPods(nil, func(pod Pod) error {
+		return ct.waitForServiceFrom(ctx, pod, service)
+	})
+}
+
+// waitForServiceFrom waits until the given service resolves to its IP from
+// the given client pod.
+func (ct *ConnectivityTest) waitForServiceFrom(ctx context.Context, pod Pod, service Service) error {
+	ct.Logf("⌛ [%s] Waiting for Service %s to become ready from %s...", pod.K8sClient.ClusterName(), service.Name(), pod.Name())
 
 	// Retry the service lookup for the duration of the ready context.
 	ctx, cancel := context.WithTimeout(ctx, ct.params.serviceReadyTimeout())
 	defer cancel()
 
-	pod := ct.RandomClientPod()
-	if pod == nil {
-		return fmt.Errorf("no client pod available")
-	}
-
-	for {
-		// Don't retry lookups more often than once per second.
-		r := time.After(time.Second)
+	for attempt := 1; ; attempt++ {
+		// Don't retry lookups more often than the configured poll interval,
+		// backing off up to the configured ceiling on repeated failures.
+		r := time.After(ct.params.servicePollBackoff(attempt))
 
-		stdout, err := ct.client.ExecInPod(ctx,
+		stdout, err := pod.K8sClient.ExecInPod(ctx,
 			pod.Pod.Namespace, pod.Pod.Name, pod.Pod.Labels["name"],
-			[]string{"nslookup", service.Service.Name}) // BusyBox nslookup doesn't support any arguments.
+			[]string{"nslookup", ct.ServiceFQDN(service)}) // BusyBox nslookup doesn't support any arguments.
 
 		// Lookup successful.
 		if err == nil {
 			svcIP := ""
 			switch service.Service.Spec.Type {
-			case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort:
+			case corev1.ServiceTypeExternalName:
+				// ExternalName services have neither a ClusterIP nor a NodePort,
+				// the name only resolves to a CNAME of the external host.
+				return nil
+			case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+				// The cluster DNS resolves LoadBalancer Services to their
+				// ClusterIP as well, not to the load balancer address.
 				svcIP = service.Service.Spec.ClusterIP
-			case corev1.ServiceTypeLoadBalancer:
-				if len(service.Service.Status.LoadBalancer.Ingress) > 0 {
-					svcIP = service.Service.Status.LoadBalancer.Ingress[0].IP
-				}
 			}
 			if svcIP == "" {
 				return nil
@@ -1385,9 +4002,13 @@ func (ct *ConnectivityTest) waitForService(ctx context.Context, service Service)
 
 		ct.Debugf("Error waiting for service %s: %s: %s", service.Name(), err, stdout.String())
 
+		if limit := ct.params.ServiceMaxAttempts; limit > 0 && attempt >= limit {
+			return newSetupError(ErrServiceTimeout, fmt.Errorf("gave up waiting for service %s after %d lookups (last error: %w)", service.Name(), attempt, err))
+		}
+
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("timeout reached waiting for service %s (last error: %w)", service.Name(), err)
+			return newSetupError(ErrServiceTimeout, fmt.Errorf("timeout reached waiting for service %s (last error: %w)", service.Name(), err))
 		default:
 		}
 
@@ -1396,12 +4017,87 @@ func (ct *ConnectivityTest) waitForService(ctx context.Context, service Service)
 	}
 }
 
+// checkPodImage warns if the main container of the given test pod, named
+// after its deployment, doesn't run the configured image, e.g. because a
+// mutating webhook rewrote it or a stale deployment was reused. With
+// StrictImages, an error is returned instead.
+func (ct *ConnectivityTest) checkPodImage(client *k8s.Client, pod *corev1.Pod, image string) error {
+	err := podImageMismatch(pod, image)
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("[%s] %w", client.ClusterName(), err)
+	if ct.params.StrictImages {
+		return err
+	}
+	ct.Warn(err.Error())
+	return nil
+}
+
+// podImageMismatch returns an error if the container of the given pod named
+// after its "name" label doesn't run the given image.
+func podImageMismatch(pod *corev1.Pod, image string) error {
+	name := pod.Labels["name"]
+	for _, c := range pod.Spec.Containers {
+		if c.Name != name {
+			continue
+		}
+		if c.Image != image {
+			return fmt.Errorf("container %s of pod %s runs image %s instead of the configured %s", c.Name, pod.Name, c.Image, image)
+		}
+		return nil
+	}
+	return fmt.Errorf("pod %s has no container %s", pod.Name, name)
+}
+
+// nodePortAddresses returns the addresses of the node a NodePort service of
+// the given IP families is expected to be reachable on: the host IP, followed
+// by the first internal address of each other family of the service, e.g. the
+// IPv6 address on dual-stack clusters, as found in the node status.
+func nodePortAddresses(hostIP string, node *corev1.Node, families []corev1.IPFamily) []string {
+	addrs := []string{hostIP}
+	if node == nil {
+		return addrs
+	}
+	seen := map[corev1.IPFamily]bool{ipFamilyOf(hostIP): true}
+	for _, addr := range node.Status.Addresses {
+		if addr.Type != corev1.NodeInternalIP {
+			continue
+		}
+		family := ipFamilyOf(addr.Address)
+		if family == "" || seen[family] || !slices.Contains(families, family) {
+			continue
+		}
+		seen[family] = true
+		addrs = append(addrs, addr.Address)
+	}
+	return addrs
+}
+
+// ipFamilyOf returns the IP family of the given address, or an empty string
+// if it isn't a valid IP address.
+func ipFamilyOf(addr string) corev1.IPFamily {
+	ip := net.ParseIP(addr)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return corev1.IPv4Protocol
+	default:
+		return corev1.IPv6Protocol
+	}
+}
+
 // waitForNodePorts waits until all the nodeports in a service are available on a given node.
 func (ct *ConnectivityTest) waitForNodePorts(ctx context.Context, nodeIP string, service Service) error {
-	pod := ct.RandomClientPod()
-	if pod == nil {
-		return fmt.Errorf("no client pod available")
-	}
+	return ct.probeFromPods(nil, func(pod Pod) error {
+		return ct.waitForNodePortsFrom(ctx, pod, nodeIP, service)
+	})
+}
+
+// waitForNodePortsFrom waits until all the nodeports in a service are
+// available on a given node from the given client pod.
+func (ct *ConnectivityTest) waitForNodePortsFrom(ctx context.Context, pod Pod, nodeIP string, service Service) error {
 	ctx, cancel := context.WithTimeout(ctx, ct.params.serviceReadyTimeout())
 	defer cancel()
 
@@ -1410,10 +4106,10 @@ func (ct *ConnectivityTest) waitForNodePorts(ctx context.Context, nodeIP string,
 		if nodePort == 0 {
 			continue
 		}
-		ct.Logf("⌛ [%s] Waiting for NodePort %s:%d (%s) to become ready...",
-			ct.client.ClusterName(), nodeIP, nodePort, service.Name())
+		ct.Logf("⌛ [%s] Waiting for NodePort %s:%d (%s) to become ready from %s...",
+			pod.K8sClient.ClusterName(), nodeIP, nodePort, service.Name(), pod.Name())
 		for {
-			e, err := ct.client.ExecInPod(ctx,
+			e, err := pod.K8sClient.ExecInPod(ctx,
 				pod.Pod.Namespace, pod.Pod.Name, pod.Pod.Labels["name"],
 				[]string{"nc", "-w", "3", "-z", nodeIP, strconv.Itoa(int(nodePort))})
 			if err == nil {
@@ -1424,29 +4120,263 @@ func (ct *ConnectivityTest) waitForNodePorts(ctx context.Context, nodeIP string,
 
 			select {
 			case <-ctx.Done():
-				return fmt.Errorf("timeout reached waiting for NodePort %s:%d (%s) (last error: %w)", nodeIP, nodePort, service.Name(), err)
-			case <-time.After(time.Second):
+				return newSetupError(ErrServiceTimeout, fmt.Errorf("timeout reached waiting for NodePort %s:%d (%s) (last error: %w)", nodeIP, nodePort, service.Name(), err))
+			case <-time.After(ct.params.pollInterval()):
 			}
 		}
 	}
 	return nil
 }
 
-func (ct *ConnectivityTest) waitForCiliumEndpoint(ctx context.Context, client *k8s.Client, namespace, name string) error {
-	ct.Logf("⌛ [%s] Waiting for CiliumEndpoint for pod %s/%s to appear...", client.ClusterName(), namespace, name)
+// waitForHostPort waits until the given HostPort of the echo pod is reachable
+// on its node from the client pods of its cluster.
+func (ct *ConnectivityTest) waitForHostPort(ctx context.Context, echoPod Pod, hostPort int) error {
+	return ct.probeFromPods(echoPod.K8sClient, func(pod Pod) error {
+		return ct.waitForHostPortFrom(ctx, pod, echoPod, hostPort)
+	})
+}
+
+// waitForHostPortFrom waits until the given HostPort of the echo pod is
+// reachable on its node from the given client pod.
+func (ct *ConnectivityTest) waitForHostPortFrom(ctx context.Context, pod Pod, echoPod Pod, hostPort int) error {
+	ctx, cancel := context.WithTimeout(ctx, ct.params.serviceReadyTimeout())
+	defer cancel()
+
+	hostIP := echoPod.Pod.Status.HostIP
+	ct.Logf("⌛ [%s] Waiting for HostPort %s:%d (%s) to become ready...",
+		pod.K8sClient.ClusterName(), hostIP, hostPort, echoPod.Name())
 	for {
-		_, err := client.GetCiliumEndpoint(ctx, ct.params.TestNamespace, name, metav1.GetOptions{})
+		e, err := pod.K8sClient.ExecInPod(ctx,
+			pod.Pod.Namespace, pod.Pod.Name, pod.Pod.Labels["name"],
+			[]string{"nc", "-w", "3", "-z", hostIP, strconv.Itoa(hostPort)})
 		if err == nil {
 			return nil
 		}
 
+		ct.Debugf("Error waiting for HostPort %s:%d (%s): %s: %s", hostIP, hostPort, echoPod.Name(), err, e.String())
+
+		select {
+		case <-ctx.Done():
+			return newSetupError(ErrServiceTimeout, fmt.Errorf("timeout reached waiting for HostPort %s:%d (%s) (last error: %w)", hostIP, hostPort, echoPod.Name(), err))
+		case <-time.After(ct.params.pollInterval()):
+		}
+	}
+}
+
+// waitForEchoHTTP waits until the echo server of the given echo pod answers
+// HTTP requests to its pod IP and port from the client pods of its cluster
+// with a 200.
+func (ct *ConnectivityTest) waitForEchoHTTP(ctx context.Context, echoPod Pod) error {
+	return ct.probeFromPods(echoPod.K8sClient, func(pod Pod) error {
+		return ct.waitForEchoHTTPFrom(ctx, pod, echoPod)
+	})
+}
+
+// waitForEchoHTTPFrom waits until the echo server of the given echo pod
+// answers HTTP requests to its pod IP and port from the given client pod with
+// a 200.
+func (ct *ConnectivityTest) waitForEchoHTTPFrom(ctx context.Context, pod Pod, echoPod Pod) error {
+	ctx, cancel := context.WithTimeout(ctx, ct.params.serviceReadyTimeout())
+	defer cancel()
+
+	url := fmt.Sprintf("%s://%s%s", echoPod.Scheme(),
+		net.JoinHostPort(echoPod.Address(IPFamilyAny), fmt.Sprint(echoPod.Port())), echoPod.Path())
+	ct.Logf("⌛ [%s] Waiting for echo server %s (%s) to serve HTTP...", echoPod.K8sClient.ClusterName(), url, echoPod.Name())
+	for {
+		e, err := pod.K8sClient.ExecInPod(ctx,
+			pod.Pod.Namespace, pod.Pod.Name, pod.Pod.Labels["name"],
+			[]string{"curl", "--silent", "--show-error", "--output", "/dev/null",
+				"--connect-timeout", "3", "--write-out", "%{http_code}", url})
+		if err == nil {
+			code := strings.TrimSpace(e.String())
+			if code == "200" {
+				return nil
+			}
+			err = fmt.Errorf("unexpected HTTP status code %s", code)
+		}
+
+		ct.Debugf("Error waiting for echo server %s (%s): %s: %s", url, echoPod.Name(), err, e.String())
+
+		select {
+		case <-ctx.Done():
+			return newSetupError(ErrServiceTimeout, fmt.Errorf("timeout reached waiting for echo server %s (%s) to serve HTTP (last error: %w)", url, echoPod.Name(), err))
+		case <-time.After(ct.params.pollInterval()):
+		}
+	}
+}
+
+func (ct *ConnectivityTest) waitForCiliumEndpoint(ctx context.Context, client *k8s.Client, namespace, name string) (*ciliumv2.CiliumEndpoint, error) {
+	ct.Logf("⌛ [%s] Waiting for CiliumEndpoint for pod %s/%s to appear...", client.ClusterName(), namespace, name)
+	for {
+		cep, err := ct.readClient(client).GetCiliumEndpoint(ctx, ct.params.TestNamespace, name, metav1.GetOptions{})
+		if err == nil {
+			if !ct.params.WaitCEPAddressing || len(endpointAddressing(cep)) > 0 {
+				return cep, nil
+			}
+			err = fmt.Errorf("CiliumEndpoint has no addressing yet")
+		}
+
 		ct.Debugf("[%s] Error getting CiliumEndpoint for pod %s/%s: %s", client.ClusterName(), namespace, name, err)
 
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("aborted waiting for CiliumEndpoint for pod %s to appear: %w (last error: %s)", name, ctx.Err(), err)
+			return nil, newSetupError(ErrEndpointTimeout, fmt.Errorf("aborted waiting for CiliumEndpoint for pod %s to appear: %w (last error: %s)", name, ctx.Err(), err))
 		case <-time.After(2 * time.Second):
 			continue
 		}
 	}
 }
+
+// endpointAddressing returns the non-empty address pairs reported in the
+// networking status of the given CiliumEndpoint.
+func endpointAddressing(cep *ciliumv2.CiliumEndpoint) ciliumv2.AddressPairList {
+	if cep.Status.Networking == nil {
+		return nil
+	}
+
+	var addressing ciliumv2.AddressPairList
+	for _, pair := range cep.Status.Networking.Addressing {
+		if pair != nil && (pair.IPV4 != "" || pair.IPV6 != "") {
+			addressing = append(addressing, pair)
+		}
+	}
+	return addressing
+}
+
+// validateUniqueNodePorts checks that no NodePort is allocated to more than one
+// of the given services. The API server prevents such collisions, unless the
+// NodePorts are managed externally.
+func validateUniqueNodePorts(services []Service) error {
+	owners := make(map[string][]string)
+	for _, s := range services {
+		for _, port := range s.Service.Spec.Ports {
+			if port.NodePort == 0 {
+				continue
+			}
+			key := fmt.Sprintf("%d/%s", port.NodePort, port.Protocol)
+			if !slices.Contains(owners[key], s.Name()) {
+				owners[key] = append(owners[key], s.Name())
+			}
+		}
+	}
+
+	var duplicates []string
+	for port, svcs := range owners {
+		if len(svcs) > 1 {
+			sort.Strings(svcs)
+			duplicates = append(duplicates, fmt.Sprintf("%s (services %s)", port, strings.Join(svcs, ", ")))
+		}
+	}
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		return fmt.Errorf("NodePorts allocated to more than one service: %s", strings.Join(duplicates, "; "))
+	}
+
+	return nil
+}
+
+// validatePodCIDRs checks that the IPs of the client and echo pods fall within
+// the ExpectedPodCIDRs, to catch IPAM misconfigurations before running the
+// tests. The IPs are taken from the CiliumEndpoint of each pod if known, and
+// from the pod status otherwise.
+func (ct *ConnectivityTest) validatePodCIDRs() error {
+	podIPs := make(map[string][]string)
+	for _, m := range []map[string]Pod{ct.ClientPods(), ct.EchoPods()} {
+		for name, pod := range m {
+			for _, pair := range ct.EndpointAddressing(pod) {
+				for _, ip := range []string{pair.IPV4, pair.IPV6} {
+					if ip != "" {
+						podIPs[name] = append(podIPs[name], ip)
+					}
+				}
+			}
+			if len(podIPs[name]) > 0 {
+				continue
+			}
+			for _, ip := range pod.Pod.Status.PodIPs {
+				podIPs[name] = append(podIPs[name], ip.IP)
+			}
+		}
+	}
+
+	var cidrs []*net.IPNet
+	for _, cidr := range ct.params.ExpectedPodCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid expected pod CIDR %q: %w", cidr, err)
+		}
+		cidrs = append(cidrs, ipNet)
+	}
+
+	if outside := podIPsOutsideCIDRs(podIPs, cidrs); len(outside) > 0 {
+		return fmt.Errorf("pod IPs outside of the expected pod CIDRs %s: %s",
+			strings.Join(ct.params.ExpectedPodCIDRs, ", "), strings.Join(outside, "; "))
+	}
+	return nil
+}
+
+// podIPsOutsideCIDRs returns the sorted "pod (IP)" descriptions of the given
+// pod IPs not contained in any of the CIDRs. IPs of an address family without
+// any CIDR are ignored.
+func podIPsOutsideCIDRs(podIPs map[string][]string, cidrs []*net.IPNet) []string {
+	isIPv4 := func(ip net.IP) bool { return ip.To4() != nil }
+
+	var outside []string
+	for name, ips := range podIPs {
+		for _, s := range ips {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				outside = append(outside, fmt.Sprintf("%s (invalid IP %q)", name, s))
+				continue
+			}
+			checked, contained := false, false
+			for _, cidr := range cidrs {
+				if isIPv4(cidr.IP) != isIPv4(ip) {
+					continue
+				}
+				checked = true
+				if cidr.Contains(ip) {
+					contained = true
+					break
+				}
+			}
+			if checked && !contained {
+				outside = append(outside, fmt.Sprintf("%s (%s)", name, s))
+			}
+		}
+	}
+	sort.Strings(outside)
+	return outside
+}
+
+// validateUniqueEndpointIPs checks that no IP address has been allocated to
+// more than one of the given CiliumEndpoints.
+func validateUniqueEndpointIPs(endpoints []*ciliumv2.CiliumEndpoint) error {
+	owners := make(map[string][]string)
+	for _, ep := range endpoints {
+		if ep.Status.Networking == nil {
+			continue
+		}
+		for _, pair := range ep.Status.Networking.Addressing {
+			for _, ip := range []string{pair.IPV4, pair.IPV6} {
+				if ip != "" {
+					owners[ip] = append(owners[ip], ep.Name)
+				}
+			}
+		}
+	}
+
+	var duplicates []string
+	for ip, pods := range owners {
+		if len(pods) > 1 {
+			sort.Strings(pods)
+			duplicates = append(duplicates, fmt.Sprintf("%s (pods %s)", ip, strings.Join(pods, ", ")))
+		}
+	}
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		return fmt.Errorf("IP addresses allocated to more than one endpoint: %s", strings.Join(duplicates, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,1757 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cilium/cilium/api/v1/models"
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/cilium/cilium-cli/defaults"
+	"github.com/cilium/cilium-cli/k8s"
+)
+
+func TestValidateServiceAccount(t *testing.T) {
+	tests := map[string]struct {
+		serviceAccount string
+		expected       string
+		wantErr        bool
+	}{
+		"ServiceAccount matches the deployment": {
+			serviceAccount: "client",
+			wantErr:        false,
+		},
+		"ServiceAccount matches the configured one": {
+			serviceAccount: "shared",
+			expected:       "shared",
+			wantErr:        false,
+		},
+		"ServiceAccount matches the deployment instead of the configured one": {
+			serviceAccount: "client",
+			expected:       "shared",
+			wantErr:        true,
+		},
+		"ServiceAccount was replaced": {
+			serviceAccount: "default",
+			wantErr:        true,
+		},
+		"ServiceAccount was removed": {
+			serviceAccount: "",
+			wantErr:        true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "client-1234",
+					Labels: map[string]string{"name": "client"},
+				},
+				Spec: corev1.PodSpec{ServiceAccountName: tc.serviceAccount},
+			}
+			if err := validateServiceAccount(pod, tc.expected); (err != nil) != tc.wantErr {
+				t.Errorf("validateServiceAccount() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUniqueEndpointIPs(t *testing.T) {
+	endpoint := func(name string, ips ...string) *ciliumv2.CiliumEndpoint {
+		ep := &ciliumv2.CiliumEndpoint{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: ciliumv2.EndpointStatus{
+				Networking: &ciliumv2.EndpointNetworking{},
+			},
+		}
+		for _, ip := range ips {
+			ep.Status.Networking.Addressing = append(ep.Status.Networking.Addressing, &ciliumv2.AddressPair{IPV4: ip})
+		}
+		return ep
+	}
+
+	tests := map[string]struct {
+		endpoints []*ciliumv2.CiliumEndpoint
+		wantErr   bool
+	}{
+		"distinct IPs": {
+			endpoints: []*ciliumv2.CiliumEndpoint{endpoint("echo-1", "10.0.0.1"), endpoint("echo-2", "10.0.0.2")},
+			wantErr:   false,
+		},
+		"duplicate IP": {
+			endpoints: []*ciliumv2.CiliumEndpoint{endpoint("echo-1", "10.0.0.1"), endpoint("echo-2", "10.0.0.1")},
+			wantErr:   true,
+		},
+		"endpoint without networking": {
+			endpoints: []*ciliumv2.CiliumEndpoint{endpoint("echo-1", "10.0.0.1"), {ObjectMeta: metav1.ObjectMeta{Name: "echo-2"}}},
+			wantErr:   false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := validateUniqueEndpointIPs(tc.endpoints); (err != nil) != tc.wantErr {
+				t.Errorf("validateUniqueEndpointIPs() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUniqueNodePorts(t *testing.T) {
+	service := func(name string, nodePorts ...int32) Service {
+		svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "cilium-test", Name: name}}
+		for _, nodePort := range nodePorts {
+			svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{Protocol: corev1.ProtocolTCP, NodePort: nodePort})
+		}
+		return Service{Service: svc}
+	}
+
+	tests := map[string]struct {
+		services []Service
+		wantErr  bool
+	}{
+		"distinct NodePorts": {
+			services: []Service{service("echo-same-node", 30001), service("echo-other-node", 30002)},
+			wantErr:  false,
+		},
+		"duplicate NodePort": {
+			services: []Service{service("echo-same-node", 30001), service("cilium-ingress", 30002, 30001)},
+			wantErr:  true,
+		},
+		"services without NodePort": {
+			services: []Service{service("echo-same-node", 0), service("echo-other-node", 0)},
+			wantErr:  false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := validateUniqueNodePorts(tc.services); (err != nil) != tc.wantErr {
+				t.Errorf("validateUniqueNodePorts() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	gr := schema.GroupResource{Resource: "namespaces"}
+
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"webhook timeout": {
+			err:  k8sErrors.NewInternalError(errors.New(`failed calling webhook "ns.example.com": context deadline exceeded`)),
+			want: true,
+		},
+		"server timeout": {
+			err:  k8sErrors.NewServerTimeout(gr, "create", 1),
+			want: true,
+		},
+		"service unavailable": {
+			err:  k8sErrors.NewServiceUnavailable("apiserver is shutting down"),
+			want: true,
+		},
+		"webhook denial": {
+			err:  k8sErrors.NewForbidden(gr, "cilium-test", errors.New(`admission webhook "ns.example.com" denied the request`)),
+			want: false,
+		},
+		"already exists": {
+			err:  k8sErrors.NewAlreadyExists(gr, "cilium-test"),
+			want: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isTransientError(tc.err); got != tc.want {
+				t.Errorf("isTransientError() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsAdmissionRejection(t *testing.T) {
+	gr := schema.GroupResource{Resource: "namespaces"}
+	gk := schema.GroupKind{Kind: "Namespace"}
+
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"nil": {
+			err:  nil,
+			want: false,
+		},
+		"forbidden": {
+			err:  k8sErrors.NewForbidden(gr, "cilium-test", errors.New("namespace is protected")),
+			want: true,
+		},
+		"invalid": {
+			err:  k8sErrors.NewInvalid(gk, "cilium-test", nil),
+			want: true,
+		},
+		"denial without status code": {
+			err: &k8sErrors.StatusError{ErrStatus: metav1.Status{
+				Status:  metav1.StatusFailure,
+				Code:    http.StatusBadRequest,
+				Message: "denied",
+			}},
+			want: true,
+		},
+		"wrapped forbidden": {
+			err:  fmt.Errorf("unable to create namespace: %w", k8sErrors.NewForbidden(gr, "cilium-test", errors.New("denied"))),
+			want: true,
+		},
+		"bad request": {
+			err:  k8sErrors.NewBadRequest("malformed request"),
+			want: false,
+		},
+		"already exists": {
+			err:  k8sErrors.NewAlreadyExists(gr, "cilium-test"),
+			want: false,
+		},
+		"message only": {
+			err:  errors.New(`admission webhook "ns.example.com" denied the request`),
+			want: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isAdmissionRejection(tc.err); got != tc.want {
+				t.Errorf("isAdmissionRejection() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPhaseTimer(t *testing.T) {
+	var timer phaseTimer
+	if got := timer.String(); got != "" {
+		t.Errorf("phaseTimer.String() = %q, want empty", got)
+	}
+
+	timer.start("deployment-ready")
+	timer.start("dns")
+	timer.start("deployment-ready")
+	timer.stop()
+	timer.stop()
+
+	if want := []string{"deployment-ready", "dns"}; !reflect.DeepEqual(timer.phases, want) {
+		t.Errorf("phaseTimer.phases = %v, want %v", timer.phases, want)
+	}
+	if got := timer.String(); !strings.HasPrefix(got, "deployment-ready took ") || !strings.Contains(got, ", dns took ") {
+		t.Errorf("phaseTimer.String() = %q", got)
+	}
+}
+
+func TestDisableSAToken(t *testing.T) {
+	for name, tt := range map[string]struct {
+		disable bool
+		want    *bool
+	}{
+		"default":  {disable: false, want: nil},
+		"disabled": {disable: true, want: new(bool)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dep := newDeployment(deploymentParameters{Name: "client", DisableSAToken: tt.disable})
+			if got := dep.Spec.Template.Spec.AutomountServiceAccountToken; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("deployment: expected %v, got %v", tt.want, got)
+			}
+			ds := newDaemonSet(daemonSetParameters{Name: "host-netns", DisableSAToken: tt.disable})
+			if got := ds.Spec.Template.Spec.AutomountServiceAccountToken; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("daemonset: expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSeccompProfile(t *testing.T) {
+	path := "profiles/audit.json"
+	tests := map[string]struct {
+		profile string
+		want    *corev1.SeccompProfile
+		wantErr bool
+	}{
+		"unset": {
+			profile: "",
+			want:    nil,
+		},
+		"runtime default": {
+			profile: "RuntimeDefault",
+			want:    &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		"localhost": {
+			profile: "Localhost/profiles/audit.json",
+			want:    &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: &path},
+		},
+		"localhost without path": {
+			profile: "Localhost/",
+			wantErr: true,
+		},
+		"invalid": {
+			profile: "runtime/default",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseSeccompProfile(tc.profile)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseSeccompProfile() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("parseSeccompProfile() = %v, want %v", got, tc.want)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			var wantSC *corev1.PodSecurityContext
+			if tc.want != nil {
+				wantSC = &corev1.PodSecurityContext{SeccompProfile: tc.want}
+			}
+			dep := newDeployment(deploymentParameters{Name: "client", SeccompProfile: got})
+			if sc := dep.Spec.Template.Spec.SecurityContext; !reflect.DeepEqual(sc, wantSC) {
+				t.Errorf("deployment: expected %v, got %v", wantSC, sc)
+			}
+			ds := newDaemonSet(daemonSetParameters{Name: "host-netns", SeccompProfile: got})
+			if sc := ds.Spec.Template.Spec.SecurityContext; !reflect.DeepEqual(sc, wantSC) {
+				t.Errorf("daemonset: expected %v, got %v", wantSC, sc)
+			}
+		})
+	}
+}
+
+func TestNewPrePullDaemonSet(t *testing.T) {
+	ct := &ConnectivityTest{params: Parameters{CurlImage: "curl"}}
+	ds := ct.newPrePullDaemonSet([]corev1.Container{
+		prePullContainer("json-mock"),
+		{Image: "coredns", Args: []string{"-version"}},
+	})
+
+	spec := ds.Spec.Template.Spec
+	if len(spec.Containers) != 1 || spec.Containers[0].Image != "curl" || len(spec.Containers[0].Command) == 0 {
+		t.Errorf("expected a single sleeping curl container, got %v", spec.Containers)
+	}
+	if len(spec.InitContainers) != 2 {
+		t.Fatalf("expected 2 init containers, got %d", len(spec.InitContainers))
+	}
+	for i, c := range spec.InitContainers {
+		if want := fmt.Sprintf("%s-%d", imagePrePullDaemonSetName, i); c.Name != want {
+			t.Errorf("init container %d: got name %q, want %q", i, c.Name, want)
+		}
+		if len(c.Command) == 0 && len(c.Args) == 0 {
+			t.Errorf("init container %s runs the default entrypoint of %s", c.Name, c.Image)
+		}
+	}
+}
+
+func TestInitContainersCompleted(t *testing.T) {
+	terminated := func(code int32) corev1.ContainerStatus {
+		return corev1.ContainerStatus{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: code}}}
+	}
+	running := corev1.ContainerStatus{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+	for name, tt := range map[string]struct {
+		statuses []corev1.ContainerStatus
+		want     bool
+	}{
+		"none":      {},
+		"completed": {statuses: []corev1.ContainerStatus{terminated(0), terminated(0)}, want: true},
+		"running":   {statuses: []corev1.ContainerStatus{terminated(0), running}},
+		"failed":    {statuses: []corev1.ContainerStatus{terminated(0), terminated(127)}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pod := &corev1.Pod{Status: corev1.PodStatus{InitContainerStatuses: tt.statuses}}
+			if got := initContainersCompleted(pod, 2); got != tt.want {
+				t.Errorf("initContainersCompleted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisableNetRaw(t *testing.T) {
+	ds := newDaemonSet(daemonSetParameters{Name: "host-netns"})
+	if got := ds.Spec.Template.Spec.Containers[0].SecurityContext; got == nil || got.Capabilities == nil ||
+		!reflect.DeepEqual(got.Capabilities.Add, []corev1.Capability{"NET_RAW"}) {
+		t.Errorf("expected NET_RAW by default, got %v", got)
+	}
+
+	ds = newDaemonSet(daemonSetParameters{Name: "host-netns", DisableNetRaw: true})
+	got := ds.Spec.Template.Spec.Containers[0].SecurityContext
+	if got == nil || got.Capabilities == nil {
+		t.Fatalf("expected the security context to be kept, got %v", got)
+	}
+	if len(got.Capabilities.Add) != 0 {
+		t.Errorf("expected NET_RAW to be dropped, got %v", got.Capabilities.Add)
+	}
+}
+
+func TestHostNetNSDaemonSetsNodes(t *testing.T) {
+	tests := map[string]struct {
+		params Parameters
+		want   []*corev1.Affinity
+	}{
+		"all nodes": {
+			params: Parameters{},
+			want:   []*corev1.Affinity{nil},
+		},
+		"given nodes": {
+			params: Parameters{HostNetNSNodes: []string{"node-a", "node-b"}},
+			want: []*corev1.Affinity{
+				{NodeAffinity: &corev1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchFields: []corev1.NodeSelectorRequirement{
+							{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-a", "node-b"}},
+						},
+					}},
+				}}},
+			},
+		},
+		"given nodes and per-architecture image": {
+			params: Parameters{HostNetNSNodes: []string{"node-a"}, HostNetNSImages: map[string]string{"arm64": "curl:arm64"}},
+			want: []*corev1.Affinity{
+				{NodeAffinity: &corev1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"arm64"}},
+						},
+						MatchFields: []corev1.NodeSelectorRequirement{
+							{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-a"}},
+						},
+					}},
+				}}},
+				{NodeAffinity: &corev1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"arm64"}},
+						},
+						MatchFields: []corev1.NodeSelectorRequirement{
+							{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-a"}},
+						},
+					}},
+				}}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{params: tc.params}
+			dss := ct.newHostNetNSDaemonSets()
+			if len(dss) != len(tc.want) {
+				t.Fatalf("got %d DaemonSets, want %d", len(dss), len(tc.want))
+			}
+			for i, ds := range dss {
+				if got := ds.Spec.Template.Spec.Affinity; !reflect.DeepEqual(got, tc.want[i]) {
+					t.Errorf("DaemonSet %s: got affinity %v, want %v", ds.Name, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHostNetNSRequired(t *testing.T) {
+	withoutCilium := FeatureSet{FeatureNodeWithoutCilium: FeatureStatus{Enabled: true}}
+	for name, tt := range map[string]struct {
+		params   Parameters
+		features FeatureSet
+		want     bool
+	}{
+		"default":                            {},
+		"node without cilium":                {features: withoutCilium, want: true},
+		"node without cilium on single node": {params: Parameters{SingleNode: true}, features: withoutCilium},
+		"node without cilium in minimal":     {params: Parameters{Minimal: true}, features: withoutCilium},
+		"client source":                      {params: Parameters{ClientSource: kindHostNetNS}, want: true},
+		"client source on single node":       {params: Parameters{ClientSource: kindHostNetNS, SingleNode: true}, want: true},
+		"client source in minimal":           {params: Parameters{ClientSource: kindHostNetNS, Minimal: true}, want: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{
+				params:              tt.params,
+				features:            tt.features,
+				optionalDeployments: map[OptionalDeployment]bool{DeployNodeWithoutCilium: true},
+			}
+			if got := ct.hostNetNSRequired(); got != tt.want {
+				t.Errorf("hostNetNSRequired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopologyZones(t *testing.T) {
+	node := func(zone string, unschedulable bool) *corev1.Node {
+		n := &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: unschedulable}}
+		if zone != "" {
+			n.Labels = map[string]string{corev1.LabelTopologyZone: zone}
+		}
+		return n
+	}
+
+	tests := map[string]struct {
+		nodes map[string]*corev1.Node
+		want  []string
+	}{
+		"multiple zones": {
+			nodes: map[string]*corev1.Node{
+				"node-a": node("zone-b", false),
+				"node-b": node("zone-a", false),
+				"node-c": node("zone-b", false),
+			},
+			want: []string{"zone-a", "zone-b"},
+		},
+		"unschedulable and unlabeled nodes": {
+			nodes: map[string]*corev1.Node{
+				"node-a": node("zone-a", false),
+				"node-b": node("zone-b", true),
+				"node-c": node("", false),
+			},
+			want: []string{"zone-a"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := topologyZones(tc.nodes, nil); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("topologyZones() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNamespaceEndpoints(t *testing.T) {
+	endpoint := func(id int64, namespace, pod string) *models.Endpoint {
+		return &models.Endpoint{ID: id, Status: &models.EndpointStatus{
+			ExternalIdentifiers: &models.EndpointIdentifiers{K8sNamespace: namespace, K8sPodName: pod},
+		}}
+	}
+	endpoints := []*models.Endpoint{
+		endpoint(1, "cilium-test", "client-6b4b857d98-abcde"),
+		endpoint(2, "kube-system", "coredns-565d847f94-fghij"),
+		{ID: 3, Status: &models.EndpointStatus{}},
+		nil,
+	}
+
+	got := namespaceEndpoints("node-a", endpoints, "cilium-test")
+	want := []string{"endpoint 1 of pod cilium-test/client-6b4b857d98-abcde on node node-a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("namespaceEndpoints() = %v, want %v", got, want)
+	}
+	if got := namespaceEndpoints("node-a", endpoints, "other"); len(got) != 0 {
+		t.Errorf("namespaceEndpoints() = %v, want none", got)
+	}
+}
+
+func TestPodsConditionTrue(t *testing.T) {
+	pod := func(name string, conditions ...corev1.PodCondition) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.PodStatus{Conditions: conditions},
+		}
+	}
+	ready := corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue}
+	gateTrue := corev1.PodCondition{Type: "example.com/network-ready", Status: corev1.ConditionTrue}
+	gateFalse := corev1.PodCondition{Type: "example.com/network-ready", Status: corev1.ConditionFalse}
+
+	tests := map[string]struct {
+		pods    []corev1.Pod
+		wantErr bool
+	}{
+		"condition True": {
+			pods:    []corev1.Pod{pod("client-1", ready, gateTrue), pod("client-2", gateTrue)},
+			wantErr: false,
+		},
+		"condition False": {
+			pods:    []corev1.Pod{pod("client-1", ready, gateTrue), pod("client-2", ready, gateFalse)},
+			wantErr: true,
+		},
+		"condition missing": {
+			pods:    []corev1.Pod{pod("client-1", ready)},
+			wantErr: true,
+		},
+		"no pods": {
+			wantErr: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := podsConditionTrue(tc.pods, "example.com/network-ready"); (err != nil) != tc.wantErr {
+				t.Errorf("podsConditionTrue() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestProbeFromPods(t *testing.T) {
+	src, dst := &k8s.Client{}, &k8s.Client{}
+	pod := func(name string, client *k8s.Client) Pod {
+		return Pod{K8sClient: client, Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "cilium-test", Name: name}}}
+	}
+	clientPods := map[string]Pod{
+		"client-b": pod("client-b", src),
+		"client-a": pod("client-a", src),
+		"client-c": pod("client-c", dst),
+	}
+
+	for name, tt := range map[string]struct {
+		all     bool
+		client  *k8s.Client
+		pods    map[string]Pod
+		failing string
+		want    []string
+		wantErr string
+	}{
+		"random pod": {all: false, pods: clientPods},
+		"all pods":   {all: true, pods: clientPods, want: []string{"client-a", "client-b", "client-c"}},
+		"all pods with failure": {
+			all: true, pods: clientPods, failing: "client-b",
+			want: []string{"client-a", "client-b", "client-c"}, wantErr: "from pod cilium-test/client-b: unreachable",
+		},
+		"random pod of cluster": {all: false, client: dst, pods: clientPods, want: []string{"client-c"}},
+		"all pods of cluster":   {all: true, client: src, pods: clientPods, want: []string{"client-a", "client-b"}},
+		"cluster without pods": {
+			all: true, client: dst, pods: map[string]Pod{"client-a": pod("client-a", src)},
+			want: []string{"client-a"},
+		},
+		"no pods": {all: true, pods: map[string]Pod{}, wantErr: "no client pod available"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{params: Parameters{ProbeFromAllClients: tt.all}, clientPods: tt.pods}
+			var got []string
+			err := ct.probeFromPods(tt.client, func(p Pod) error {
+				got = append(got, p.Pod.Name)
+				if p.Pod.Name == tt.failing {
+					return errors.New("unreachable")
+				}
+				return nil
+			})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("expected error %q, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if !tt.all && tt.want == nil {
+				// A random pod is probed from.
+				if len(got) != 1 {
+					t.Errorf("expected a single pod, got %v", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected pods %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPodsInCluster(t *testing.T) {
+	src, dst := &k8s.Client{}, &k8s.Client{}
+	pod := func(name string, client *k8s.Client) Pod {
+		return Pod{K8sClient: client, Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "cilium-test", Name: name}}}
+	}
+	pods := map[string]Pod{
+		"echo-b": pod("echo-b", src),
+		"echo-a": pod("echo-a", src),
+		"echo-c": pod("echo-c", dst),
+	}
+
+	for name, tt := range map[string]struct {
+		client *k8s.Client
+		want   []string
+	}{
+		"src":     {client: src, want: []string{"cilium-test/echo-a", "cilium-test/echo-b"}},
+		"dst":     {client: dst, want: []string{"cilium-test/echo-c"}},
+		"unknown": {client: &k8s.Client{}, want: nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, p := range podsInCluster(pods, tt.client) {
+				got = append(got, p.Name())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSelectPerfZone(t *testing.T) {
+	node := func(zone string) corev1.Node {
+		n := corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}}
+		if zone != "" {
+			n.Labels[corev1.LabelTopologyZone] = zone
+		}
+		return n
+	}
+
+	for name, tt := range map[string]struct {
+		zones     []string
+		zone      string
+		multiNode bool
+	}{
+		"no labels":        {zones: []string{"", ""}, zone: "", multiNode: false},
+		"single node zone": {zones: []string{"a", "b"}, zone: "b", multiNode: false},
+		"multi node zone":  {zones: []string{"a", "b", "b"}, zone: "b", multiNode: true},
+		"partial labels":   {zones: []string{"", "", "a"}, zone: "a", multiNode: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var nodes []corev1.Node
+			for _, z := range tt.zones {
+				nodes = append(nodes, node(z))
+			}
+			zone, multiNode := selectPerfZone(nodes)
+			if zone != tt.zone || multiNode != tt.multiNode {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.zone, tt.multiNode, zone, multiNode)
+			}
+			if affinity := perfZoneAffinity(zone, 100, false); (affinity == nil) != (tt.zone == "") {
+				t.Errorf("unexpected affinity %v for zone %q", affinity, zone)
+			}
+		})
+	}
+}
+
+func TestPerfZoneAffinity(t *testing.T) {
+	preferred := perfZoneAffinity("a", 50, false)
+	if preferred.RequiredDuringSchedulingIgnoredDuringExecution != nil ||
+		len(preferred.PreferredDuringSchedulingIgnoredDuringExecution) != 1 ||
+		preferred.PreferredDuringSchedulingIgnoredDuringExecution[0].Weight != 50 {
+		t.Errorf("unexpected preferred affinity %v", preferred)
+	}
+
+	required := perfZoneAffinity("a", 50, true)
+	if len(required.PreferredDuringSchedulingIgnoredDuringExecution) != 0 ||
+		required.RequiredDuringSchedulingIgnoredDuringExecution == nil ||
+		required.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Values[0] != "a" {
+		t.Errorf("unexpected required affinity %v", required)
+	}
+
+	if p := (Parameters{}); p.perfZoneWeight() != 100 {
+		t.Errorf("expected default weight 100, got %d", p.perfZoneWeight())
+	}
+}
+
+func TestPerfPodZones(t *testing.T) {
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{corev1.LabelTopologyZone: "a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-2", Labels: map[string]string{corev1.LabelTopologyZone: "a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-3", Labels: map[string]string{corev1.LabelTopologyZone: "b"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-4"}},
+	}
+
+	for name, tt := range map[string]struct {
+		nodes []string
+		want  []string
+	}{
+		"same zone":      {nodes: []string{"node-1", "node-2"}, want: []string{"a"}},
+		"different zone": {nodes: []string{"node-3", "node-1", "node-2"}, want: []string{"a", "b"}},
+		"unlabeled node": {nodes: []string{"node-1", "node-4"}, want: []string{"a"}},
+		"no labels":      {nodes: []string{"node-4"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var pods []corev1.Pod
+			for _, node := range tt.nodes {
+				pods = append(pods, corev1.Pod{Spec: corev1.PodSpec{NodeName: node}})
+			}
+			if got := perfPodZones(pods, nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected zones %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWithNetem(t *testing.T) {
+	for name, tt := range map[string]struct {
+		params   Parameters
+		kind     string
+		wantArgs string
+	}{
+		"disabled":       {params: Parameters{}, kind: kindEchoName},
+		"latency":        {params: Parameters{NetemLatency: 100 * time.Millisecond}, kind: kindEchoName, wantArgs: "delay 100ms"},
+		"latency & loss": {params: Parameters{NetemLatency: time.Second, NetemLoss: 0.5}, kind: kindEchoName, wantArgs: "delay 1000ms loss 0.5%"},
+		"other target":   {params: Parameters{NetemLoss: 1, NetemTarget: kindClientName}, kind: kindEchoName},
+		"client target":  {params: Parameters{NetemLoss: 1, NetemTarget: kindClientName}, kind: kindClientName, wantArgs: "loss 1%"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{params: tt.params}
+			dep := ct.withNetem(newDeployment(deploymentParameters{Name: "echo", Kind: tt.kind}), tt.kind)
+
+			containers := dep.Spec.Template.Spec.Containers
+			if tt.wantArgs == "" {
+				if len(containers) != 1 {
+					t.Errorf("expected no netem sidecar, got %d containers", len(containers))
+				}
+				return
+			}
+			if len(containers) != 2 || containers[1].Name != NetemContainerName {
+				t.Fatalf("expected a netem sidecar, got %v", containers)
+			}
+			if script := containers[1].Command[2]; !strings.Contains(script, "netem "+tt.wantArgs+" ") {
+				t.Errorf("expected netem options %q, got script %q", tt.wantArgs, script)
+			} else if strings.Contains(script, "apk") {
+				t.Errorf("expected no package installation at runtime, got script %q", script)
+			}
+			if image := containers[1].Image; image != defaults.ConnectivityNetemImage {
+				t.Errorf("expected the default netem image, got %s", image)
+			}
+		})
+	}
+}
+
+func TestDeploymentRolloutComplete(t *testing.T) {
+	replicas := int32(2)
+	for name, tt := range map[string]struct {
+		status  appsv1.DeploymentStatus
+		wantErr bool
+	}{
+		"complete":         {status: appsv1.DeploymentStatus{UpdatedReplicas: 2}},
+		"partially rolled": {status: appsv1.DeploymentStatus{UpdatedReplicas: 1}, wantErr: true},
+		"unavailable":      {status: appsv1.DeploymentStatus{UpdatedReplicas: 2, UnavailableReplicas: 1}, wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &replicas}, Status: tt.status}
+			if err := deploymentRolloutComplete(d); (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestIsSuiteClientPod(t *testing.T) {
+	pod := func(kind, name, ownerKind, ownerName string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Labels:          map[string]string{"kind": kind, "name": name},
+			OwnerReferences: []metav1.OwnerReference{{Kind: ownerKind, Name: ownerName}},
+		}}
+	}
+	for name, tt := range map[string]struct {
+		pod  *corev1.Pod
+		want bool
+	}{
+		"client":                {pod: pod(kindClientName, clientDeploymentName, "ReplicaSet", "client-5d8f7"), want: true},
+		"client2":               {pod: pod(kindClientName, client2DeploymentName, "ReplicaSet", "client2-7c9b4"), want: true},
+		"client daemonset":      {pod: pod(kindClientName, clientDaemonSetName, "DaemonSet", clientDaemonSetName), want: true},
+		"user deployment":       {pod: pod(kindClientName, "my-client", "ReplicaSet", "my-client-6f9d"), want: false},
+		"relabeled user pod":    {pod: pod(kindClientName, clientDeploymentName, "ReplicaSet", "my-client-6f9d"), want: false},
+		"other kind":            {pod: pod("custom", clientDeploymentName, "ReplicaSet", "client-5d8f7"), want: false},
+		"user daemonset":        {pod: pod(kindClientName, "my-client", "DaemonSet", "my-client"), want: false},
+		"prefix of client2 set": {pod: pod(kindClientName, clientDeploymentName, "ReplicaSet", "client2-7c9b4"), want: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := isSuiteClientPod(tt.pod); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+type deploymentScenario struct {
+	name        string
+	deployments []OptionalDeployment
+}
+
+func (s *deploymentScenario) Name() string               { return s.name }
+func (s *deploymentScenario) Run(context.Context, *Test) {}
+func (s *deploymentScenario) RequiredDeployments() []OptionalDeployment {
+	return s.deployments
+}
+
+type plainScenario struct{}
+
+func (s *plainScenario) Name() string               { return "plain" }
+func (s *plainScenario) Run(context.Context, *Test) {}
+
+func TestRequiredOptionalDeployments(t *testing.T) {
+	for name, tt := range map[string]struct {
+		scenarios []Scenario
+		want      map[OptionalDeployment]bool
+	}{
+		"none": {
+			scenarios: []Scenario{&deploymentScenario{name: "client-to-client"}},
+			want:      map[OptionalDeployment]bool{},
+		},
+		"union": {
+			scenarios: []Scenario{
+				&deploymentScenario{name: "pod-to-pod", deployments: []OptionalDeployment{DeployEchoOtherNode}},
+				&deploymentScenario{name: "pod-to-echo-tls-service", deployments: []OptionalDeployment{DeployEchoTLS}},
+			},
+			want: map[OptionalDeployment]bool{DeployEchoOtherNode: true, DeployEchoTLS: true},
+		},
+		"undeclared": {
+			scenarios: []Scenario{&deploymentScenario{name: "client-to-client"}, &plainScenario{}},
+			want: map[OptionalDeployment]bool{
+				DeployEchoOtherNode:       true,
+				DeployNodeWithoutCilium:   true,
+				DeployIngress:             true,
+				DeployExternalNameService: true,
+				DeployEchoLBService:       true,
+				DeployEchoTopology:        true,
+				DeployEchoTLS:             true,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{params: Parameters{Writer: &bytes.Buffer{}}}
+			test := &Test{ctx: ct, name: "test", scenarios: map[Scenario][]*Action{}}
+			for _, s := range tt.scenarios {
+				test.scenarios[s] = nil
+			}
+			ct.tests = []*Test{test}
+			if got := ct.requiredOptionalDeployments(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requiredOptionalDeployments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForEachCluster(t *testing.T) {
+	errFailed := errors.New("failed")
+	for name, tt := range map[string]struct {
+		params         Parameters
+		fail           bool
+		wantConcurrent int32
+		wantErrs       int
+	}{
+		"default": {
+			params:         Parameters{},
+			wantConcurrent: 2,
+		},
+		"serial": {
+			params:         Parameters{MultiClusterDeployConcurrency: 1},
+			wantConcurrent: 1,
+		},
+		"concurrent": {
+			params:         Parameters{MultiClusterDeployConcurrency: 2},
+			wantConcurrent: 2,
+		},
+		"errors of all clusters": {
+			params:         Parameters{MultiClusterDeployConcurrency: 2},
+			fail:           true,
+			wantConcurrent: 2,
+			wantErrs:       2,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{params: tt.params}
+			var running, maxRunning, started int32
+			var mu sync.Mutex
+			// Each cluster waits for wantConcurrent clusters to have started
+			// before finishing, which only happens if that many run at once.
+			barrier := make(chan struct{})
+			err := ct.forEachCluster(context.Background(), []*k8s.Client{{}, {}}, func(ctx context.Context, _ *k8s.Client) error {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				started++
+				if started == tt.wantConcurrent {
+					close(barrier)
+				}
+				mu.Unlock()
+				select {
+				case <-barrier:
+				case <-time.After(10 * time.Second):
+					return errors.New("timeout waiting for the other clusters to start")
+				}
+				mu.Lock()
+				running--
+				mu.Unlock()
+				if tt.fail {
+					return errFailed
+				}
+				return nil
+			})
+			if maxRunning != tt.wantConcurrent {
+				t.Errorf("expected %d clusters at once, got %d", tt.wantConcurrent, maxRunning)
+			}
+			if tt.wantErrs == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if !errors.Is(err, errFailed) {
+				t.Fatalf("expected %q, got %v", errFailed, err)
+			}
+			if got := strings.Count(err.Error(), errFailed.Error()); got != tt.wantErrs {
+				t.Errorf("expected %d errors, got %d: %s", tt.wantErrs, got, err)
+			}
+		})
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		ct := &ConnectivityTest{params: Parameters{MultiClusterDeployTimeout: 10 * time.Millisecond}}
+		err := ct.forEachCluster(context.Background(), []*k8s.Client{{}, {}}, func(ctx context.Context, _ *k8s.Client) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected a deadline error, got %v", err)
+		}
+	})
+}
+
+// failingNamespaces is a namespace client for which no namespace exists and
+// creating one fails with err.
+type failingNamespaces struct {
+	typedcorev1.NamespaceInterface
+	err error
+}
+
+func (n failingNamespaces) Get(_ context.Context, name string, _ metav1.GetOptions) (*corev1.Namespace, error) {
+	return nil, k8sErrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, name)
+}
+
+func (n failingNamespaces) Create(context.Context, *corev1.Namespace, metav1.CreateOptions) (*corev1.Namespace, error) {
+	return nil, n.err
+}
+
+type failingCoreV1 struct {
+	typedcorev1.CoreV1Interface
+	namespaces failingNamespaces
+}
+
+func (c failingCoreV1) Namespaces() typedcorev1.NamespaceInterface {
+	return c.namespaces
+}
+
+type failingClientset struct {
+	kubernetes.Interface
+	coreV1 failingCoreV1
+}
+
+func (c failingClientset) CoreV1() typedcorev1.CoreV1Interface {
+	return c.coreV1
+}
+
+func TestEnsureNamespaceError(t *testing.T) {
+	errRefused := errors.New("connection refused")
+	clientset := failingClientset{coreV1: failingCoreV1{namespaces: failingNamespaces{err: errRefused}}}
+	ct := &ConnectivityTest{params: Parameters{TestNamespace: "cilium-test", Writer: &bytes.Buffer{}}}
+
+	err := ct.forEachCluster(context.Background(), []*k8s.Client{{Clientset: clientset}, {Clientset: clientset}}, ct.ensureNamespace)
+	if !errors.Is(err, ErrNamespaceCreate) {
+		t.Fatalf("expected ErrNamespaceCreate, got %v", err)
+	}
+	if !errors.Is(err, errRefused) {
+		t.Errorf("expected the underlying error to be wrapped, got %v", err)
+	}
+	var setupErr *SetupError
+	if !errors.As(err, &setupErr) || setupErr.Class != ErrNamespaceCreate {
+		t.Errorf("expected a SetupError of class ErrNamespaceCreate, got %v", err)
+	}
+}
+
+func TestThrottleCreate(t *testing.T) {
+	ct := &ConnectivityTest{createSem: make(chan struct{}, 2)}
+	var running, maxRunning int32
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release := ct.throttleCreate(context.Background())
+			defer release()
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if maxRunning != 2 {
+		t.Errorf("expected 2 creates at once, got %d", maxRunning)
+	}
+
+	// With every slot taken, a cancelled context must not block.
+	full := &ConnectivityTest{createSem: make(chan struct{}, 1)}
+	full.createSem <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	full.throttleCreate(ctx)()
+	if len(full.createSem) != 1 {
+		t.Errorf("expected the cancelled create to leave the semaphore untouched, got %d", len(full.createSem))
+	}
+}
+
+func TestNodePortAddresses(t *testing.T) {
+	node := &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+		{Type: corev1.NodeHostName, Address: "node-1"},
+		{Type: corev1.NodeExternalIP, Address: "2001:db8::1"},
+		{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+		{Type: corev1.NodeInternalIP, Address: "fd00::1"},
+		{Type: corev1.NodeInternalIP, Address: "fd00::2"},
+	}}}
+	dualStack := []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+	for name, tt := range map[string]struct {
+		hostIP   string
+		node     *corev1.Node
+		families []corev1.IPFamily
+		want     []string
+	}{
+		"unknown node": {
+			hostIP:   "10.0.0.1",
+			families: dualStack,
+			want:     []string{"10.0.0.1"},
+		},
+		"dual-stack": {
+			hostIP:   "10.0.0.1",
+			node:     node,
+			families: dualStack,
+			want:     []string{"10.0.0.1", "fd00::1"},
+		},
+		"dual-stack IPv6 host IP": {
+			hostIP:   "fd00::1",
+			node:     node,
+			families: dualStack,
+			want:     []string{"fd00::1", "10.0.0.1"},
+		},
+		"single-stack": {
+			hostIP:   "10.0.0.1",
+			node:     node,
+			families: []corev1.IPFamily{corev1.IPv4Protocol},
+			want:     []string{"10.0.0.1"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := nodePortAddresses(tt.hostIP, tt.node, tt.families); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPodImageMismatch(t *testing.T) {
+	pod := func(images ...string) *corev1.Pod {
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "client-1", Labels: map[string]string{"name": "client"}}}
+		for i, image := range images {
+			name := "client"
+			if i > 0 {
+				name = fmt.Sprintf("sidecar-%d", i)
+			}
+			p.Spec.Containers = append(p.Spec.Containers, corev1.Container{Name: name, Image: image})
+		}
+		return p
+	}
+	for name, tt := range map[string]struct {
+		pod     *corev1.Pod
+		wantErr bool
+	}{
+		"match":             {pod: pod("curl:v1", "other:v1")},
+		"mismatch":          {pod: pod("curl:v2"), wantErr: true},
+		"sidecar mismatch":  {pod: pod("curl:v1", "curl:v2")},
+		"no main container": {pod: pod(), wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := podImageMismatch(tt.pod, "curl:v1"); (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+
+	ct := &ConnectivityTest{params: Parameters{Writer: &bytes.Buffer{}}}
+	if err := ct.checkPodImage(&k8s.Client{}, pod("curl:v2"), "curl:v1"); err != nil {
+		t.Errorf("expected a warning only, got %s", err)
+	}
+	ct.params.StrictImages = true
+	if err := ct.checkPodImage(&k8s.Client{}, pod("curl:v2"), "curl:v1"); err == nil {
+		t.Errorf("expected an error with StrictImages")
+	}
+}
+
+func TestPodIPsOutsideCIDRs(t *testing.T) {
+	cidrs := func(ss ...string) []*net.IPNet {
+		var nets []*net.IPNet
+		for _, s := range ss {
+			_, n, err := net.ParseCIDR(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nets = append(nets, n)
+		}
+		return nets
+	}
+	podIPs := map[string][]string{
+		"client": {"10.0.1.5", "fd00::1:5"},
+		"echo":   {"10.1.0.7", "fd00::2:7"},
+	}
+
+	for name, tt := range map[string]struct {
+		cidrs []*net.IPNet
+		want  []string
+	}{
+		"all within": {
+			cidrs: cidrs("10.0.0.0/15", "fd00::/64"),
+		},
+		"ipv4 only": {
+			cidrs: cidrs("10.0.0.0/16"),
+			want:  []string{"echo (10.1.0.7)"},
+		},
+		"dual-stack": {
+			cidrs: cidrs("10.0.0.0/16", "fd00::1:0/112"),
+			want:  []string{"echo (10.1.0.7)", "echo (fd00::2:7)"},
+		},
+		"multiple ranges": {
+			cidrs: cidrs("10.0.0.0/16", "10.1.0.0/16"),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := podIPsOutsideCIDRs(podIPs, tt.cidrs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("podIPsOutsideCIDRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadyEndpoints(t *testing.T) {
+	ready, notReady := true, false
+	endpoint := func(addr string, r *bool) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{Addresses: []string{addr}, Conditions: discoveryv1.EndpointConditions{Ready: r}}
+	}
+	pod := func(ep discoveryv1.Endpoint, name string) discoveryv1.Endpoint {
+		ep.TargetRef = &corev1.ObjectReference{Kind: "Pod", Namespace: "cilium-test", Name: name}
+		return ep
+	}
+
+	for name, tt := range map[string]struct {
+		slices []discoveryv1.EndpointSlice
+		want   int
+	}{
+		"no slices": {},
+		"ready and unknown": {
+			slices: []discoveryv1.EndpointSlice{
+				{Endpoints: []discoveryv1.Endpoint{endpoint("10.0.0.1", &ready), endpoint("10.0.0.2", nil)}},
+			},
+			want: 2,
+		},
+		"not ready": {
+			slices: []discoveryv1.EndpointSlice{
+				{Endpoints: []discoveryv1.Endpoint{endpoint("10.0.0.1", &ready), endpoint("10.0.0.2", &notReady)}},
+			},
+			want: 1,
+		},
+		"duplicate across slices": {
+			slices: []discoveryv1.EndpointSlice{
+				{Endpoints: []discoveryv1.Endpoint{endpoint("10.0.0.1", &ready)}},
+				{Endpoints: []discoveryv1.Endpoint{endpoint("10.0.0.1", &ready)}},
+			},
+			want: 1,
+		},
+		"dual-stack": {
+			slices: []discoveryv1.EndpointSlice{
+				{Endpoints: []discoveryv1.Endpoint{pod(endpoint("10.0.0.1", &ready), "echo-1")}},
+				{Endpoints: []discoveryv1.Endpoint{pod(endpoint("fd00::1", &ready), "echo-1")}},
+			},
+			want: 1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := readyEndpoints(tt.slices); got != tt.want {
+				t.Errorf("expected %d ready endpoints, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewEchoTLSSecret(t *testing.T) {
+	ct := &ConnectivityTest{params: Parameters{TestNamespace: "cilium-test"}}
+	secret, err := ct.newEchoTLSSecret()
+	if err != nil {
+		t.Fatalf("newEchoTLSSecret() error = %s", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(secret.Data["ca.crt"]) {
+		t.Fatalf("unable to parse CA certificate")
+	}
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		t.Fatalf("unable to decode certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("unable to parse certificate: %s", err)
+	}
+	if _, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]); err != nil {
+		t.Errorf("certificate does not match key: %s", err)
+	}
+
+	for _, name := range []string{"echo-tls", "echo-tls.cilium-test.svc", "echo-tls.cilium-test.svc.cluster.local"} {
+		if _, err := cert.Verify(x509.VerifyOptions{DNSName: name, Roots: roots}); err != nil {
+			t.Errorf("certificate not valid for %s: %s", name, err)
+		}
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "echo-same-node", Roots: roots}); err == nil {
+		t.Errorf("certificate unexpectedly valid for echo-same-node")
+	}
+}
+
+func TestWithTLSProxy(t *testing.T) {
+	dep := withTLSProxy(newDeployment(deploymentParameters{Name: echoTLSDeploymentName, Kind: kindEchoTLSName, Port: 8080}), "json-mock")
+
+	containers := dep.Spec.Template.Spec.Containers
+	if len(containers) != 2 {
+		t.Fatalf("got %d containers, want 2", len(containers))
+	}
+	proxy := containers[1]
+	if proxy.Name != echoTLSProxyContainerName || proxy.Image != "json-mock" {
+		t.Errorf("unexpected proxy container %s with image %s", proxy.Name, proxy.Image)
+	}
+	if len(proxy.Ports) != 1 || proxy.Ports[0].ContainerPort != echoTLSPort {
+		t.Errorf("proxy ports = %v, want %d", proxy.Ports, echoTLSPort)
+	}
+
+	volumes := dep.Spec.Template.Spec.Volumes
+	if len(volumes) != 2 || volumes[0].ConfigMap == nil || volumes[1].Secret == nil ||
+		volumes[1].Secret.SecretName != echoTLSDeploymentName {
+		t.Errorf("unexpected volumes %v", volumes)
+	}
+	if len(proxy.Command) == 0 || proxy.Command[0] != "node" {
+		t.Errorf("unexpected proxy command %v", proxy.Command)
+	}
+
+	// Run the proxy on a free port with a certificate in a temporary
+	// directory, in front of a local echo server.
+	ct := &ConnectivityTest{params: Parameters{TestNamespace: "cilium-test"}}
+	secret, err := ct.newEchoTLSSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		if err := os.WriteFile(filepath.Join(dir, key), secret.Data[key], 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Echo-Path", r.URL.Path)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer echo.Close()
+	port := freePort(t)
+	startNodeScript(t, fmt.Sprintf(echoTLSProxyScript, dir, corev1.TLSCertKey, corev1.TLSPrivateKeyKey, port, echo.Listener.Addr().(*net.TCPAddr).Port))
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(secret.Data["ca.crt"])
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: echoTLSDeploymentName}},
+	}
+	resp := waitForGet(t, client, fmt.Sprintf("https://127.0.0.1:%d/path", port))
+	if resp.StatusCode != http.StatusAccepted || resp.Header.Get("X-Echo-Path") != "/path" {
+		t.Errorf("expected the echo server response, got %d %v", resp.StatusCode, resp.Header)
+	}
+}
+
+func TestDNSConfigMap(t *testing.T) {
+	for name, tt := range map[string]struct {
+		configMap string
+		namespace string
+		want      string
+	}{
+		"unset":                   {want: corednsConfigMapName},
+		"existing":                {configMap: "my-corefile", want: "my-corefile"},
+		"existing test namespace": {configMap: "my-corefile", namespace: defaults.ConnectivityCheckNamespace, want: "my-corefile"},
+		"copied":                  {configMap: "my-corefile", namespace: "kube-system", want: corednsConfigMapName},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := Parameters{TestNamespace: defaults.ConnectivityCheckNamespace, DNSConfigMap: tt.configMap, DNSConfigMapNamespace: tt.namespace}
+			dep := newDeploymentWithDNSTestServer(deploymentParameters{Name: echoSameNodeDeploymentName}, "coredns", p.dnsConfigMapName(), nil)
+			volumes := dep.Spec.Template.Spec.Volumes
+			if len(volumes) != 1 || volumes[0].ConfigMap == nil || volumes[0].ConfigMap.Name != tt.want {
+				t.Errorf("expected the DNS test server to mount configmap %s, got %v", tt.want, volumes)
+			}
+		})
+	}
+}
+
+func TestEchoLBClass(t *testing.T) {
+	for name, tt := range map[string]struct {
+		class    string
+		wantType corev1.ServiceType
+	}{
+		"unset": {wantType: corev1.ServiceTypeNodePort},
+		"class": {class: "io.cilium/l2-announcer", wantType: corev1.ServiceTypeLoadBalancer},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := Parameters{EchoLBClass: tt.class}
+			svc := (&ConnectivityTest{params: p}).newEchoService(echoSameNodeDeploymentName)
+			if svc.Spec.Type != tt.wantType {
+				t.Errorf("Type = %s, want %s", svc.Spec.Type, tt.wantType)
+			}
+			if got := svc.Spec.LoadBalancerClass; (got == nil) != (tt.class == "") || (got != nil && *got != tt.class) {
+				t.Errorf("unexpected LoadBalancerClass %v, want %q", got, tt.class)
+			}
+		})
+	}
+}
+
+func TestHostPortHolder(t *testing.T) {
+	hostPortPod := func(namespace, name, node string, ports ...int32) corev1.Pod {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: corev1.PodSpec{
+				NodeName:   node,
+				Containers: []corev1.Container{{}},
+			},
+		}
+		for _, port := range ports {
+			pod.Spec.Containers[0].Ports = append(pod.Spec.Containers[0].Ports, corev1.ContainerPort{HostPort: port})
+		}
+		return pod
+	}
+	node := func(name string, labels map[string]string) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	pending := hostPortPod("cilium-test", "echo-other-node-b", "", 4000, 4001)
+	pending.Spec.NodeSelector = map[string]string{"pool": "test"}
+	nodes := []corev1.Node{
+		node("node-1", map[string]string{"pool": "test"}),
+		node("node-2", map[string]string{"pool": "other"}),
+	}
+
+	for name, tt := range map[string]struct {
+		others   []corev1.Pod
+		wantPort int32
+		wantPod  string
+	}{
+		"sibling on ineligible node": {
+			others: []corev1.Pod{hostPortPod("cilium-test", "echo-other-node-a", "node-2", 4000)},
+		},
+		"holder on eligible node": {
+			others: []corev1.Pod{
+				hostPortPod("cilium-test", "echo-other-node-a", "node-2", 4000),
+				hostPortPod("kube-system", "proxy", "node-1", 4001),
+			},
+			wantPort: 4001,
+			wantPod:  "proxy",
+		},
+		"other ports": {
+			others: []corev1.Pod{hostPortPod("kube-system", "proxy", "node-1", 8080)},
+		},
+		"self": {
+			others: []corev1.Pod{pending},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			port, holder := hostPortHolder(&pending, podHostPorts(&pending), nodes, tt.others)
+			if tt.wantPod == "" {
+				if holder != nil {
+					t.Fatalf("unexpected holder %s/%s of HostPort %d", holder.Namespace, holder.Name, port)
+				}
+				return
+			}
+			if holder == nil || holder.Name != tt.wantPod || port != tt.wantPort {
+				t.Fatalf("expected pod %s holding HostPort %d, got %v on %d", tt.wantPod, tt.wantPort, holder, port)
+			}
+		})
+	}
+}
+
+// freePort returns a local TCP port which is free at the time of the call.
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startNodeScript runs the given script with Node.js until the test ends, and
+// skips the test if Node.js is not available.
+func startNodeScript(t *testing.T, script string) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skipf("node not available: %s", err)
+	}
+	cmd := exec.Command(node, "-e", script)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+}
+
+// waitForGet retries a GET request to url until it succeeds or 5 seconds
+// have passed.
+func waitForGet(t *testing.T, client *http.Client, url string) *http.Response {
+	var err error
+	for i := 0; i < 50; i++ {
+		var resp *http.Response
+		if resp, err = client.Get(url); err == nil {
+			resp.Body.Close()
+			return resp
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("%s not reachable: %s", url, err)
+	return nil
+}
+
+func TestWithStatusServer(t *testing.T) {
+	dep := withStatusServer(newDeployment(deploymentParameters{Name: echoSameNodeDeploymentName}), "json-mock", 503)
+	c := dep.Spec.Template.Spec.Containers[len(dep.Spec.Template.Spec.Containers)-1]
+	if c.Image != "json-mock" || len(c.Ports) != 1 || c.Ports[0].ContainerPort != EchoStatusPort ||
+		len(c.Command) == 0 || c.Command[0] != "node" {
+		t.Errorf("unexpected status server container %v", c)
+	}
+
+	// Run the server on a free port rather than EchoStatusPort.
+	port := freePort(t)
+	startNodeScript(t, fmt.Sprintf(statusServerScript, 503, port))
+	url := fmt.Sprintf("http://127.0.0.1:%d/", port)
+	client := &http.Client{Timeout: 5 * time.Second}
+	waitForGet(t, client, url)
+
+	// Concurrent requests with a body are all answered.
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	errs := make([]error, 5)
+	for i := range codes {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Post(url, "text/plain", strings.NewReader(strings.Repeat("x", 64*1024)))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			resp.Body.Close()
+			codes[i] = resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	for i, code := range codes {
+		if errs[i] != nil || code != 503 {
+			t.Errorf("expected 503, got %d (%v)", code, errs[i])
+		}
+	}
+}
+
+// fakeIPTables keeps the rules inserted into the INPUT chain in a file named
+// after the binary, so that -C finds them, and fails if FAKE_IPTABLES_FAIL
+// names the binary.
+const fakeIPTables = `#!/bin/sh
+name=$(basename "$0")
+[ "$FAKE_IPTABLES_FAIL" = "$name" ] && exit 1
+rules="$FAKE_IPTABLES_DIR/$name.rules"
+op=$2
+shift 3
+case $op in
+-C) grep -qxF -- "$*" "$rules" 2>/dev/null ;;
+-I) echo "$*" >>"$rules" ;;
+esac
+`
+
+func TestWithConnectionCounter(t *testing.T) {
+	dep := withConnectionCounter(newDeployment(deploymentParameters{Name: echoSameNodeDeploymentName}), "netem", 8080)
+	c := dep.Spec.Template.Spec.Containers[len(dep.Spec.Template.Spec.Containers)-1]
+	if c.SecurityContext == nil || c.SecurityContext.Capabilities == nil ||
+		!reflect.DeepEqual(c.SecurityContext.Capabilities.Add, []corev1.Capability{"NET_ADMIN"}) {
+		t.Errorf("expected the sidecar to have NET_ADMIN, got %v", c.SecurityContext)
+	}
+
+	for name, tt := range map[string]struct {
+		fail      string
+		wantErr   bool
+		wantRules map[string]int
+	}{
+		"dual-stack":     {wantRules: map[string]int{"iptables": 1, "ip6tables": 1}},
+		"ipv6 disabled":  {fail: "ip6tables", wantRules: map[string]int{"iptables": 1, "ip6tables": 0}},
+		"iptables fails": {fail: "iptables", wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, bin := range []string{"iptables", "ip6tables"} {
+				if err := os.WriteFile(filepath.Join(dir, bin), []byte(fakeIPTables), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(dir, "sleep"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			// Run the sidecar twice, as after a restart, to check that the
+			// rules are only added once.
+			for i := 0; i < 2; i++ {
+				cmd := exec.Command(c.Command[0], c.Command[1:]...)
+				cmd.Env = append(os.Environ(),
+					"PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"),
+					"FAKE_IPTABLES_DIR="+dir,
+					"FAKE_IPTABLES_FAIL="+tt.fail)
+				out, err := cmd.CombinedOutput()
+				if tt.wantErr {
+					if err == nil {
+						t.Fatalf("expected the sidecar to fail, got output %q", out)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("sidecar failed: %s: %s", err, out)
+				}
+			}
+
+			rule := "-p tcp --syn --dport 8080 -j " + connectionCounterChain + "\n"
+			for bin, want := range tt.wantRules {
+				rules, _ := os.ReadFile(filepath.Join(dir, bin+".rules"))
+				if string(rules) != strings.Repeat(rule, want) {
+					t.Errorf("expected %d counter rules for %s, got %q", want, bin, rules)
+				}
+			}
+		})
+	}
+}
+
+func TestConnectionCount(t *testing.T) {
+	for name, tt := range map[string]struct {
+		output  string
+		want    uint64
+		wantErr bool
+	}{
+		"dual-stack": {
+			output: `Chain INPUT (policy ACCEPT 12 packets, 720 bytes)
+    pkts      bytes target     prot opt in     out     source               destination
+       7      420 CONNECTION_COUNTER  tcp  --  *      *       0.0.0.0/0            0.0.0.0/0            tcp dpt:8080 flags:0x17/0x02
+Chain INPUT (policy ACCEPT 0 packets, 0 bytes)
+    pkts      bytes target     prot opt in     out     source               destination
+       3      240 CONNECTION_COUNTER  tcp      *      *       ::/0                 ::/0                 tcp dpt:8080 flags:0x17/0x02
+`,
+			want: 10,
+		},
+		"no connection": {
+			output: `Chain INPUT (policy ACCEPT 0 packets, 0 bytes)
+    pkts      bytes target     prot opt in     out     source               destination
+       0        0 CONNECTION_COUNTER  tcp  --  *      *       0.0.0.0/0            0.0.0.0/0            tcp dpt:8080 flags:0x17/0x02
+`,
+			want: 0,
+		},
+		"no counter rule": {
+			output: `Chain INPUT (policy ACCEPT 0 packets, 0 bytes)
+    pkts      bytes target     prot opt in     out     source               destination
+`,
+			wantErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := connectionCount(tt.output)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %d connections, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReconciledService(t *testing.T) {
+	ct := &ConnectivityTest{params: Parameters{EchoPort: 9000}}
+	desired := ct.newEchoService(echoSameNodeDeploymentName)
+
+	// The service as stored by the API server, with its allocated cluster
+	// IP and NodePort, and the target port of a previous run.
+	existing := ct.newEchoService(echoSameNodeDeploymentName)
+	existing.ResourceVersion = "42"
+	existing.Labels = map[string]string{"kind": kindEchoName, "owner": "someone-else"}
+	existing.Spec.ClusterIP = "10.96.0.10"
+	existing.Spec.ClusterIPs = []string{"10.96.0.10"}
+	existing.Spec.Ports[0].NodePort = 31000
+	existing.Spec.Ports[0].TargetPort = intstr.FromInt(8080)
+
+	if equality.Semantic.DeepDerivative(reconciledService(existing, desired).Spec, existing.Spec) {
+		t.Fatal("expected the drifted target port to be detected")
+	}
+
+	got := reconciledService(existing, desired)
+	if got.ResourceVersion != "42" || got.Spec.ClusterIP != "10.96.0.10" || got.Labels["owner"] != "someone-else" {
+		t.Errorf("expected the existing metadata and cluster IP to be kept, got %+v", got)
+	}
+	if got.Spec.Ports[0].NodePort != 31000 || got.Spec.Ports[0].TargetPort != intstr.FromInt(9000) {
+		t.Errorf("expected the allocated NodePort and the desired target port, got %+v", got.Spec.Ports[0])
+	}
+	if !metadataInSync(desired.ObjectMeta, got.ObjectMeta) || !equality.Semantic.DeepDerivative(reconciledService(got, desired).Spec, got.Spec) {
+		t.Errorf("expected the reconciled service to be in sync, got %+v", got)
+	}
+}
+
+func TestSetLBAlgorithm(t *testing.T) {
+	for name, tt := range map[string]struct {
+		algorithm   string
+		annotations map[string]string
+		want        map[string]string
+	}{
+		"unset": {
+			want: map[string]string{},
+		},
+		"algorithm": {
+			algorithm: "maglev",
+			want:      map[string]string{lbAlgorithmAnnotation: "maglev"},
+		},
+		"existing annotations": {
+			algorithm:   "random",
+			annotations: map[string]string{"service.cilium.io/global": "true"},
+			want:        map[string]string{"service.cilium.io/global": "true", lbAlgorithmAnnotation: "random"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			(&ConnectivityTest{params: Parameters{EchoLBAlgorithm: tt.algorithm}}).setLBAlgorithm(svc)
+			if !reflect.DeepEqual(svc.Annotations, tt.want) {
+				t.Errorf("annotations = %v, want %v", svc.Annotations, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import "errors"
+
+// Classes of the failures to deploy or validate the test resources, which
+// SetupError wraps in addition to the underlying error.
+var (
+	ErrNamespaceCreate   = errors.New("test namespace creation failed")
+	ErrDeploymentTimeout = errors.New("timeout waiting for test deployments")
+	ErrEndpointTimeout   = errors.New("timeout waiting for CiliumEndpoint")
+	ErrDNSTimeout        = errors.New("timeout waiting for DNS")
+	ErrServiceTimeout    = errors.New("timeout waiting for service")
+)
+
+// SetupError is returned when deploying or validating the test resources
+// fails. Callers can branch on the failure class with errors.Is, e.g.
+// errors.Is(err, ErrDNSTimeout), or retrieve it with errors.As.
+type SetupError struct {
+	// Class is one of the Err* failure classes above.
+	Class error
+	// Err is the underlying error.
+	Err error
+}
+
+func newSetupError(class, err error) error {
+	return &SetupError{Class: class, Err: err}
+}
+
+func (e *SetupError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SetupError) Unwrap() []error {
+	return []error{e.Class, e.Err}
+}
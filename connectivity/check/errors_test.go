@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSetupError(t *testing.T) {
+	cause := fmt.Errorf("timeout reached waiting lookup for localhost from pod client to succeed (last error: %w)", context.DeadlineExceeded)
+	err := fmt.Errorf("from pod client: %w", newSetupError(ErrDNSTimeout, cause))
+
+	if got, want := err.Error(), "from pod client: "+cause.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrDNSTimeout) {
+		t.Errorf("expected error to be ErrDNSTimeout")
+	}
+	if errors.Is(err, ErrEndpointTimeout) {
+		t.Errorf("unexpected ErrEndpointTimeout")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the underlying error to be unwrapped")
+	}
+
+	var setupErr *SetupError
+	if !errors.As(errors.Join(errors.New("other"), err), &setupErr) {
+		t.Fatalf("expected a SetupError")
+	}
+	if setupErr.Class != ErrDNSTimeout {
+		t.Errorf("Class = %v, want %v", setupErr.Class, ErrDNSTimeout)
+	}
+}
@@ -199,6 +199,13 @@ func (ct *ConnectivityTest) extractFeaturesFromConfigMap(ctx context.Context, cl
 	mode = "disabled"
 	if v, ok := cm.Data["tunnel"]; ok {
 		mode = v
+	} else if cm.Data["routing-mode"] == "tunnel" {
+		// Newer Cilium versions split the tunnel option into the routing
+		// mode and the tunnel protocol, which defaults to vxlan.
+		mode = "vxlan"
+		if v, ok := cm.Data["tunnel-protocol"]; ok {
+			mode = v
+		}
 	}
 	result[FeatureTunnel] = FeatureStatus{
 		Enabled: mode != "disabled",
@@ -514,6 +521,9 @@ func (ct *ConnectivityTest) detectFeatures(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		if err := checkRoutingMode(ct.params.ExpectRoutingMode, features); err != nil {
+			return fmt.Errorf("[%s] Cilium pod %s: %w", ciliumPod.K8sClient.ClusterName(), ciliumPod.Name(), err)
+		}
 
 		if initialized {
 			ct.validateFeatureSet(features, ciliumPod.Name())
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	setupStepMetric       = "cilium_connectivity_setup_step_duration_seconds"
+	validationPhaseMetric = "cilium_connectivity_validation_phase_duration_seconds"
+)
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeSetupMetrics writes the duration of the deployment and validation steps
+// as well as of the validation phases in the Prometheus text format to
+// MetricsFile, so that the health of the test infrastructure can be tracked
+// over time, e.g. through the node exporter textfile collector.
+func (ct *ConnectivityTest) writeSetupMetrics() {
+	if ct.params.MetricsFile == "" {
+		return
+	}
+
+	ct.validationMu.Lock()
+	steps := append([]setupStep{}, ct.setupSteps...)
+	phases := ct.validationPhases
+	ct.validationMu.Unlock()
+
+	f, err := os.Create(ct.params.MetricsFile)
+	if err != nil {
+		ct.Warnf("Unable to write metrics to %s: %s", ct.params.MetricsFile, err)
+		return
+	}
+
+	err = writeMetrics(f, steps, phases)
+	if e := f.Close(); e != nil {
+		err = errors.Join(err, e)
+	}
+	if err != nil {
+		ct.Warnf("Unable to write metrics to %s: %s", ct.params.MetricsFile, err)
+	}
+}
+
+// writeMetrics writes the given setup steps and validation phases as gauges
+// in the Prometheus text format. The durations of steps recorded more than
+// once are summed up, a step is reported as failed if any of them failed.
+func writeMetrics(out io.Writer, steps []setupStep, phases *phaseTimer) error {
+	// bufio.Writer keeps the first write error, which is returned by Flush.
+	w := bufio.NewWriter(out)
+
+	var names []string
+	durations := make(map[string]time.Duration)
+	failed := make(map[string]bool)
+	for _, s := range steps {
+		if _, ok := durations[s.name]; !ok {
+			names = append(names, s.name)
+		}
+		durations[s.name] += s.duration
+		failed[s.name] = failed[s.name] || s.err != nil
+	}
+
+	fmt.Fprintf(w, "# HELP %s Duration of the connectivity test deployment and validation steps.\n", setupStepMetric)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", setupStepMetric)
+	for _, name := range names {
+		result := "success"
+		if failed[name] {
+			result = "failure"
+		}
+		fmt.Fprintf(w, "%s{step=\"%s\",result=\"%s\"} %g\n",
+			setupStepMetric, metricLabelEscaper.Replace(name), result, durations[name].Seconds())
+	}
+
+	fmt.Fprintf(w, "# HELP %s Duration of the connectivity test deployment validation phases.\n", validationPhaseMetric)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", validationPhaseMetric)
+	if phases != nil {
+		for _, phase := range phases.phases {
+			fmt.Fprintf(w, "%s{phase=\"%s\"} %g\n",
+				validationPhaseMetric, metricLabelEscaper.Replace(phase), phases.durations[phase].Seconds())
+		}
+	}
+
+	return w.Flush()
+}
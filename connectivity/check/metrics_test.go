@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	steps := []setupStep{
+		{name: "deployment client", duration: 2 * time.Second},
+		{name: `service "echo"`, duration: 500 * time.Millisecond},
+		{name: "deployment client", duration: time.Second, err: errors.New("timeout")},
+	}
+	phases := &phaseTimer{
+		phases:    []string{"deployment-ready", "dns"},
+		durations: map[string]time.Duration{"deployment-ready": 3 * time.Second, "dns": 250 * time.Millisecond},
+	}
+
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, steps, phases); err != nil {
+		t.Fatalf("writeMetrics() error = %v", err)
+	}
+
+	want := `# HELP cilium_connectivity_setup_step_duration_seconds Duration of the connectivity test deployment and validation steps.
+# TYPE cilium_connectivity_setup_step_duration_seconds gauge
+cilium_connectivity_setup_step_duration_seconds{step="deployment client",result="failure"} 3
+cilium_connectivity_setup_step_duration_seconds{step="service \"echo\"",result="success"} 0.5
+# HELP cilium_connectivity_validation_phase_duration_seconds Duration of the connectivity test deployment validation phases.
+# TYPE cilium_connectivity_validation_phase_duration_seconds gauge
+cilium_connectivity_validation_phase_duration_seconds{phase="deployment-ready"} 3
+cilium_connectivity_validation_phase_duration_seconds{phase="dns"} 0.25
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected metrics:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -4,11 +4,14 @@
 package check
 
 import (
+	"encoding/json"
+	"fmt"
 	"net"
 	"net/url"
 	"strconv"
 
 	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"golang.org/x/exp/slices"
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/cilium/cilium-cli/k8s"
@@ -81,7 +84,12 @@ func (p Pod) Path() string {
 
 // Address returns the network address of the Pod.
 func (p Pod) Address(family IPFamily) string {
-	for _, addr := range p.Pod.Status.PodIPs {
+	podIPs := p.Pod.Status.PodIPs
+	if len(podIPs) == 0 && p.Pod.Status.PodIP != "" {
+		// PodIPs may not be populated by older API servers.
+		podIPs = []corev1.PodIP{{IP: p.Pod.Status.PodIP}}
+	}
+	for _, addr := range podIPs {
 		ip := net.ParseIP(addr.IP)
 		if (family == IPFamilyV4 || family == IPFamilyAny) && ip.To4() != nil {
 			return addr.IP
@@ -93,6 +101,18 @@ func (p Pod) Address(family IPFamily) string {
 	return ""
 }
 
+// HasNetRaw returns whether a container of the Pod is granted the NET_RAW
+// capability, required to send ICMP echo requests.
+func (p Pod) HasNetRaw() bool {
+	for _, c := range p.Pod.Spec.Containers {
+		if c.SecurityContext != nil && c.SecurityContext.Capabilities != nil &&
+			slices.Contains(c.SecurityContext.Capabilities.Add, "NET_RAW") {
+			return true
+		}
+	}
+	return false
+}
+
 // HasLabel checks if given label exists and value matches.
 func (p Pod) HasLabel(name, value string) bool {
 	v, ok := p.Pod.Labels[name]
@@ -112,6 +132,36 @@ func (p Pod) Labels() map[string]string {
 	return newMap
 }
 
+// NetworkStatus is an entry of the network-status annotation which Multus
+// sets on the pods it attached to additional networks.
+type NetworkStatus struct {
+	Name      string   `json:"name"`
+	Interface string   `json:"interface"`
+	IPs       []string `json:"ips"`
+	Default   bool     `json:"default"`
+}
+
+// SecondaryNetworks returns the status of the networks the pod is attached to
+// in addition to its default network, as reported by Multus.
+func (p Pod) SecondaryNetworks() ([]NetworkStatus, error) {
+	annotation, ok := p.Pod.Annotations[multusNetworkStatusAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	var statuses []NetworkStatus
+	if err := json.Unmarshal([]byte(annotation), &statuses); err != nil {
+		return nil, fmt.Errorf("unable to parse %s annotation of pod %s: %w", multusNetworkStatusAnnotation, p.Name(), err)
+	}
+	var secondary []NetworkStatus
+	for _, status := range statuses {
+		if !status.Default {
+			secondary = append(secondary, status)
+		}
+	}
+	return secondary, nil
+}
+
 // Service is a service acting as a peer in a connectivity test.
 // It implements interface TestPeer.
 type Service struct {
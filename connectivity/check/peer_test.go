@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHasNetRaw(t *testing.T) {
+	withCaps := func(caps ...corev1.Capability) Pod {
+		return Pod{Pod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			SecurityContext: &corev1.SecurityContext{Capabilities: &corev1.Capabilities{Add: caps}},
+		}}}}}
+	}
+	for name, tt := range map[string]struct {
+		pod  Pod
+		want bool
+	}{
+		"NET_RAW":             {pod: withCaps("NET_ADMIN", "NET_RAW"), want: true},
+		"other capability":    {pod: withCaps("NET_ADMIN")},
+		"no security context": {pod: Pod{Pod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{}}}}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.pod.HasNetRaw(); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSecondaryNetworks(t *testing.T) {
+	for name, tt := range map[string]struct {
+		annotations map[string]string
+		want        []NetworkStatus
+		wantErr     bool
+	}{
+		"no annotation": {},
+		"secondary": {
+			annotations: map[string]string{multusNetworkStatusAnnotation: `[
+				{"name": "cilium", "interface": "eth0", "ips": ["10.0.1.5"], "default": true},
+				{"name": "cilium-test/macvlan-conf", "interface": "net1", "ips": ["192.168.1.5", "fd00::5"]}
+			]`},
+			want: []NetworkStatus{{Name: "cilium-test/macvlan-conf", Interface: "net1", IPs: []string{"192.168.1.5", "fd00::5"}}},
+		},
+		"invalid": {
+			annotations: map[string]string{multusNetworkStatusAnnotation: "{"},
+			wantErr:     true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pod := Pod{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "client", Annotations: tt.annotations}}}
+			got, err := pod.SecondaryNetworks()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SecondaryNetworks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SecondaryNetworks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
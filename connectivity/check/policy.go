@@ -675,15 +675,16 @@ func (t *Test) deletePolicies(ctx context.Context) error {
 	return nil
 }
 
-// ciliumLogs dumps the logs of all Cilium agents since the start of the Test.
-// filter is applied on each line of output.
+// ciliumLogs dumps the logs of all Cilium agents since the start of the Test,
+// or over the last LogSince if shorter.
 func (t *Test) ciliumLogs(ctx context.Context) {
+	since := t.Context().params.logsSince(t.startTime, time.Now())
 	for _, pod := range t.Context().ciliumPods {
-		log, err := pod.K8sClient.CiliumLogs(ctx, pod.Pod.Namespace, pod.Pod.Name, t.startTime, nil)
+		log, err := pod.K8sClient.CiliumLogs(ctx, pod.Pod.Namespace, pod.Pod.Name, since, nil)
 		if err != nil {
 			t.Fatalf("Error reading Cilium logs: %s", err)
 		}
-		t.Infof("Cilium agent %s/%s logs since %s:\n%s", pod.Pod.Namespace, pod.Pod.Name, t.startTime.String(), log)
+		t.Infof("Cilium agent %s/%s logs since %s:\n%s", pod.Pod.Namespace, pod.Pod.Name, since.String(), log)
 	}
 }
 
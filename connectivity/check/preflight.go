@@ -0,0 +1,538 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	healthModels "github.com/cilium/cilium/api/v1/health/models"
+	"github.com/cilium/cilium/api/v1/models"
+	"golang.org/x/exp/slices"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cilium/cilium-cli/defaults"
+	"github.com/cilium/cilium-cli/k8s"
+)
+
+const (
+	// defaultNodePortRangeMin and defaultNodePortRangeMax match the default
+	// value of the kube-apiserver --service-node-port-range flag.
+	defaultNodePortRangeMin = 30000
+	defaultNodePortRangeMax = 32767
+
+	nodePortRangeFlag = "--service-node-port-range="
+)
+
+// MTUCheckMode selects how an MTU mismatch between nodes is reported.
+type MTUCheckMode string
+
+const (
+	MTUCheckModeDisabled MTUCheckMode = "disabled"
+	MTUCheckModeWarning  MTUCheckMode = "warning"
+	MTUCheckModeStrict   MTUCheckMode = "strict"
+)
+
+// NodeHealthCheckMode selects how unhealthy node-to-node paths are reported.
+type NodeHealthCheckMode string
+
+const (
+	NodeHealthCheckModeDisabled NodeHealthCheckMode = "disabled"
+	NodeHealthCheckModeWarning  NodeHealthCheckMode = "warning"
+	NodeHealthCheckModeStrict   NodeHealthCheckMode = "strict"
+)
+
+// checkNodePortAvailability verifies that the NodePort range of each cluster
+// has enough free ports left for the echo services which are about to be
+// created, so that deploy doesn't fail halfway through.
+func (ct *ConnectivityTest) checkNodePortAvailability(ctx context.Context) error {
+	for client, services := range ct.nodePortServices() {
+		missing := 0
+		for _, svc := range services {
+			_, err := ct.readClient(client).GetService(ctx, ct.params.TestNamespace, svc.Name, metav1.GetOptions{})
+			if k8sErrors.IsNotFound(err) {
+				missing += len(svc.Spec.Ports)
+			} else if err != nil {
+				return fmt.Errorf("unable to get service %s: %w", svc.Name, err)
+			}
+		}
+		if missing == 0 {
+			continue
+		}
+
+		low, high := ct.nodePortRange(ctx, client)
+		svcs, err := ct.readClient(client).ListServices(ctx, corev1.NamespaceAll, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("unable to list services: %w", err)
+		}
+		used := 0
+		for _, svc := range svcs.Items {
+			for _, port := range svc.Spec.Ports {
+				if int(port.NodePort) >= low && int(port.NodePort) <= high {
+					used++
+				}
+			}
+		}
+
+		if free := high - low + 1 - used; free < missing {
+			return fmt.Errorf("[%s] not enough free NodePorts in range %d-%d: %d needed, %d available",
+				client.ClusterName(), low, high, missing, free)
+		}
+		ct.Debugf("[%s] %d NodePorts in use in range %d-%d, %d needed", client.ClusterName(), used, low, high, missing)
+	}
+
+	return nil
+}
+
+// nodePortServices returns the NodePort and LoadBalancer services deploy
+// creates in each cluster, each of their ports being allocated a NodePort.
+func (ct *ConnectivityTest) nodePortServices() map[*k8s.Client][]*corev1.Service {
+	services := map[*k8s.Client][]*corev1.Service{}
+	add := func(client *k8s.Client, svc *corev1.Service) {
+		services[client] = append(services[client], svc)
+	}
+
+	add(ct.clients.src, ct.newEchoService(echoSameNodeDeploymentName))
+	if ct.params.MultiClusterBidirectional {
+		add(ct.clients.dst, ct.newEchoService(echoSameNodeDeploymentName))
+	}
+	if ct.params.MultiCluster != "" && ct.optionalDeployments[DeployEchoOtherNode] {
+		add(ct.clients.src, ct.newEchoService(echoOtherNodeDeploymentName))
+	}
+	if !ct.params.Minimal && (!ct.params.SingleNode || ct.params.MultiCluster != "") && ct.optionalDeployments[DeployEchoOtherNode] {
+		add(ct.clients.dst, ct.newEchoService(echoOtherNodeDeploymentName))
+	}
+	if ct.params.EchoLBService && ct.optionalDeployments[DeployEchoOtherNode] && ct.optionalDeployments[DeployEchoLBService] {
+		add(ct.clients.src, ct.newEchoLBService())
+	}
+	if ct.params.EchoTopologyHints && ct.optionalDeployments[DeployEchoTopology] && len(topologyZones(ct.nodes, ct.params.NodeSelector)) >= 2 {
+		add(ct.clients.src, ct.newEchoTopologyService())
+	}
+	if ct.params.EchoTLS && ct.optionalDeployments[DeployEchoTLS] {
+		add(ct.clients.src, ct.newEchoTLSService())
+	}
+	return services
+}
+
+// checkHostNetNSImages warns if the cluster nodes have different CPU
+// architectures which are not covered by HostNetNSImages, as the host-netns
+// pods on some of them will fail to start unless the curl image is multi-arch.
+func (ct *ConnectivityTest) checkHostNetNSImages(ctx context.Context, client *k8s.Client) error {
+	nodes, err := ct.readClient(client).ListNodes(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list nodes: %w", err)
+	}
+
+	arches := map[string]struct{}{}
+	for _, node := range nodes.Items {
+		if arch, ok := node.Labels[corev1.LabelArchStable]; ok {
+			if _, ok := ct.params.HostNetNSImages[arch]; !ok {
+				arches[arch] = struct{}{}
+			}
+		}
+	}
+
+	if len(arches) > 1 {
+		uncovered := make([]string, 0, len(arches))
+		for arch := range arches {
+			uncovered = append(uncovered, arch)
+		}
+		sort.Strings(uncovered)
+		ct.Warnf("[%s] Nodes with architectures %s run the host-netns pods with image %s, make sure it is multi-arch or set --host-netns-image",
+			client.ClusterName(), strings.Join(uncovered, ", "), ct.params.CurlImage)
+	}
+
+	return nil
+}
+
+// checkRoutingMode returns an error if the routing mode of the given Cilium
+// features doesn't match the expected one, which is either "native", "tunnel"
+// for any tunnel protocol, or a specific tunnel protocol such as "vxlan".
+func checkRoutingMode(expected string, features FeatureSet) error {
+	if expected == "" {
+		return nil
+	}
+
+	tunnel := features[FeatureTunnel]
+	actual := "native"
+	if tunnel.Enabled {
+		actual = tunnel.Mode
+	}
+	if actual == expected || (expected == "tunnel" && tunnel.Enabled) {
+		return nil
+	}
+
+	return fmt.Errorf("routing mode is %q instead of the expected %q, cross-node connectivity tests would likely fail", actual, expected)
+}
+
+// checkLeftoverResources warns if the test namespace already contains test
+// deployments or daemonsets, e.g. from an interrupted run, as they are reused
+// as is unless --force-deploy or --reconcile is set. With StrictLeftovers, an
+// error is returned instead.
+func (ct *ConnectivityTest) checkLeftoverResources(ctx context.Context, client *k8s.Client) error {
+	if ct.params.ForceDeploy || ct.params.Reconcile {
+		return nil
+	}
+
+	opts := metav1.ListOptions{LabelSelector: "kind"}
+	deployments, err := ct.readClient(client).ListDeployments(ctx, ct.params.TestNamespace, opts)
+	if err != nil {
+		return fmt.Errorf("unable to list deployments: %w", err)
+	}
+	daemonSets, err := ct.readClient(client).ListDaemonSet(ctx, ct.params.TestNamespace, opts)
+	if err != nil {
+		return fmt.Errorf("unable to list daemonsets: %w", err)
+	}
+
+	leftovers := leftoverResources(deployments.Items, daemonSets.Items)
+	if len(leftovers) == 0 {
+		return nil
+	}
+
+	err = fmt.Errorf("[%s] namespace %s already contains %s, which will be reused as is; use --force-deploy to redeploy them or --reconcile to update drifted specs",
+		client.ClusterName(), ct.params.TestNamespace, strings.Join(leftovers, ", "))
+	if ct.params.StrictLeftovers {
+		return err
+	}
+	ct.Warn(err.Error())
+	return nil
+}
+
+// leftoverResources returns the sorted kind/name of the given deployments and
+// daemonsets.
+func leftoverResources(deployments []appsv1.Deployment, daemonSets []appsv1.DaemonSet) []string {
+	leftovers := make([]string, 0, len(deployments)+len(daemonSets))
+	for _, dep := range deployments {
+		leftovers = append(leftovers, "deployment/"+dep.Name)
+	}
+	for _, ds := range daemonSets {
+		leftovers = append(leftovers, "daemonset/"+ds.Name)
+	}
+	sort.Strings(leftovers)
+	return leftovers
+}
+
+// checkSchedulableNodes verifies that there are enough nodes for the test
+// pods to be spread as requested, instead of letting them wait in Pending
+// until the deployment timeout. The echo-other-node and perf-client-other-node
+// pods need a node other than the client pod's, the echo-external-node pod a
+// node without Cilium.
+func (ct *ConnectivityTest) checkSchedulableNodes() error {
+	multiNode := !ct.params.SingleNode && ct.params.MultiCluster == ""
+
+	if ct.params.Perf {
+		if multiNode {
+			return ct.checkOtherNode(perfClientAcrossDeploymentName)
+		}
+		return nil
+	}
+	if ct.params.Minimal {
+		return nil
+	}
+
+	if multiNode && ct.optionalDeployments[DeployEchoOtherNode] {
+		if err := ct.checkOtherNode(echoOtherNodeDeploymentName); err != nil {
+			return err
+		}
+	}
+	if (!ct.params.SingleNode || ct.params.MultiCluster != "") &&
+		ct.features[FeatureNodeWithoutCilium].Enabled && ct.optionalDeployments[DeployNodeWithoutCilium] {
+		if nodes := schedulableNodes(ct.externalNodes, nodeWithoutCiliumSelector, tolerateAllTaints); len(nodes) == 0 {
+			return fmt.Errorf("[%s] %s requires a schedulable node without Cilium, found none among %v",
+				ct.client.ClusterName(), echoExternalNodeDeploymentName, ct.nodesWithoutCilium)
+		}
+	}
+	return nil
+}
+
+// checkOtherNode returns an error if there are less than 2 Cilium nodes the
+// test pods can be scheduled on, as required by the deployment name.
+func (ct *ConnectivityTest) checkOtherNode(name string) error {
+	nodes := schedulableNodes(ct.nodes, ct.params.NodeSelector, nil)
+	if len(nodes) >= 2 {
+		return nil
+	}
+	return fmt.Errorf("[%s] %s requires 2 schedulable nodes matching the node selector %v, found %d %v; use --single-node to skip the cross-node tests",
+		ct.client.ClusterName(), name, ct.params.NodeSelector, len(nodes), nodes)
+}
+
+// schedulableNodes returns the sorted names of the given nodes matching the
+// selector on which a pod with the given tolerations can be scheduled.
+func schedulableNodes(nodes map[string]*corev1.Node, selector map[string]string, tolerations []corev1.Toleration) []string {
+	names := make([]string, 0, len(nodes))
+	for name, node := range nodes {
+		if isSchedulable(node, selector, tolerations) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isSchedulable(node *corev1.Node, selector map[string]string, tolerations []corev1.Toleration) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		if !slices.ContainsFunc(tolerations, func(t corev1.Toleration) bool { return t.ToleratesTaint(taint) }) {
+			return false
+		}
+	}
+	for k, v := range selector {
+		if node.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// checkServiceAccount verifies that the ServiceAccount given with
+// --service-account, which all test pods run as instead of the ones created on
+// deploy, exists in the test namespace. Its imagePullSecrets are inherited by
+// the test pods, which is the usual way to provide registry credentials.
+func (ct *ConnectivityTest) checkServiceAccount(ctx context.Context, client *k8s.Client) error {
+	if ct.params.ServiceAccount == "" {
+		return nil
+	}
+
+	sa, err := ct.readClient(client).GetServiceAccount(ctx, ct.params.TestNamespace, ct.params.ServiceAccount, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("[%s] unable to get service account %s/%s: %w", client.ClusterName(), ct.params.TestNamespace, ct.params.ServiceAccount, err)
+	}
+
+	secrets := make([]string, 0, len(sa.ImagePullSecrets))
+	for _, secret := range sa.ImagePullSecrets {
+		secrets = append(secrets, secret.Name)
+	}
+	if len(secrets) > 0 {
+		ct.Debugf("[%s] Test pods inherit the image pull secrets %s of service account %s", client.ClusterName(), strings.Join(secrets, ", "), sa.Name)
+	} else {
+		ct.Debugf("[%s] Service account %s has no image pull secrets", client.ClusterName(), sa.Name)
+	}
+	return nil
+}
+
+// checkMTU compares the device MTU computed by the Cilium agent on each node,
+// as inconsistent MTUs typically break cross-node connectivity in ways which
+// are hard to diagnose. A mismatch is reported as a warning, or as an error
+// in strict mode.
+func (ct *ConnectivityTest) checkMTU(ctx context.Context) error {
+	switch ct.params.MTUCheck {
+	case "", MTUCheckModeDisabled:
+		return nil
+	}
+
+	mtus := make(map[string]int64, len(ct.ciliumPods))
+	for _, ciliumPod := range ct.ciliumPods {
+		stdout, err := ciliumPod.K8sClient.ExecInPod(ctx, ciliumPod.Pod.Namespace, ciliumPod.Pod.Name,
+			defaults.AgentContainerName, []string{"cilium", "config", "-o", "json"})
+		if err != nil {
+			return fmt.Errorf("failed to fetch cilium config from %s: %w", ciliumPod.Name(), err)
+		}
+
+		cfg := &models.DaemonConfiguration{}
+		if err := json.Unmarshal(stdout.Bytes(), cfg); err != nil {
+			return fmt.Errorf("unmarshaling cilium config json from %s: %w", ciliumPod.Name(), err)
+		}
+		if cfg.Status == nil || cfg.Status.DeviceMTU == 0 {
+			ct.Debugf("Cilium pod %s does not report its device MTU", ciliumPod.Name())
+			continue
+		}
+		mtus[ciliumPod.K8sClient.ClusterName()+"/"+ciliumPod.Pod.Spec.NodeName] = cfg.Status.DeviceMTU
+	}
+
+	err := checkMTUConsistency(mtus)
+	if err == nil {
+		return nil
+	}
+	if ct.params.MTUCheck == MTUCheckModeStrict {
+		return err
+	}
+	ct.Warnf("%s, cross-node connectivity tests may fail", err)
+	return nil
+}
+
+// checkMTUConsistency returns an error listing the nodes by MTU if the given
+// nodes don't all have the same MTU.
+func checkMTUConsistency(mtus map[string]int64) error {
+	byMTU := map[int64][]string{}
+	for node, mtu := range mtus {
+		byMTU[mtu] = append(byMTU[mtu], node)
+	}
+	if len(byMTU) <= 1 {
+		return nil
+	}
+
+	values := make([]int64, 0, len(byMTU))
+	for mtu := range byMTU {
+		values = append(values, mtu)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	groups := make([]string, 0, len(values))
+	for _, mtu := range values {
+		nodes := byMTU[mtu]
+		sort.Strings(nodes)
+		groups = append(groups, fmt.Sprintf("%d (%s)", mtu, strings.Join(nodes, ", ")))
+	}
+	return fmt.Errorf("nodes have inconsistent MTUs: %s", strings.Join(groups, ", "))
+}
+
+// checkNodeHealth reports the node-to-node paths which cilium-health found
+// unhealthy from each Cilium agent, as an early datapath health signal before
+// running the cross-node scenarios. The last results of cilium-health are
+// used rather than probing again, so that the check is quick. Unhealthy paths
+// are reported as a warning, or as an error in strict mode.
+func (ct *ConnectivityTest) checkNodeHealth(ctx context.Context) error {
+	switch ct.params.NodeHealthCheck {
+	case "", NodeHealthCheckModeDisabled:
+		return nil
+	}
+
+	names := make([]string, 0, len(ct.ciliumPods))
+	for name := range ct.ciliumPods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unhealthy []string
+	for _, name := range names {
+		ciliumPod := ct.ciliumPods[name]
+		stdout, err := ciliumPod.K8sClient.ExecInPod(ctx, ciliumPod.Pod.Namespace, ciliumPod.Pod.Name,
+			defaults.AgentContainerName, []string{"cilium-health", "status", "-o", "json"})
+		if err != nil {
+			return fmt.Errorf("failed to fetch cilium-health status from %s: %w", ciliumPod.Name(), err)
+		}
+
+		status := &healthModels.HealthStatusResponse{}
+		if err := json.Unmarshal(stdout.Bytes(), status); err != nil {
+			return fmt.Errorf("unmarshaling cilium-health status json from %s: %w", ciliumPod.Name(), err)
+		}
+		unhealthy = append(unhealthy, unhealthyPaths(ciliumPod.Pod.Spec.NodeName, status)...)
+	}
+
+	if len(unhealthy) == 0 {
+		ct.Debugf("cilium-health reports all node-to-node paths as healthy")
+		return nil
+	}
+	err := fmt.Errorf("cilium-health reports unhealthy node-to-node paths: %s", strings.Join(unhealthy, "; "))
+	if ct.params.NodeHealthCheck == NodeHealthCheckModeStrict {
+		return err
+	}
+	ct.Warnf("%s, cross-node connectivity tests may fail", err)
+	return nil
+}
+
+// unhealthyPaths returns the paths from the given node to the host and health
+// endpoint of each node in the cilium-health status whose probe failed.
+func unhealthyPaths(from string, status *healthModels.HealthStatusResponse) []string {
+	var unhealthy []string
+	check := func(to, target string, path *healthModels.PathStatus) {
+		if path == nil {
+			return
+		}
+		for proto, s := range map[string]*healthModels.ConnectivityStatus{"icmp": path.Icmp, "http": path.HTTP} {
+			if s != nil && s.Status != "" {
+				unhealthy = append(unhealthy, fmt.Sprintf("%s -> %s %s %s %s: %s", from, to, target, path.IP, proto, s.Status))
+			}
+		}
+	}
+
+	for _, node := range status.Nodes {
+		if node == nil {
+			continue
+		}
+		if node.Host != nil {
+			check(node.Name, "host", node.Host.PrimaryAddress)
+			for _, path := range node.Host.SecondaryAddresses {
+				check(node.Name, "host", path)
+			}
+		}
+		if node.HealthEndpoint != nil {
+			check(node.Name, "health-endpoint", node.HealthEndpoint.PrimaryAddress)
+			for _, path := range node.HealthEndpoint.SecondaryAddresses {
+				check(node.Name, "health-endpoint", path)
+			}
+		}
+	}
+
+	sort.Strings(unhealthy)
+	return unhealthy
+}
+
+// nodePortRange returns the NodePort range configured on the kube-apiserver.
+// It falls back to the Kubernetes default if the range cannot be determined,
+// e.g. on managed clusters where the kube-apiserver is not visible.
+func (ct *ConnectivityTest) nodePortRange(ctx context.Context, client *k8s.Client) (int, int) {
+	pods, err := ct.readClient(client).ListPods(ctx, metav1.NamespaceSystem, metav1.ListOptions{LabelSelector: "component=kube-apiserver"})
+	if err == nil {
+		for _, pod := range pods.Items {
+			for _, c := range pod.Spec.Containers {
+				args := append(append([]string{}, c.Command...), c.Args...)
+				for _, arg := range args {
+					if !strings.HasPrefix(arg, nodePortRangeFlag) {
+						continue
+					}
+					low, high, err := parseNodePortRange(strings.TrimPrefix(arg, nodePortRangeFlag))
+					if err != nil {
+						ct.Debugf("Unable to parse %s: %s", arg, err)
+						continue
+					}
+					return low, high
+				}
+			}
+		}
+	}
+
+	return defaultNodePortRangeMin, defaultNodePortRangeMax
+}
+
+// parseNodePortRange parses a port range in the "min-max" or "base+size"
+// format accepted by the kube-apiserver --service-node-port-range flag.
+func parseNodePortRange(value string) (int, int, error) {
+	value = strings.TrimSpace(value)
+	if base, size, ok := strings.Cut(value, "+"); ok {
+		low, err := strconv.Atoi(base)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid port range %q: %w", value, err)
+		}
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("invalid port range %q", value)
+		}
+		return low, low + n - 1, nil
+	}
+
+	if lowStr, highStr, ok := strings.Cut(value, "-"); ok {
+		low, err := strconv.Atoi(lowStr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid port range %q: %w", value, err)
+		}
+		high, err := strconv.Atoi(highStr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid port range %q: %w", value, err)
+		}
+		if high < low {
+			return 0, 0, fmt.Errorf("invalid port range %q", value)
+		}
+		return low, high, nil
+	}
+
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q: %w", value, err)
+	}
+	return port, port, nil
+}
@@ -0,0 +1,359 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"reflect"
+	"testing"
+
+	healthModels "github.com/cilium/cilium/api/v1/health/models"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cilium/cilium-cli/k8s"
+)
+
+func TestParseNodePortRange(t *testing.T) {
+	tests := map[string]struct {
+		in       string
+		wantLow  int
+		wantHigh int
+		wantErr  bool
+	}{
+		"Default range": {
+			in:       "30000-32767",
+			wantLow:  30000,
+			wantHigh: 32767,
+		},
+		"Base and size": {
+			in:       "30000+100",
+			wantLow:  30000,
+			wantHigh: 30099,
+		},
+		"Single port": {
+			in:       "30000",
+			wantLow:  30000,
+			wantHigh: 30000,
+		},
+		"Inverted range": {
+			in:      "32767-30000",
+			wantErr: true,
+		},
+		"Garbage": {
+			in:      "foo-bar",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			low, high, err := parseNodePortRange(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseNodePortRange() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if low != tc.wantLow || high != tc.wantHigh {
+				t.Errorf("parseNodePortRange() = %d-%d, want %d-%d", low, high, tc.wantLow, tc.wantHigh)
+			}
+		})
+	}
+}
+
+func TestNodePortServices(t *testing.T) {
+	client := &k8s.Client{}
+	all := map[OptionalDeployment]bool{
+		DeployEchoOtherNode: true,
+		DeployEchoLBService: true,
+		DeployEchoTopology:  true,
+		DeployEchoTLS:       true,
+	}
+	zones := map[string]*corev1.Node{
+		"node-1": {ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelTopologyZone: "a"}}},
+		"node-2": {ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelTopologyZone: "b"}}},
+	}
+
+	for name, tt := range map[string]struct {
+		params Parameters
+		nodes  map[string]*corev1.Node
+		want   []string
+	}{
+		"default": {
+			want: []string{echoSameNodeDeploymentName, echoOtherNodeDeploymentName},
+		},
+		"single node": {
+			params: Parameters{SingleNode: true},
+			want:   []string{echoSameNodeDeploymentName},
+		},
+		"optional services": {
+			params: Parameters{EchoLBService: true, EchoTopologyHints: true, EchoTLS: true},
+			nodes:  zones,
+			want: []string{echoSameNodeDeploymentName, echoOtherNodeDeploymentName,
+				echoLBServiceName, echoTopologyDeploymentName, echoTLSDeploymentName},
+		},
+		"single zone": {
+			params: Parameters{EchoTopologyHints: true},
+			want:   []string{echoSameNodeDeploymentName, echoOtherNodeDeploymentName},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{
+				params:              tt.params,
+				clients:             &deploymentClients{src: client, dst: client},
+				optionalDeployments: all,
+				nodes:               tt.nodes,
+			}
+			var got []string
+			for _, svc := range ct.nodePortServices()[client] {
+				got = append(got, svc.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nodePortServices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRoutingMode(t *testing.T) {
+	native := FeatureSet{FeatureTunnel: {Enabled: false, Mode: "disabled"}}
+	vxlan := FeatureSet{FeatureTunnel: {Enabled: true, Mode: "vxlan"}}
+
+	tests := map[string]struct {
+		expected string
+		features FeatureSet
+		wantErr  bool
+	}{
+		"No expectation":     {expected: "", features: vxlan},
+		"Native":             {expected: "native", features: native},
+		"Any tunnel":         {expected: "tunnel", features: vxlan},
+		"Tunnel protocol":    {expected: "vxlan", features: vxlan},
+		"Native mismatch":    {expected: "native", features: vxlan, wantErr: true},
+		"Tunnel mismatch":    {expected: "tunnel", features: native, wantErr: true},
+		"Protocol mismatch":  {expected: "geneve", features: vxlan, wantErr: true},
+		"Unknown tunnel off": {expected: "vxlan", features: FeatureSet{}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkRoutingMode(tc.expected, tc.features)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("checkRoutingMode() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckMTUConsistency(t *testing.T) {
+	for name, tt := range map[string]struct {
+		mtus    map[string]int64
+		wantErr string
+	}{
+		"no nodes":   {},
+		"consistent": {mtus: map[string]int64{"c/a": 1450, "c/b": 1450}},
+		"inconsistent": {
+			mtus:    map[string]int64{"c/b": 1500, "c/a": 1450, "c/c": 1450},
+			wantErr: "nodes have inconsistent MTUs: 1450 (c/a, c/c), 1500 (c/b)",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := checkMTUConsistency(tt.mtus)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLeftoverResources(t *testing.T) {
+	deployments := []appsv1.Deployment{
+		{ObjectMeta: metav1.ObjectMeta{Name: "echo-same-node"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "client"}},
+	}
+	daemonSets := []appsv1.DaemonSet{
+		{ObjectMeta: metav1.ObjectMeta{Name: "host-netns"}},
+	}
+
+	want := []string{"daemonset/host-netns", "deployment/client", "deployment/echo-same-node"}
+	if got := leftoverResources(deployments, daemonSets); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := leftoverResources(nil, nil); len(got) != 0 {
+		t.Errorf("expected no leftovers, got %v", got)
+	}
+}
+
+func TestSchedulableNodes(t *testing.T) {
+	nodes := map[string]*corev1.Node{
+		"ready": {ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pool": "test"}}},
+		"other": {ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pool": "other"}}},
+		"cordoned": {
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pool": "test"}},
+			Spec:       corev1.NodeSpec{Unschedulable: true},
+		},
+		"tainted": {
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pool": "test"}},
+			Spec:       corev1.NodeSpec{Taints: []corev1.Taint{{Key: "dedicated", Effect: corev1.TaintEffectNoSchedule}}},
+		},
+		"prefer-no-schedule": {
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pool": "test"}},
+			Spec:       corev1.NodeSpec{Taints: []corev1.Taint{{Key: "dedicated", Effect: corev1.TaintEffectPreferNoSchedule}}},
+		},
+	}
+
+	tests := map[string]struct {
+		selector    map[string]string
+		tolerations []corev1.Toleration
+		want        []string
+	}{
+		"no selector": {
+			want: []string{"other", "prefer-no-schedule", "ready"},
+		},
+		"with selector": {
+			selector: map[string]string{"pool": "test"},
+			want:     []string{"prefer-no-schedule", "ready"},
+		},
+		"no match": {
+			selector: map[string]string{"pool": "none"},
+			want:     []string{},
+		},
+		"tolerated taint": {
+			selector:    map[string]string{"pool": "test"},
+			tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+			want:        []string{"prefer-no-schedule", "ready", "tainted"},
+		},
+		"other taint tolerated": {
+			selector:    map[string]string{"pool": "test"},
+			tolerations: []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpExists}},
+			want:        []string{"prefer-no-schedule", "ready"},
+		},
+		"all taints tolerated": {
+			tolerations: tolerateAllTaints,
+			want:        []string{"other", "prefer-no-schedule", "ready", "tainted"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := schedulableNodes(nodes, tc.selector, tc.tolerations); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckSchedulableNodes(t *testing.T) {
+	oneNode := map[string]*corev1.Node{"node-a": {}}
+	twoNodes := map[string]*corev1.Node{"node-a": {}, "node-b": {}}
+	cordoned := map[string]*corev1.Node{"external": {
+		ObjectMeta: metav1.ObjectMeta{Labels: nodeWithoutCiliumSelector},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+	}}
+	tainted := map[string]*corev1.Node{"external": {
+		ObjectMeta: metav1.ObjectMeta{Labels: nodeWithoutCiliumSelector},
+		Spec:       corev1.NodeSpec{Taints: []corev1.Taint{{Key: "dedicated", Effect: corev1.TaintEffectNoExecute}}},
+	}}
+
+	tests := map[string]struct {
+		params        Parameters
+		nodes         map[string]*corev1.Node
+		externalNodes map[string]*corev1.Node
+		wantErr       bool
+	}{
+		"two nodes": {
+			nodes: twoNodes,
+		},
+		"one node": {
+			nodes:   oneNode,
+			wantErr: true,
+		},
+		"one node with single-node": {
+			params: Parameters{SingleNode: true},
+			nodes:  oneNode,
+		},
+		"one node with minimal": {
+			params: Parameters{Minimal: true},
+			nodes:  oneNode,
+		},
+		"one node in multi-cluster": {
+			params: Parameters{MultiCluster: "other"},
+			nodes:  oneNode,
+		},
+		"perf on one node": {
+			params:  Parameters{Perf: true},
+			nodes:   oneNode,
+			wantErr: true,
+		},
+		"perf on one node with single-node": {
+			params: Parameters{Perf: true, SingleNode: true},
+			nodes:  oneNode,
+		},
+		"tainted node without cilium": {
+			nodes:         twoNodes,
+			externalNodes: tainted,
+		},
+		"cordoned node without cilium": {
+			nodes:         twoNodes,
+			externalNodes: cordoned,
+			wantErr:       true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ct := &ConnectivityTest{
+				params:              tc.params,
+				client:              &k8s.Client{},
+				nodes:               tc.nodes,
+				externalNodes:       tc.externalNodes,
+				optionalDeployments: map[OptionalDeployment]bool{DeployEchoOtherNode: true, DeployNodeWithoutCilium: true},
+				features:            FeatureSet{FeatureNodeWithoutCilium: {Enabled: len(tc.externalNodes) != 0}},
+			}
+			if err := ct.checkSchedulableNodes(); (err != nil) != tc.wantErr {
+				t.Errorf("expected error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestUnhealthyPaths(t *testing.T) {
+	healthy := &healthModels.ConnectivityStatus{Latency: 100}
+	failed := &healthModels.ConnectivityStatus{Status: "Connection timed out"}
+
+	status := &healthModels.HealthStatusResponse{
+		Nodes: []*healthModels.NodeStatus{
+			{
+				Name: "kind/node-a",
+				Host: &healthModels.HostStatus{
+					PrimaryAddress: &healthModels.PathStatus{IP: "10.0.0.1", Icmp: healthy, HTTP: healthy},
+				},
+				HealthEndpoint: &healthModels.EndpointStatus{
+					PrimaryAddress: &healthModels.PathStatus{IP: "10.244.0.10", Icmp: healthy, HTTP: healthy},
+				},
+			},
+			{
+				Name: "kind/node-b",
+				Host: &healthModels.HostStatus{
+					PrimaryAddress:     &healthModels.PathStatus{IP: "10.0.0.2", Icmp: healthy, HTTP: healthy},
+					SecondaryAddresses: []*healthModels.PathStatus{{IP: "fd00::2", Icmp: failed}},
+				},
+				HealthEndpoint: &healthModels.EndpointStatus{
+					PrimaryAddress: &healthModels.PathStatus{IP: "10.244.1.10", Icmp: healthy, HTTP: failed},
+				},
+			},
+		},
+	}
+
+	want := []string{
+		"node-a -> kind/node-b health-endpoint 10.244.1.10 http: Connection timed out",
+		"node-a -> kind/node-b host fd00::2 icmp: Connection timed out",
+	}
+	if got := unhealthyPaths("node-a", status); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := unhealthyPaths("node-a", &healthModels.HealthStatusResponse{}); len(got) != 0 {
+		t.Errorf("expected no unhealthy paths, got %v", got)
+	}
+}
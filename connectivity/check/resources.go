@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/cilium/cilium-cli/k8s"
+)
+
+// createdResource identifies a resource created on deploy, so that it can be
+// cleaned up by an external process even if the CLI crashes.
+type createdResource struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+}
+
+// recordCreated records a resource created on deploy, and rewrites
+// CreatedResourcesFile with all resources created so far. Failing to write the
+// file only results in a warning, as it doesn't affect the tests.
+func (ct *ConnectivityTest) recordCreated(client *k8s.Client, kind, namespace, name string) {
+	if ct.params.CreatedResourcesFile == "" {
+		return
+	}
+
+	ct.createdResourcesMu.Lock()
+	defer ct.createdResourcesMu.Unlock()
+
+	ct.createdResources = append(ct.createdResources, createdResource{
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Cluster:   client.ClusterName(),
+	})
+	if err := writeCreatedResources(ct.params.CreatedResourcesFile, ct.createdResources); err != nil {
+		ct.Warnf("Unable to write created resources to %s: %s", ct.params.CreatedResourcesFile, err)
+	}
+}
+
+// writeCreatedResources atomically replaces the file at path with the given
+// resources as JSON, so that readers never observe a partially written file.
+func writeCreatedResources(path string, resources []createdResource) error {
+	data, err := json.MarshalIndent(resources, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteCreatedResources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resources.json")
+	resources := []createdResource{
+		{Kind: "Namespace", Name: "cilium-test", Cluster: "kind-kind"},
+		{Kind: "Deployment", Namespace: "cilium-test", Name: "client", Cluster: "kind-kind"},
+	}
+
+	for i := range resources {
+		if err := writeCreatedResources(path, resources[:i+1]); err != nil {
+			t.Fatalf("writeCreatedResources() error = %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("unable to read %s: %v", path, err)
+		}
+		var got []createdResource
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("invalid JSON %q: %v", data, err)
+		}
+		if !reflect.DeepEqual(got, resources[:i+1]) {
+			t.Errorf("expected %v, got %v", resources[:i+1], got)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("unable to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only %s to remain, got %d files", path, len(entries))
+	}
+}
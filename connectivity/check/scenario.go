@@ -21,3 +21,11 @@ type ConditionalScenario interface {
 	Scenario
 	Requirements() []FeatureRequirement
 }
+
+// DeploymentScenario is a test scenario which only needs some of the optional
+// test deployments. All of them are deployed if any enabled scenario doesn't
+// implement it.
+type DeploymentScenario interface {
+	Scenario
+	RequiredDeployments() []OptionalDeployment
+}
@@ -503,31 +503,33 @@ func (t *Test) WithCABundleSecret() *Test {
 	return t
 }
 
-// WithCertificate makes a secret with a certificate and adds it to the cluster
-func (t *Test) WithCertificate(name, hostname string) *Test {
+// newCertificate generates a new CA and a server certificate for the given
+// hostnames signed by it. It returns the PEM encoded CA certificate, server
+// certificate and server private key.
+func newCertificate(hostnames ...string) (caCert, certBytes, keyBytes []byte, err error) {
 	caCert, _, caKey, err := initca.New(&csr.CertificateRequest{
 		KeyRequest: csr.NewKeyRequest(),
 		CN:         "Cilium Test CA",
 	})
 	if err != nil {
-		t.Fatalf("Unable to create CA: %s", err)
+		return nil, nil, nil, fmt.Errorf("unable to create CA: %w", err)
 	}
 
 	g := &csr.Generator{Validator: genkey.Validator}
 	csrBytes, keyBytes, err := g.ProcessRequest(&csr.CertificateRequest{
-		CN:    hostname,
-		Hosts: []string{hostname},
+		CN:    hostnames[0],
+		Hosts: hostnames,
 	})
 	if err != nil {
-		t.Fatalf("Unable to create CSR: %s", err)
+		return nil, nil, nil, fmt.Errorf("unable to create CSR: %w", err)
 	}
 	parsedCa, err := helpers.ParseCertificatePEM(caCert)
 	if err != nil {
-		t.Fatalf("Unable to parse CA: %s", err)
+		return nil, nil, nil, fmt.Errorf("unable to parse CA: %w", err)
 	}
 	caPriv, err := helpers.ParsePrivateKeyPEM(caKey)
 	if err != nil {
-		t.Fatalf("Unable to parse CA key: %s", err)
+		return nil, nil, nil, fmt.Errorf("unable to parse CA key: %w", err)
 	}
 
 	signConf := &config.Signing{
@@ -539,11 +541,21 @@ func (t *Test) WithCertificate(name, hostname string) *Test {
 
 	s, err := local.NewSigner(caPriv, parsedCa, signer.DefaultSigAlgo(caPriv), signConf)
 	if err != nil {
-		t.Fatalf("Unable to create signer: %s", err)
+		return nil, nil, nil, fmt.Errorf("unable to create signer: %w", err)
 	}
-	certBytes, err := s.Sign(signer.SignRequest{Request: string(csrBytes)})
+	certBytes, err = s.Sign(signer.SignRequest{Request: string(csrBytes)})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to sign certificate: %w", err)
+	}
+
+	return caCert, certBytes, keyBytes, nil
+}
+
+// WithCertificate makes a secret with a certificate and adds it to the cluster
+func (t *Test) WithCertificate(name, hostname string) *Test {
+	caCert, certBytes, keyBytes, err := newCertificate(hostname)
 	if err != nil {
-		t.Fatalf("Unable to sign certificate: %s", err)
+		t.Fatalf("Unable to create certificate: %s", err)
 	}
 
 	if t.certificateCAs == nil {
@@ -3,8 +3,44 @@ package check
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
+func TestLogsSince(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		logSince time.Duration
+		start    time.Time
+		want     time.Time
+	}{
+		"whole test": {
+			logSince: 0,
+			start:    now.Add(-time.Hour),
+			want:     now.Add(-time.Hour),
+		},
+		"last minutes of a long test": {
+			logSince: 5 * time.Minute,
+			start:    now.Add(-time.Hour),
+			want:     now.Add(-5 * time.Minute),
+		},
+		"short test": {
+			logSince: 5 * time.Minute,
+			start:    now.Add(-time.Minute),
+			want:     now.Add(-time.Minute),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := Parameters{LogSince: tc.logSince}
+			if got := p.logsSince(tc.start, now); !got.Equal(tc.want) {
+				t.Errorf("logsSince() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestWithFeatureRequirements(t *testing.T) {
 	tests := map[string]struct {
 		requirements []FeatureRequirement
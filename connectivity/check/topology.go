@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// WriteTopology renders the deployed test topology as a Graphviz DOT graph.
+// Pods are grouped by cluster and node, and each Service is linked to the
+// Pods it selects. It must be called after the deployment was validated.
+func (ct *ConnectivityTest) WriteTopology(w io.Writer) error {
+	pods := make(map[string]Pod)
+	for _, m := range []map[string]Pod{ct.ClientPods(), ct.EchoPods(), ct.ExternalEchoPods(), ct.PerfClientPods(), ct.PerfServerPod(), ct.HostNetNSPodsByNode(), ct.EchoTopologyPods()} {
+		for _, pod := range m {
+			pods[pod.Name()] = pod
+		}
+	}
+
+	type location struct {
+		cluster string
+		node    string
+	}
+	byLocation := make(map[location][]string)
+	for name, pod := range pods {
+		loc := location{node: pod.Pod.Spec.NodeName}
+		if pod.K8sClient != nil {
+			loc.cluster = pod.K8sClient.ClusterName()
+		}
+		byLocation[loc] = append(byLocation[loc], name)
+	}
+	locations := make([]location, 0, len(byLocation))
+	for loc := range byLocation {
+		locations = append(locations, loc)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].cluster != locations[j].cluster {
+			return locations[i].cluster < locations[j].cluster
+		}
+		return locations[i].node < locations[j].node
+	})
+
+	services := make(map[string]Service)
+	for _, m := range []map[string]Service{ct.EchoServices(), ct.IngressService(), ct.ExternalNameServices(), ct.EchoLBServices(), ct.EchoTopologyServices()} {
+		for _, svc := range m {
+			services[svc.Name()] = svc
+		}
+	}
+	serviceNames := make([]string, 0, len(services))
+	for name := range services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+
+	fmt.Fprintln(w, "digraph connectivity {")
+	fmt.Fprintln(w, "\trankdir=LR;")
+
+	for i, loc := range locations {
+		names := byLocation[loc]
+		sort.Strings(names)
+
+		// Graphviz only draws a box around subgraphs named cluster*.
+		fmt.Fprintf(w, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(w, "\t\tlabel=%q;\n", loc.cluster+" / "+loc.node)
+		for _, name := range names {
+			fmt.Fprintf(w, "\t\t%q [label=%q];\n", name, pods[name].Pod.Name+"\n"+pods[name].Pod.Status.PodIP)
+		}
+		fmt.Fprintln(w, "\t}")
+	}
+
+	for _, name := range serviceNames {
+		svc := services[name].Service
+		addr := svc.Spec.ClusterIP
+		if svc.Spec.ExternalName != "" {
+			addr = svc.Spec.ExternalName
+		}
+		fmt.Fprintf(w, "\t%q [shape=box, label=%q];\n", name, svc.Name+"\n"+addr)
+
+		if len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		selector := labels.SelectorFromSet(svc.Spec.Selector)
+		var backends []string
+		for podName, pod := range pods {
+			if selector.Matches(labels.Set(pod.Pod.Labels)) {
+				backends = append(backends, podName)
+			}
+		}
+		sort.Strings(backends)
+		for _, backend := range backends {
+			fmt.Fprintf(w, "\t%q -> %q;\n", name, backend)
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+func (ct *ConnectivityTest) writeTopology() error {
+	if ct.params.TopologyFile == "" {
+		return nil
+	}
+
+	f, err := os.Create(ct.params.TopologyFile)
+	if err != nil {
+		return err
+	}
+
+	if err := ct.WriteTopology(f); err != nil {
+		if e := f.Close(); e != nil {
+			return errors.Join(err, e)
+		}
+		return err
+	}
+
+	return f.Close()
+}
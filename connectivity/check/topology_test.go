@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package check
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWriteTopology(t *testing.T) {
+	pod := func(name, node string) Pod {
+		return Pod{Pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "cilium-test", Name: name, Labels: map[string]string{"name": strings.Split(name, "-1")[0]}},
+			Spec:       corev1.PodSpec{NodeName: node},
+		}}
+	}
+	ct := &ConnectivityTest{
+		clientPods: map[string]Pod{"client-1": pod("client-1", "node-a")},
+		echoPods: map[string]Pod{
+			"echo-same-node-1":  pod("echo-same-node-1", "node-a"),
+			"echo-other-node-1": pod("echo-other-node-1", "node-b"),
+		},
+		echoServices: map[string]Service{
+			"echo-same-node": {Service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "cilium-test", Name: "echo-same-node"},
+				Spec:       corev1.ServiceSpec{Selector: map[string]string{"name": "echo-same-node"}},
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := ct.WriteTopology(&buf); err != nil {
+		t.Fatalf("WriteTopology() error = %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"digraph connectivity {",
+		`label=" / node-a";`,
+		`label=" / node-b";`,
+		`"cilium-test/echo-same-node" -> "cilium-test/echo-same-node-1";`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteTopology() output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `"cilium-test/echo-same-node" -> "cilium-test/echo-other-node-1"`) {
+		t.Errorf("WriteTopology() linked a service to a pod it does not select:\n%s", out)
+	}
+}
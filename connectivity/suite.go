@@ -95,6 +95,9 @@ var (
 	//go:embed manifests/client-egress-to-fqdns-one-one-one-one.yaml
 	clientEgressToFQDNsCiliumIOPolicyYAML string
 
+	//go:embed manifests/client-egress-to-fqdns-external.yaml
+	clientEgressToFQDNsExternalPolicyYAML string
+
 	//go:embed manifests/echo-ingress-from-other-client.yaml
 	echoIngressFromOtherClientPolicyYAML string
 
@@ -119,6 +122,9 @@ var (
 	//go:embed manifests/client-egress-l7-http.yaml
 	clientEgressL7HTTPPolicyYAML string
 
+	//go:embed manifests/client-egress-l7-http-status.yaml
+	clientEgressL7HTTPStatusPolicyYAML string
+
 	//go:embed manifests/client-egress-l7-http-method.yaml
 	clientEgressL7HTTPMethodPolicyYAML string
 
@@ -165,7 +171,7 @@ var (
 )
 
 func Run(ctx context.Context, ct *check.ConnectivityTest) error {
-	if err := ct.SetupAndValidate(ctx); err != nil {
+	if err := ct.Setup(ctx); err != nil {
 		return err
 	}
 
@@ -173,15 +179,33 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 
 	// render templates, if any problems fail early
 	for key, temp := range map[string]string{
-		"clientEgressToCIDRExternalPolicyYAML":     clientEgressToCIDRExternalPolicyYAML,
-		"clientEgressToCIDRExternalPolicyKNPYAML":  clientEgressToCIDRExternalPolicyKNPYAML,
-		"clientEgressToCIDRExternalDenyPolicyYAML": clientEgressToCIDRExternalDenyPolicyYAML,
-		"clientEgressL7HTTPPolicyYAML":             clientEgressL7HTTPPolicyYAML,
-		"clientEgressL7HTTPNamedPortPolicyYAML":    clientEgressL7HTTPNamedPortPolicyYAML,
-		"clientEgressToFQDNsCiliumIOPolicyYAML":    clientEgressToFQDNsCiliumIOPolicyYAML,
-		"clientEgressL7TLSPolicyYAML":              clientEgressL7TLSPolicyYAML,
-		"clientEgressL7HTTPMatchheaderSecretYAML":  clientEgressL7HTTPMatchheaderSecretYAML,
-		"echoIngressFromCIDRYAML":                  echoIngressFromCIDRYAML,
+		"clientEgressToCIDRExternalPolicyYAML":               clientEgressToCIDRExternalPolicyYAML,
+		"clientEgressToCIDRExternalPolicyKNPYAML":            clientEgressToCIDRExternalPolicyKNPYAML,
+		"clientEgressToCIDRExternalDenyPolicyYAML":           clientEgressToCIDRExternalDenyPolicyYAML,
+		"clientEgressL7HTTPPolicyYAML":                       clientEgressL7HTTPPolicyYAML,
+		"clientEgressL7HTTPNamedPortPolicyYAML":              clientEgressL7HTTPNamedPortPolicyYAML,
+		"clientEgressToFQDNsCiliumIOPolicyYAML":              clientEgressToFQDNsCiliumIOPolicyYAML,
+		"clientEgressToFQDNsExternalPolicyYAML":              clientEgressToFQDNsExternalPolicyYAML,
+		"clientEgressL7TLSPolicyYAML":                        clientEgressL7TLSPolicyYAML,
+		"clientEgressL7HTTPMatchheaderSecretYAML":            clientEgressL7HTTPMatchheaderSecretYAML,
+		"echoIngressFromCIDRYAML":                            echoIngressFromCIDRYAML,
+		"clientEgressL7HTTPMethodPolicyYAML":                 clientEgressL7HTTPMethodPolicyYAML,
+		"clientEgressToEchoDenyPolicyYAML":                   clientEgressToEchoDenyPolicyYAML,
+		"clientEgressToEchoExpressionDenyPolicyYAML":         clientEgressToEchoExpressionDenyPolicyYAML,
+		"clientEgressToEchoExpressionPolicyKNPYAML":          clientEgressToEchoExpressionPolicyKNPYAML,
+		"clientEgressToEchoExpressionPolicyYAML":             clientEgressToEchoExpressionPolicyYAML,
+		"clientEgressToEchoPolicyKNPYAML":                    clientEgressToEchoPolicyKNPYAML,
+		"clientEgressToEchoDenyNamedPortPolicyYAML":          clientEgressToEchoDenyNamedPortPolicyYAML,
+		"clientEgressToEchoServiceAccountDenyPolicyYAML":     clientEgressToEchoServiceAccountDenyPolicyYAML,
+		"clientEgressToEchoServiceAccountPolicyYAML":         clientEgressToEchoServiceAccountPolicyYAML,
+		"clientEgressToEchoPolicyYAML":                       clientEgressToEchoPolicyYAML,
+		"clientWithServiceAccountEgressToEchoDenyPolicyYAML": clientWithServiceAccountEgressToEchoDenyPolicyYAML,
+		"clientWithServiceAccountEgressToEchoPolicyYAML":     clientWithServiceAccountEgressToEchoPolicyYAML,
+		"echoIngressAuthFailPolicyYAML":                      echoIngressAuthFailPolicyYAML,
+		"echoIngressL7HTTPFromAnywherePolicyYAML":            echoIngressL7HTTPFromAnywherePolicyYAML,
+		"echoIngressL7HTTPNamedPortPolicyYAML":               echoIngressL7HTTPNamedPortPolicyYAML,
+		"echoIngressL7HTTPPolicyYAML":                        echoIngressL7HTTPPolicyYAML,
+		"echoIngressMTLSPolicyYAML":                          echoIngressMTLSPolicyYAML,
 	} {
 		val, err := utils.RenderTemplate(temp, ct.Params())
 		if err != nil {
@@ -190,6 +214,9 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 		renderedTemplates[key] = val
 	}
 
+	// Port the echo pods are reached on by the pod-to-pod scenarios.
+	echoPort := uint32(ct.Params().EchoServerPort())
+
 	ct.Infof("Cilium version: %v", ct.CiliumVersion)
 
 	// Network Performance Test
@@ -197,7 +224,17 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 		ct.NewTest("network-perf").WithScenarios(
 			tests.NetperfPodtoPod(""),
 		)
-		return ct.Run(ctx)
+		return deployAndRun(ctx, ct)
+	}
+
+	// Minimal smoke test, only the client and echo-same-node deployments
+	// are available.
+	if ct.Params().Minimal {
+		ct.NewTest("minimal").WithScenarios(
+			tests.PodToPod(),
+			tests.PodToService(),
+		)
+		return deployAndRun(ctx, ct)
 	}
 
 	// Datapath Conformance Tests
@@ -209,21 +246,25 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 			)
 		ct.NewTest("north-south-loadbalancing-with-l7-policy").
 			WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureNodeWithoutCilium)).
-			WithCiliumPolicy(echoIngressL7HTTPFromAnywherePolicyYAML).
+			WithCiliumPolicy(renderedTemplates["echoIngressL7HTTPFromAnywherePolicyYAML"]).
 			WithScenarios(
 				tests.OutsideToNodePort(),
 			)
-		ct.NewTest("pod-to-pod-encryption").
-			WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureEncryptionPod)).
-			WithScenarios(
-				tests.PodToPodEncryption(),
-			)
-		ct.NewTest("node-to-node-encryption").
-			WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureEncryptionPod),
-				check.RequireFeatureEnabled(check.FeatureEncryptionNode)).
-			WithScenarios(
-				tests.NodeToNodeEncryption(),
-			)
+		// The encryption tests run tcpdump in the host-netns pods, which
+		// requires NET_RAW.
+		if !ct.Params().NoHostNetNSNetRaw {
+			ct.NewTest("pod-to-pod-encryption").
+				WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureEncryptionPod)).
+				WithScenarios(
+					tests.PodToPodEncryption(),
+				)
+			ct.NewTest("node-to-node-encryption").
+				WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureEncryptionPod),
+					check.RequireFeatureEnabled(check.FeatureEncryptionNode)).
+				WithScenarios(
+					tests.NodeToNodeEncryption(),
+				)
+		}
 
 		ct.NewTest("egress-gateway").
 			WithCiliumEgressGatewayPolicy(egressGatewayPolicyYAML).
@@ -233,7 +274,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 				tests.EgressGateway(),
 			)
 
-		return ct.Run(ctx)
+		return deployAndRun(ctx, ct)
 	}
 
 	// Run all tests without any policies in place.
@@ -241,6 +282,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 		tests.PodToPod(),
 		tests.ClientToClient(),
 		tests.PodToService(),
+		tests.PodToKubernetesAPIService(),
 		tests.PodToHostPort(),
 		tests.PodToWorld(tests.WithRetryAll()),
 		tests.PodToHost(),
@@ -250,6 +292,17 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 	if s, ok := ct.Feature(check.FeatureNodeWithoutCilium); ok && s.Enabled {
 		noPoliciesScenarios = append(noPoliciesScenarios, tests.FromCIDRToPod())
 	}
+	if v4, ok := ct.Feature(check.FeatureIPv4); ok && v4.Enabled {
+		if v6, ok := ct.Feature(check.FeatureIPv6); ok && v6.Enabled {
+			noPoliciesScenarios = append(noPoliciesScenarios, tests.PodToPodIPFamily())
+		}
+	}
+	if ct.Params().ProbeCount > 0 {
+		noPoliciesScenarios = append(noPoliciesScenarios, tests.PodToPodProbes())
+	}
+	if ct.Params().PingCount > 0 {
+		noPoliciesScenarios = append(noPoliciesScenarios, tests.PodToPodICMP())
+	}
 	ct.NewTest("no-policies").WithScenarios(noPoliciesScenarios...)
 
 	// Skip the nodeport-related tests in the multicluster scenario if KPR is not
@@ -266,6 +319,32 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 			tests.PodToLocalNodePort(),
 		)
 
+	if ct.Params().ExternalNameService {
+		ct.NewTest("external-name-service").WithScenarios(tests.PodToExternalNameService())
+	}
+
+	if ct.Params().EchoLBService {
+		ct.NewTest("echo-lb-service").WithScenarios(tests.PodToEchoLBService())
+	}
+
+	if ct.Params().EchoTopologyHints {
+		ct.NewTest("echo-topology-hints").WithScenarios(tests.PodToEchoTopologyService())
+	}
+
+	if ct.Params().EchoTLS {
+		ct.NewTest("echo-tls").WithScenarios(tests.PodToEchoTLSService())
+	}
+
+	if ct.Params().ClientNetworks != "" {
+		ct.NewTest("client-secondary-network").WithScenarios(tests.ClientToClientSecondaryNetwork())
+	}
+
+	if ct.Params().ExpectedEgressIP != "" {
+		ct.NewTest("egress-ip").
+			WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureNodeWithoutCilium)).
+			WithScenarios(tests.EgressIP())
+	}
+
 	// Test with an allow-all-except-world (and unmanaged) policy.
 	ct.NewTest("allow-all-except-world").WithCiliumPolicy(allowAllExceptWorldPolicyYAML).
 		WithScenarios(
@@ -449,37 +528,37 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 		})
 
 	// This policy allows port 8080 from client to echo, so this should succeed
-	ct.NewTest("client-egress").WithCiliumPolicy(clientEgressToEchoPolicyYAML).
+	ct.NewTest("client-egress").WithCiliumPolicy(renderedTemplates["clientEgressToEchoPolicyYAML"]).
 		WithScenarios(
 			tests.PodToPod(),
 		)
 
 	// This policy allows port 8080 from client to echo, so this should succeed
-	ct.NewTest("client-egress-knp").WithK8SPolicy(clientEgressToEchoPolicyKNPYAML).
+	ct.NewTest("client-egress-knp").WithK8SPolicy(renderedTemplates["clientEgressToEchoPolicyKNPYAML"]).
 		WithScenarios(
 			tests.PodToPod(),
 		)
 
 	// This policy allows port 8080 from client to echo (using label match expression, so this should succeed
-	ct.NewTest("client-egress-expression").WithCiliumPolicy(clientEgressToEchoExpressionPolicyYAML).
+	ct.NewTest("client-egress-expression").WithCiliumPolicy(renderedTemplates["clientEgressToEchoExpressionPolicyYAML"]).
 		WithScenarios(
 			tests.PodToPod(),
 		)
 
 	// This policy allows port 8080 from client to echo (using label match expression, so this should succeed
-	ct.NewTest("client-egress-expression-knp").WithK8SPolicy(clientEgressToEchoExpressionPolicyKNPYAML).
+	ct.NewTest("client-egress-expression-knp").WithK8SPolicy(renderedTemplates["clientEgressToEchoExpressionPolicyKNPYAML"]).
 		WithScenarios(
 			tests.PodToPod(),
 		)
 
 	// This policy allows port 8080 from client with service account label to echo
-	ct.NewTest("client-with-service-account-egress-to-echo").WithCiliumPolicy(clientWithServiceAccountEgressToEchoPolicyYAML).
+	ct.NewTest("client-with-service-account-egress-to-echo").WithCiliumPolicy(renderedTemplates["clientWithServiceAccountEgressToEchoPolicyYAML"]).
 		WithScenarios(
 			tests.PodToPod(tests.WithSourceLabelsOption(map[string]string{"kind": "client"})),
 		)
 
 	// This policy allows port 8080 from client to endpoint with service account label as echo-same-node
-	ct.NewTest("client-egress-to-echo-service-account").WithCiliumPolicy(clientEgressToEchoServiceAccountPolicyYAML).
+	ct.NewTest("client-egress-to-echo-service-account").WithCiliumPolicy(renderedTemplates["clientEgressToEchoServiceAccountPolicyYAML"]).
 		WithScenarios(
 			tests.PodToPod(
 				tests.WithSourceLabelsOption(map[string]string{"kind": "client"}),
@@ -583,9 +662,9 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 
 	// This policy denies port 8080 from client to echo
 	ct.NewTest("client-egress-to-echo-deny").
-		WithCiliumPolicy(allowAllEgressPolicyYAML).         // Allow all egress traffic
-		WithCiliumPolicy(allowAllIngressPolicyYAML).        // Allow all ingress traffic
-		WithCiliumPolicy(clientEgressToEchoDenyPolicyYAML). // Deny client to echo traffic via port 8080
+		WithCiliumPolicy(allowAllEgressPolicyYAML).                              // Allow all egress traffic
+		WithCiliumPolicy(allowAllIngressPolicyYAML).                             // Allow all ingress traffic
+		WithCiliumPolicy(renderedTemplates["clientEgressToEchoDenyPolicyYAML"]). // Deny client to echo traffic via port 8080
 		WithScenarios(
 			tests.ClientToClient(), // Client to client traffic should be allowed
 			tests.PodToPod(),       // Client to echo traffic should be denied
@@ -593,7 +672,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 		WithExpectations(func(a *check.Action) (egress, ingress check.Result) {
 			if a.Source().HasLabel("kind", "client") &&
 				a.Destination().HasLabel("kind", "echo") &&
-				a.Destination().Port() == 8080 {
+				a.Destination().Port() == echoPort {
 				return check.ResultPolicyDenyEgressDrop, check.ResultNone
 			}
 			return check.ResultOK, check.ResultNone
@@ -603,7 +682,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 	ct.NewTest("client-ingress-to-echo-named-port-deny").
 		WithCiliumPolicy(allowAllEgressPolicyYAML).  // Allow all egress traffic
 		WithCiliumPolicy(allowAllIngressPolicyYAML). // Allow all ingress traffic
-		WithCiliumPolicy(clientEgressToEchoDenyNamedPortPolicyYAML).
+		WithCiliumPolicy(renderedTemplates["clientEgressToEchoDenyNamedPortPolicyYAML"]).
 		WithScenarios(
 			tests.PodToPod(tests.WithSourceLabelsOption(clientLabel)),  // Client to echo should be denied
 			tests.PodToPod(tests.WithSourceLabelsOption(client2Label)), // Client2 to echo should be allowed
@@ -620,7 +699,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 	ct.NewTest("client-egress-to-echo-expression-deny").
 		WithCiliumPolicy(allowAllEgressPolicyYAML).  // Allow all egress traffic
 		WithCiliumPolicy(allowAllIngressPolicyYAML). // Allow all ingress traffic
-		WithCiliumPolicy(clientEgressToEchoExpressionDenyPolicyYAML).
+		WithCiliumPolicy(renderedTemplates["clientEgressToEchoExpressionDenyPolicyYAML"]).
 		WithScenarios(
 			tests.PodToPod(tests.WithSourceLabelsOption(clientLabel)),  // Client to echo should be denied
 			tests.PodToPod(tests.WithSourceLabelsOption(client2Label)), // Client2 to echo should be allowed
@@ -637,7 +716,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 	ct.NewTest("client-with-service-account-egress-to-echo-deny").
 		WithCiliumPolicy(allowAllEgressPolicyYAML).  // Allow all egress traffic
 		WithCiliumPolicy(allowAllIngressPolicyYAML). // Allow all ingress traffic
-		WithCiliumPolicy(clientWithServiceAccountEgressToEchoDenyPolicyYAML).
+		WithCiliumPolicy(renderedTemplates["clientWithServiceAccountEgressToEchoDenyPolicyYAML"]).
 		WithScenarios(
 			tests.PodToPod(tests.WithSourceLabelsOption(map[string]string{"name": "client"})),  // Client to echo should be denied
 			tests.PodToPod(tests.WithSourceLabelsOption(map[string]string{"name": "client2"})), // Client2 to echo should be allowed
@@ -654,7 +733,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 	ct.NewTest("client-egress-to-echo-service-account-deny").
 		WithCiliumPolicy(allowAllEgressPolicyYAML).  // Allow all egress traffic
 		WithCiliumPolicy(allowAllIngressPolicyYAML). // Allow all ingress traffic
-		WithCiliumPolicy(clientEgressToEchoServiceAccountDenyPolicyYAML).
+		WithCiliumPolicy(renderedTemplates["clientEgressToEchoServiceAccountDenyPolicyYAML"]).
 		WithScenarios(
 			tests.PodToPod(tests.WithSourceLabelsOption(map[string]string{"name": "client"})),
 		).
@@ -709,7 +788,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 	// Test L7 HTTP introspection using an ingress policy on echo pods.
 	ct.NewTest("echo-ingress-l7").
 		WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureL7Proxy)).
-		WithCiliumPolicy(echoIngressL7HTTPPolicyYAML). // L7 allow policy with HTTP introspection
+		WithCiliumPolicy(renderedTemplates["echoIngressL7HTTPPolicyYAML"]). // L7 allow policy with HTTP introspection
 		WithScenarios(
 			tests.PodToPodWithEndpoints(),
 		).
@@ -733,7 +812,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 	// Test L7 HTTP introspection using an ingress policy on echo pods.
 	ct.NewTest("echo-ingress-l7-named-port").
 		WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureL7Proxy)).
-		WithCiliumPolicy(echoIngressL7HTTPNamedPortPolicyYAML). // L7 allow policy with HTTP introspection (named port)
+		WithCiliumPolicy(renderedTemplates["echoIngressL7HTTPNamedPortPolicyYAML"]). // L7 allow policy with HTTP introspection (named port)
 		WithScenarios(
 			tests.PodToPodWithEndpoints(),
 		).
@@ -757,15 +836,15 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 	// Test L7 HTTP with different methods introspection using an egress policy on the clients.
 	ct.NewTest("client-egress-l7-method").
 		WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureL7Proxy)).
-		WithCiliumPolicy(clientEgressOnlyDNSPolicyYAML).      // DNS resolution only
-		WithCiliumPolicy(clientEgressL7HTTPMethodPolicyYAML). // L7 allow policy with HTTP introspection (POST only)
+		WithCiliumPolicy(clientEgressOnlyDNSPolicyYAML).                           // DNS resolution only
+		WithCiliumPolicy(renderedTemplates["clientEgressL7HTTPMethodPolicyYAML"]). // L7 allow policy with HTTP introspection (POST only)
 		WithScenarios(
 			tests.PodToPodWithEndpoints(tests.WithMethod("POST"), tests.WithDestinationLabelsOption(map[string]string{"other": "echo"})),
 			tests.PodToPodWithEndpoints(tests.WithDestinationLabelsOption(map[string]string{"first": "echo"})),
 		).
 		WithExpectations(func(a *check.Action) (egress, ingress check.Result) {
 			if a.Source().HasLabel("other", "client") && // Only client2 is allowed to make HTTP calls.
-				(a.Destination().Port() == 8080) { // port 8080 is traffic to echo Pod.
+				(a.Destination().Port() == echoPort) { // the echo port is traffic to echo Pod.
 				if a.Destination().HasLabel("other", "echo") { //we are POSTing only other echo
 					egress = check.ResultOK
 
@@ -793,7 +872,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 			if a.Source().HasLabel("other", "client") && // Only client2 is allowed to make HTTP calls.
 				// Outbound HTTP to set domain-name defaults to one.one.one.one is L7-introspected and allowed.
 				(a.Destination().Port() == 80 && a.Destination().Address(check.GetIPFamily(ct.Params().ExternalTarget)) == ct.Params().ExternalTarget ||
-					a.Destination().Port() == 8080) { // 8080 is traffic to echo Pod.
+					a.Destination().Port() == echoPort) { // the echo port is traffic to echo Pod.
 				if a.Destination().Path() == "/" || a.Destination().Path() == "" {
 					egress = check.ResultOK
 					// Expect all curls from client2 to be proxied and to be GET calls.
@@ -808,6 +887,14 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 			return check.ResultDefaultDenyEgressDrop, check.ResultNone
 		})
 
+	// Test that non-200 responses are passed through the L7 proxy unmodified.
+	if ct.Params().EchoStatusCode != 0 {
+		ct.NewTest("client-egress-l7-http-status").
+			WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureL7Proxy)).
+			WithCiliumPolicy(clientEgressL7HTTPStatusPolicyYAML).
+			WithScenarios(tests.PodToEchoStatus())
+	}
+
 	// Test L7 HTTP named port introspection using an egress policy on the clients.
 	ct.NewTest("client-egress-l7-named-port").
 		WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureL7Proxy)).
@@ -821,7 +908,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 			if a.Source().HasLabel("other", "client") && // Only client2 is allowed to make HTTP calls.
 				// Outbound HTTP to domain-name, default one.one.one.one, is L7-introspected and allowed.
 				(a.Destination().Port() == 80 && a.Destination().Address(check.GetIPFamily(ct.Params().ExternalTarget)) == ct.Params().ExternalTarget ||
-					a.Destination().Port() == 8080) { // named port http-8080 is traffic to echo Pod.
+					a.Destination().Port() == echoPort) { // the named echo port is traffic to echo Pod.
 				if a.Destination().Path() == "/" || a.Destination().Path() == "" {
 					egress = check.ResultOK
 					// Expect all curls from client2 to be proxied and to be GET calls.
@@ -884,14 +971,14 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 		).
 		WithExpectations(func(a *check.Action) (egress, ingress check.Result) {
 			if a.Source().HasLabel("other", "client") && // Only client2 has the header policy.
-				(a.Destination().Port() == 8080) { // port 8080 is traffic to echo Pod.
+				(a.Destination().Port() == echoPort) { // the echo port is traffic to echo Pod.
 				return check.ResultOK, check.ResultNone
 			}
 			return check.ResultCurlHTTPError, check.ResultNone // if the header is not set the request will get a 401
 		})
 
 	// Test mTLS auth with always-fail
-	ct.NewTest("echo-ingress-auth-always-fail").WithCiliumPolicy(echoIngressAuthFailPolicyYAML).
+	ct.NewTest("echo-ingress-auth-always-fail").WithCiliumPolicy(renderedTemplates["echoIngressAuthFailPolicyYAML"]).
 		// this test is only useful when auth is supported in the Cilium version and it is enabled
 		// currently this is tested my mtls-spiffe as that is the only functional auth method
 		WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureAuthMTLSSpiffe)).
@@ -904,7 +991,7 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 		})
 
 	// Test mTLS auth with SPIFFE
-	ct.NewTest("echo-ingress-auth-mtls-spiffe").WithCiliumPolicy(echoIngressMTLSPolicyYAML).
+	ct.NewTest("echo-ingress-auth-mtls-spiffe").WithCiliumPolicy(renderedTemplates["echoIngressMTLSPolicyYAML"]).
 		WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureAuthMTLSSpiffe)).
 		WithScenarios(
 			tests.PodToPod(),
@@ -986,8 +1073,23 @@ func Run(ctx context.Context, ct *check.ConnectivityTest) error {
 			return check.ResultDNSOKDropCurlTimeout, check.ResultNone
 		})
 
+	// Only allow HTTPS to the external FQDN, if one is configured.
+	if ct.Params().ExternalFQDN != "" {
+		ct.NewTest("to-external-fqdn").WithCiliumPolicy(renderedTemplates["clientEgressToFQDNsExternalPolicyYAML"]).
+			WithFeatureRequirements(check.RequireFeatureEnabled(check.FeatureL7Proxy)).
+			WithScenarios(tests.PodToExternalFQDN())
+	}
+
 	// Tests with DNS redirects to the proxy (e.g., client-egress-l7, dns-only,
 	// and to-fqdns) should always be executed last. See #367 for details.
 
+	return deployAndRun(ctx, ct)
+}
+
+// deployAndRun deploys the workloads needed by the registered tests and runs them.
+func deployAndRun(ctx context.Context, ct *check.ConnectivityTest) error {
+	if err := ct.DeployAndValidate(ctx); err != nil {
+		return err
+	}
 	return ct.Run(ctx)
 }
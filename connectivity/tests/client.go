@@ -23,6 +23,10 @@ func (s *clientToClient) Name() string {
 	return "client-to-client"
 }
 
+func (s *clientToClient) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
 func (s *clientToClient) Run(ctx context.Context, t *check.Test) {
 	var i int
 	ct := t.Context()
@@ -57,3 +61,67 @@ func (s *clientToClient) Run(ctx context.Context, t *check.Test) {
 		}
 	}
 }
+
+// ClientToClientSecondaryNetwork sends an ICMP packet from each client Pod to
+// each other client Pod over the additional networks the client Pods were
+// attached to by Multus, from the interface of the same network.
+func ClientToClientSecondaryNetwork() check.Scenario {
+	return &clientToClientSecondaryNetwork{}
+}
+
+// clientToClientSecondaryNetwork implements a Scenario.
+type clientToClientSecondaryNetwork struct{}
+
+func (s *clientToClientSecondaryNetwork) Name() string {
+	return "client-to-client-secondary-network"
+}
+
+func (s *clientToClientSecondaryNetwork) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
+func (s *clientToClientSecondaryNetwork) Run(ctx context.Context, t *check.Test) {
+	var i int
+	ct := t.Context()
+
+	for _, src := range ct.ClientPods() {
+		src := src // copy to avoid memory aliasing when using reference
+		srcNetworks, err := src.SecondaryNetworks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(srcNetworks) == 0 {
+			t.Fatalf("client pod %s isn't attached to any additional network", src.Name())
+		}
+
+		for _, dst := range ct.ClientPods() {
+			if src.Name() == dst.Name() {
+				continue
+			}
+			dstNetworks, err := dst.SecondaryNetworks()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, srcNetwork := range srcNetworks {
+				for _, dstNetwork := range dstNetworks {
+					if dstNetwork.Name != srcNetwork.Name {
+						continue
+					}
+					for _, ip := range dstNetwork.IPs {
+						ipFam := check.GetIPFamily(ip)
+						peer := check.ICMPEndpoint(fmt.Sprintf("%s-%s", dst.Name(), dstNetwork.Interface), ip)
+						t.NewAction(s, fmt.Sprintf("ping-%s-%d", ipFam, i), &src, peer, ipFam).Run(func(a *check.Action) {
+							cmd := ct.PingCommand(peer, ipFam)
+							// Ping from the interface of the network, so
+							// that the packets don't take the default route.
+							cmd = append(cmd[:len(cmd)-1:len(cmd)-1], "-I", srcNetwork.Interface, cmd[len(cmd)-1])
+							a.ExecInPod(ctx, cmd)
+						})
+						i++
+					}
+				}
+			}
+		}
+	}
+}
@@ -36,6 +36,10 @@ func (s *egressGateway) Name() string {
 	return "egress-gateway"
 }
 
+func (s *egressGateway) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployNodeWithoutCilium}
+}
+
 func (s *egressGateway) Run(ctx context.Context, t *check.Test) {
 	ct := t.Context()
 
@@ -66,6 +70,53 @@ func (s *egressGateway) Run(ctx context.Context, t *check.Test) {
 	}
 }
 
+// EgressIP sends a request from each client Pod to the echo server on the node
+// without Cilium, and checks that the source IP it observed is the configured
+// ExpectedEgressIP.
+func EgressIP() check.Scenario {
+	return &egressIP{}
+}
+
+// egressIP implements a Scenario.
+type egressIP struct{}
+
+func (s *egressIP) Name() string {
+	return "egress-ip"
+}
+
+func (s *egressIP) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployNodeWithoutCilium}
+}
+
+func (s *egressIP) Run(ctx context.Context, t *check.Test) {
+	ct := t.Context()
+	expected := net.ParseIP(ct.Params().ExpectedEgressIP)
+	family := check.GetIPFamily(ct.Params().ExpectedEgressIP)
+
+	i := 0
+	for _, client := range ct.ClientPods() {
+		client := client
+
+		for _, externalEcho := range ct.ExternalEchoPods() {
+			t.NewAction(s, fmt.Sprintf("curl-%d", i), &client, externalEcho, family).Run(func(a *check.Action) {
+				a.ExecInPod(ctx, ct.CurlClientIPCommand(externalEcho, family))
+
+				var res struct {
+					ClientIP string `json:"client-ip"`
+				}
+				if err := json.Unmarshal([]byte(a.CmdOutput()), &res); err != nil {
+					a.Failf("unable to parse echo server response %q: %s", a.CmdOutput(), err)
+					return
+				}
+				if observed := net.ParseIP(res.ClientIP); !observed.Equal(expected) {
+					a.Failf("request reached the echo server with source IP %s, expected %s", res.ClientIP, expected)
+				}
+			})
+			i++
+		}
+	}
+}
+
 // getGatewayNodeInternalIP returns the k8s internal IP of the node acting as
 // gateway for this test
 func (s *egressGateway) getGatewayNodeInternalIP(ct *check.ConnectivityTest) net.IP {
@@ -62,6 +62,10 @@ func (s *podToPodEncryption) Name() string {
 	return "pod-to-pod-encryption"
 }
 
+func (s *podToPodEncryption) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode, check.DeployNodeWithoutCilium}
+}
+
 func (s *podToPodEncryption) Run(ctx context.Context, t *check.Test) {
 	ct := t.Context()
 	client := ct.RandomClientPod()
@@ -224,6 +228,10 @@ func (s *nodeToNodeEncryption) Name() string {
 	return "node-to-node-encryption"
 }
 
+func (s *nodeToNodeEncryption) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode, check.DeployNodeWithoutCilium}
+}
+
 func (s *nodeToNodeEncryption) Run(ctx context.Context, t *check.Test) {
 	client := t.Context().RandomClientPod()
 
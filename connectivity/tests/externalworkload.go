@@ -20,6 +20,10 @@ func (s *podToExternalWorkload) Name() string {
 	return "pod-to-external-workload"
 }
 
+func (s *podToExternalWorkload) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
 func (s *podToExternalWorkload) Run(ctx context.Context, t *check.Test) {
 	var i int
 	ct := t.Context()
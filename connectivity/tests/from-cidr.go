@@ -24,6 +24,10 @@ func (f *fromCIDRToPod) Name() string {
 	return "from-cidr-to-pod"
 }
 
+func (f *fromCIDRToPod) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode, check.DeployNodeWithoutCilium}
+}
+
 func (f *fromCIDRToPod) Run(ctx context.Context, t *check.Test) {
 	clientPod := t.Context().HostNetNSPodsByNode()[t.NodesWithoutCilium()[0]]
 	i := 0
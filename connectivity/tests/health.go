@@ -27,6 +27,10 @@ func (s *ciliumHealth) Name() string {
 	return "cilium-health"
 }
 
+func (s *ciliumHealth) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
 func (s *ciliumHealth) Run(ctx context.Context, t *check.Test) {
 	for name, pod := range t.Context().CiliumPods() {
 		pod := pod
@@ -23,6 +23,10 @@ func (s *podToHost) Name() string {
 	return "pod-to-host"
 }
 
+func (s *podToHost) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
 func (s *podToHost) Run(ctx context.Context, t *check.Test) {
 	ct := t.Context()
 	// Construct a unique list of all nodes in the cluster running workloads.
@@ -72,6 +76,10 @@ func (s *podToHostPort) Name() string {
 	return "pod-to-hostport"
 }
 
+func (s *podToHostPort) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode}
+}
+
 func (s *podToHostPort) Requirements() []check.FeatureRequirement {
 	return []check.FeatureRequirement{
 		check.RequireFeatureEnabled(check.FeatureHostPort),
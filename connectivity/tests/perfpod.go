@@ -39,6 +39,10 @@ func (s *netPerfPodtoPod) Name() string {
 	return fmt.Sprintf("%s:%s", tn, s.name)
 }
 
+func (s *netPerfPodtoPod) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
 func (s *netPerfPodtoPod) Run(ctx context.Context, t *check.Test) {
 	samples := t.Context().Params().PerfSamples
 	duration := t.Context().Params().PerfDuration
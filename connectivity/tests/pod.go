@@ -6,6 +6,9 @@ package tests
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 
 	"github.com/cilium/cilium-cli/connectivity/check"
 )
@@ -36,6 +39,10 @@ func (s *podToPod) Name() string {
 	return "pod-to-pod"
 }
 
+func (s *podToPod) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode}
+}
+
 func (s *podToPod) Run(ctx context.Context, t *check.Test) {
 	var i int
 	ct := t.Context()
@@ -67,6 +74,220 @@ func (s *podToPod) Run(ctx context.Context, t *check.Test) {
 	}
 }
 
+// PodToPodIPFamily generates one HTTP request per IP family from each client
+// pod to each echo pod, addressing the echo pod explicitly by its IPv4 and
+// IPv6 address, and checks that the request was sent from the client address
+// of the same family. Families for which either pod has no address are
+// skipped. Cross-family requests are not covered, as they require NAT46/64.
+func PodToPodIPFamily() check.Scenario {
+	return &podToPodIPFamily{}
+}
+
+// podToPodIPFamily implements a Scenario.
+type podToPodIPFamily struct{}
+
+func (s *podToPodIPFamily) Name() string {
+	return "pod-to-pod-ip-family"
+}
+
+func (s *podToPodIPFamily) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode}
+}
+
+func (s *podToPodIPFamily) Run(ctx context.Context, t *check.Test) {
+	var i int
+	ct := t.Context()
+
+	for _, client := range ct.ClientPods() {
+		client := client // copy to avoid memory aliasing when using reference
+		for _, echo := range ct.EchoPods() {
+			for _, ipFam := range []check.IPFamily{check.IPFamilyV4, check.IPFamilyV6} {
+				src, dst := client.Address(ipFam), echo.Address(ipFam)
+				if src == "" || dst == "" {
+					t.Debugf("Skipping %s from %s to %s, no %s address", s.Name(), client.Name(), echo.Name(), ipFam)
+					continue
+				}
+
+				t.NewAction(s, fmt.Sprintf("curl-%s-%d", ipFam, i), &client, echo, ipFam).Run(func(a *check.Action) {
+					a.ExecInPod(ctx, ct.CurlCommand(echo, ipFam))
+
+					if !strings.HasPrefix(a.CmdOutput(), src+":") {
+						a.Failf("request to %s was not sent from %s address %s: %s", dst, ipFam, src, a.CmdOutput())
+					}
+				})
+			}
+
+			i++
+		}
+	}
+}
+
+// PodToPodProbes sends --probe-count HTTP requests from each client pod to
+// each echo pod, and fails if the ratio of successful requests is below
+// --probe-success-ratio. Unlike single-shot probes, this catches datapaths
+// which drop a fraction of the traffic.
+func PodToPodProbes() check.Scenario {
+	return &podToPodProbes{}
+}
+
+// podToPodProbes implements a Scenario.
+type podToPodProbes struct{}
+
+func (s *podToPodProbes) Name() string {
+	return "pod-to-pod-probes"
+}
+
+func (s *podToPodProbes) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode}
+}
+
+func (s *podToPodProbes) Run(ctx context.Context, t *check.Test) {
+	var i int
+	ct := t.Context()
+	count := ct.Params().ProbeCount
+
+	for _, client := range ct.ClientPods() {
+		client := client // copy to avoid memory aliasing when using reference
+		for _, echo := range ct.EchoPods() {
+			t.ForEachIPFamily(func(ipFam check.IPFamily) {
+				t.NewAction(s, fmt.Sprintf("curl-%s-%d", ipFam, i), &client, echo, ipFam).Run(func(a *check.Action) {
+					// Run all requests in a single exec, counting the successful
+					// ones, as a failing exec would fail the whole action.
+					a.ExecInPod(ctx, ct.ClientShellCommand(fmt.Sprintf(
+						"ok=0; i=0; while [ $i -lt %d ]; do i=$((i+1)); %s >/dev/null 2>&1 && ok=$((ok+1)); done; echo $ok",
+						count, shellJoin(ct.CurlCommand(echo, ipFam)))))
+
+					ok, err := strconv.Atoi(strings.TrimSpace(a.CmdOutput()))
+					if err != nil {
+						a.Fatalf("unable to parse number of successful requests %q: %s", a.CmdOutput(), err)
+					}
+					ratio := float64(ok) / float64(count)
+					if ratio < ct.Params().ProbeSuccessRatio {
+						a.Failf("%d/%d requests to %s succeeded (%.2f), below the expected ratio of %.2f",
+							ok, count, echo.Name(), ratio, ct.Params().ProbeSuccessRatio)
+					} else {
+						a.Debugf("%d/%d requests to %s succeeded", ok, count, echo.Name())
+					}
+				})
+			})
+
+			i++
+		}
+	}
+}
+
+// PodToPodICMP sends --ping-count ICMP echo requests from each client pod to
+// each echo pod, and fails if more than --ping-max-loss percent of them are
+// lost. Client pods which aren't granted NET_RAW are skipped.
+func PodToPodICMP() check.Scenario {
+	return &podToPodICMP{}
+}
+
+// podToPodICMP implements a Scenario.
+type podToPodICMP struct{}
+
+func (s *podToPodICMP) Name() string {
+	return "pod-to-pod-icmp"
+}
+
+func (s *podToPodICMP) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode}
+}
+
+func (s *podToPodICMP) Run(ctx context.Context, t *check.Test) {
+	var i int
+	ct := t.Context()
+	count := ct.Params().PingCount
+
+	for _, client := range ct.ClientPods() {
+		client := client // copy to avoid memory aliasing when using reference
+		if !client.HasNetRaw() {
+			t.Debugf("Skipping %s from %s, it isn't granted NET_RAW", s.Name(), client.Name())
+			continue
+		}
+		for _, echo := range ct.EchoPods() {
+			t.ForEachIPFamily(func(ipFam check.IPFamily) {
+				t.NewAction(s, fmt.Sprintf("ping-%s-%d", ipFam, i), &client, echo, ipFam).Run(func(a *check.Action) {
+					// ping fails if any request is lost, ignore its exit code
+					// to compare the loss against the threshold instead.
+					a.ExecInPod(ctx, ct.ClientShellCommand(shellJoin(ct.PingCountCommand(echo, ipFam, count))+" || true"))
+
+					loss, err := check.ParsePingLoss(a.CmdOutput())
+					if err != nil {
+						a.Fatal(err)
+					}
+					if loss > ct.Params().PingMaxLoss {
+						a.Failf("%v%% of the %d ICMP echo requests to %s were lost, above the maximum of %v%%",
+							loss, count, echo.Name(), ct.Params().PingMaxLoss)
+					} else {
+						a.Debugf("%v%% of the %d ICMP echo requests to %s were lost", loss, count, echo.Name())
+					}
+
+					a.ValidateFlows(ctx, client, a.GetEgressRequirements(check.FlowParameters{
+						Protocol: check.ICMP,
+					}))
+					a.ValidateFlows(ctx, echo, a.GetIngressRequirements(check.FlowParameters{
+						Protocol: check.ICMP,
+					}))
+				})
+			})
+
+			i++
+		}
+	}
+}
+
+// shellJoin quotes each argument for a POSIX shell and joins them.
+func shellJoin(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
+
+// PodToEchoStatus sends an HTTP request from each client pod to the status
+// server of each echo pod, and checks that the client receives the status
+// code configured with --echo-status-code.
+func PodToEchoStatus() check.Scenario {
+	return &podToEchoStatus{}
+}
+
+// podToEchoStatus implements a Scenario.
+type podToEchoStatus struct{}
+
+func (s *podToEchoStatus) Name() string {
+	return "pod-to-echo-status"
+}
+
+func (s *podToEchoStatus) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode}
+}
+
+func (s *podToEchoStatus) Run(ctx context.Context, t *check.Test) {
+	var i int
+	ct := t.Context()
+	want := strconv.Itoa(ct.Params().EchoStatusCode)
+
+	for _, client := range ct.ClientPods() {
+		client := client // copy to avoid memory aliasing when using reference
+		for _, echo := range ct.EchoPods() {
+			url := "http://" + net.JoinHostPort(echo.Address(check.IPFamilyAny), strconv.Itoa(check.EchoStatusPort)) + "/"
+			ep := check.HTTPEndpoint(echo.Name()+"-status", url)
+
+			t.NewAction(s, fmt.Sprintf("curl-%d", i), &client, ep, check.IPFamilyAny).Run(func(a *check.Action) {
+				a.ExecInPod(ctx, ct.CurlStatusCommand(ep, check.IPFamilyAny))
+
+				if got := strings.TrimSpace(a.CmdOutput()); got != want {
+					a.Failf("expected HTTP status %s from %s, got %s", want, echo.Name(), got)
+				}
+			})
+
+			i++
+		}
+	}
+}
+
 func PodToPodWithEndpoints(opts ...Option) check.Scenario {
 	options := &labelsOption{}
 	for _, opt := range opts {
@@ -92,6 +313,10 @@ func (s *podToPodWithEndpoints) Name() string {
 	return "pod-to-pod-with-endpoints"
 }
 
+func (s *podToPodWithEndpoints) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode}
+}
+
 func (s *podToPodWithEndpoints) Run(ctx context.Context, t *check.Test) {
 	var i int
 	ct := t.Context()
@@ -6,14 +6,17 @@ package tests
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/cilium/cilium-cli/connectivity/check"
 )
 
-// PodToService sends an HTTP request from all client Pods
-// to all Services in the test context.
+// PodToService sends an HTTP request from all client Pods, or from the host
+// netns Pods with --client-source=host-netns, to all Services in the test
+// context.
 func PodToService(opts ...Option) check.Scenario {
 	options := &labelsOption{}
 	for _, opt := range opts {
@@ -35,11 +38,15 @@ func (s *podToService) Name() string {
 	return "pod-to-service"
 }
 
+func (s *podToService) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode}
+}
+
 func (s *podToService) Run(ctx context.Context, t *check.Test) {
 	var i int
 	ct := t.Context()
 
-	for _, pod := range ct.ClientPods() {
+	for _, pod := range ct.SourcePods() {
 		pod := pod // copy to avoid memory aliasing when using reference
 		if !hasAllLabels(pod, s.sourceLabels) {
 			continue
@@ -52,6 +59,278 @@ func (s *podToService) Run(ctx context.Context, t *check.Test) {
 			t.NewAction(s, fmt.Sprintf("curl-%d", i), &pod, svc, check.IPFamilyAny).Run(func(a *check.Action) {
 				a.ExecInPod(ctx, ct.CurlCommand(svc, check.IPFamilyAny))
 
+				// Requests from the host network namespace are not sent
+				// from a Cilium endpoint, so there are no pod flows to
+				// validate.
+				if pod.Pod.Spec.HostNetwork {
+					return
+				}
+				a.ValidateFlows(ctx, pod, a.GetEgressRequirements(check.FlowParameters{
+					DNSRequired: true,
+					AltDstPort:  svc.Port(),
+				}))
+			})
+
+			i++
+		}
+	}
+}
+
+// PodToExternalNameService resolves all ExternalName services in the test
+// context from all client Pods and checks that they point to the external host.
+func PodToExternalNameService() check.Scenario {
+	return &podToExternalNameService{}
+}
+
+// podToExternalNameService implements a Scenario.
+type podToExternalNameService struct{}
+
+func (s *podToExternalNameService) Name() string {
+	return "pod-to-external-name-service"
+}
+
+func (s *podToExternalNameService) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployExternalNameService}
+}
+
+func (s *podToExternalNameService) Run(ctx context.Context, t *check.Test) {
+	var i int
+	ct := t.Context()
+
+	for _, pod := range ct.ClientPods() {
+		pod := pod // copy to avoid memory aliasing when using reference
+		for _, svc := range ct.ExternalNameServices() {
+			t.NewAction(s, fmt.Sprintf("nslookup-%d", i), &pod, svc, check.IPFamilyAny).Run(func(a *check.Action) {
+				a.ExecInPod(ctx, []string{"nslookup", svc.Address(check.IPFamilyAny)})
+
+				externalName := svc.Service.Spec.ExternalName
+				if !strings.Contains(a.CmdOutput(), externalName) {
+					a.Failf("service %s does not resolve to %s: %s", svc.Name(), externalName, a.CmdOutput())
+				}
+			})
+
+			i++
+		}
+	}
+}
+
+// PodToKubernetesAPIService opens a TCP connection from all client Pods to
+// the ClusterIP of the kubernetes Service, to check that the API server is
+// reachable and not only resolvable.
+func PodToKubernetesAPIService() check.Scenario {
+	return &podToKubernetesAPIService{}
+}
+
+// podToKubernetesAPIService implements a Scenario.
+type podToKubernetesAPIService struct{}
+
+func (s *podToKubernetesAPIService) Name() string {
+	return "pod-to-kubernetes-api-service"
+}
+
+func (s *podToKubernetesAPIService) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
+func (s *podToKubernetesAPIService) Run(ctx context.Context, t *check.Test) {
+	var i int
+	ct := t.Context()
+
+	for _, pod := range ct.ClientPods() {
+		pod := pod // copy to avoid memory aliasing when using reference
+		for _, svc := range ct.KubernetesAPIServices() {
+			clusterIP := svc.Service.Spec.ClusterIP
+			port := strconv.FormatUint(uint64(svc.Port()), 10)
+			t.NewAction(s, fmt.Sprintf("nc-%d", i), &pod, svc, check.IPFamilyAny).Run(func(a *check.Action) {
+				a.ExecInPod(ctx, []string{"nc", "-w", "3", "-z", clusterIP, port})
+			})
+
+			i++
+		}
+	}
+}
+
+// echoLBRequests is the number of connections opened to the echo
+// load-balancing service by PodToEchoLBService.
+const echoLBRequests = 100
+
+// PodToEchoLBService opens many connections from all client Pods to the
+// Service fronting all echo Pods, and checks using the echo connection counter
+// that each echo Pod accepted a fair share of them.
+func PodToEchoLBService() check.Scenario {
+	return &podToEchoLBService{}
+}
+
+// podToEchoLBService implements a Scenario.
+type podToEchoLBService struct{}
+
+func (s *podToEchoLBService) Name() string {
+	return "pod-to-echo-lb-service"
+}
+
+func (s *podToEchoLBService) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode, check.DeployEchoLBService}
+}
+
+func (s *podToEchoLBService) Run(ctx context.Context, t *check.Test) {
+	var i int
+	ct := t.Context()
+
+	connectionCounts := func(a *check.Action) map[string]uint64 {
+		counts := make(map[string]uint64)
+		for name, echo := range ct.EchoPods() {
+			n, err := ct.EchoConnectionCount(ctx, echo)
+			if err != nil {
+				a.Fatal(err)
+			}
+			counts[name] = n
+		}
+		return counts
+	}
+
+	for _, pod := range ct.ClientPods() {
+		pod := pod // copy to avoid memory aliasing when using reference
+		for _, svc := range ct.EchoLBServices() {
+			t.NewAction(s, fmt.Sprintf("curl-%d", i), &pod, svc, check.IPFamilyAny).Run(func(a *check.Action) {
+				before := connectionCounts(a)
+
+				// Close the connection after each request so that each of them
+				// is load-balanced, and use URL globbing to issue them all from
+				// a single curl invocation.
+				cmd := ct.CurlCommand(svc, check.IPFamilyAny, "-H", "Connection: close")
+				cmd[len(cmd)-1] += fmt.Sprintf("/?request=[1-%d]", echoLBRequests)
+				a.ExecInPod(ctx, cmd)
+
+				// Backends are selected at random or by hash, so only require
+				// a fair fraction of the connections on each of them.
+				for name, n := range connectionCounts(a) {
+					if accepted := n - before[name]; accepted < echoLBRequests/5 {
+						a.Failf("echo pod %s accepted %d connections, expected at least %d of %d", name, accepted, echoLBRequests/5, echoLBRequests)
+					}
+				}
+			})
+
+			i++
+		}
+	}
+}
+
+// echoTopologyRequests is the number of connections opened to the echo
+// topology service by PodToEchoTopologyService.
+const echoTopologyRequests = 100
+
+// PodToEchoTopologyService opens many connections from all client Pods to the
+// Service with topology-aware hints fronting an echo Pod in each zone, and
+// checks using the echo connection counter that the echo Pods in the zone of
+// the client accepted them.
+func PodToEchoTopologyService() check.Scenario {
+	return &podToEchoTopologyService{}
+}
+
+// podToEchoTopologyService implements a Scenario.
+type podToEchoTopologyService struct{}
+
+func (s *podToEchoTopologyService) Name() string {
+	return "pod-to-echo-topology-service"
+}
+
+func (s *podToEchoTopologyService) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoTopology}
+}
+
+func (s *podToEchoTopologyService) Run(ctx context.Context, t *check.Test) {
+	var i int
+	ct := t.Context()
+
+	zoneOf := func(pod check.Pod) string {
+		if node, ok := ct.Nodes()[pod.Pod.Spec.NodeName]; ok {
+			return node.Labels[corev1.LabelTopologyZone]
+		}
+		return ""
+	}
+
+	connectionCounts := func(a *check.Action) map[string]uint64 {
+		counts := make(map[string]uint64)
+		for name, echo := range ct.EchoTopologyPods() {
+			n, err := ct.EchoConnectionCount(ctx, echo)
+			if err != nil {
+				a.Fatal(err)
+			}
+			counts[name] = n
+		}
+		return counts
+	}
+
+	for _, pod := range ct.ClientPods() {
+		pod := pod // copy to avoid memory aliasing when using reference
+		zone := zoneOf(pod)
+		for _, svc := range ct.EchoTopologyServices() {
+			t.NewAction(s, fmt.Sprintf("curl-%d", i), &pod, svc, check.IPFamilyAny).Run(func(a *check.Action) {
+				if zone == "" {
+					a.Fatalf("node %s of client pod %s has no zone", pod.Pod.Spec.NodeName, pod.Name())
+				}
+				before := connectionCounts(a)
+
+				cmd := ct.CurlCommand(svc, check.IPFamilyAny, "-H", "Connection: close")
+				cmd[len(cmd)-1] += fmt.Sprintf("/?request=[1-%d]", echoTopologyRequests)
+				a.ExecInPod(ctx, cmd)
+
+				var local, remote uint64
+				for name, n := range connectionCounts(a) {
+					if zoneOf(ct.EchoTopologyPods()[name]) == zone {
+						local += n - before[name]
+					} else {
+						remote += n - before[name]
+					}
+				}
+				// The counters also include the connections of the readiness
+				// probes, so tolerate a few of them in the other zones.
+				if local < echoTopologyRequests || remote >= echoTopologyRequests/5 {
+					a.Failf("echo pods in zone %s accepted %d connections and in other zones %d, expected all %d in zone %s",
+						zone, local, remote, echoTopologyRequests, zone)
+				}
+			})
+
+			i++
+		}
+	}
+}
+
+// echoTLSCAPath is where PodToEchoTLSService writes the CA of the echo-tls
+// certificate in the client Pods.
+const echoTLSCAPath = "/tmp/echo-tls-ca.crt"
+
+// PodToEchoTLSService sends an HTTPS request from all client Pods to the
+// Service fronting the echo Pod serving HTTPS, verifying its certificate
+// against the CA which issued it.
+func PodToEchoTLSService() check.Scenario {
+	return &podToEchoTLSService{}
+}
+
+// podToEchoTLSService implements a Scenario.
+type podToEchoTLSService struct{}
+
+func (s *podToEchoTLSService) Name() string {
+	return "pod-to-echo-tls-service"
+}
+
+func (s *podToEchoTLSService) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoTLS}
+}
+
+func (s *podToEchoTLSService) Run(ctx context.Context, t *check.Test) {
+	var i int
+	ct := t.Context()
+
+	for _, pod := range ct.ClientPods() {
+		pod := pod // copy to avoid memory aliasing when using reference
+		for _, svc := range ct.EchoTLSServices() {
+			https := check.HTTPEndpointWithLabels(svc.Name()+"-https",
+				fmt.Sprintf("https://%s:%d", ct.ServiceFQDN(svc), svc.Port()), svc.Labels())
+
+			t.NewAction(s, fmt.Sprintf("curl-%d", i), &pod, https, check.IPFamilyAny).Run(func(a *check.Action) {
+				a.WriteDataToPod(ctx, echoTLSCAPath, ct.EchoTLSCA())
+				a.ExecInPod(ctx, ct.CurlCommand(https, check.IPFamilyAny, "--cacert", echoTLSCAPath))
 				a.ValidateFlows(ctx, pod, a.GetEgressRequirements(check.FlowParameters{
 					DNSRequired: true,
 					AltDstPort:  svc.Port(),
@@ -86,6 +365,10 @@ func (s *podToIngress) Name() string {
 	return "pod-to-ingress-service"
 }
 
+func (s *podToIngress) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployIngress}
+}
+
 func (s *podToIngress) Run(ctx context.Context, t *check.Test) {
 	var i int
 	ct := t.Context()
@@ -126,6 +409,10 @@ func (s *podToRemoteNodePort) Name() string {
 	return "pod-to-remote-nodeport"
 }
 
+func (s *podToRemoteNodePort) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode}
+}
+
 func (s *podToRemoteNodePort) Run(ctx context.Context, t *check.Test) {
 	var i int
 
@@ -170,6 +457,10 @@ func (s *podToLocalNodePort) Name() string {
 	return "pod-to-local-nodeport"
 }
 
+func (s *podToLocalNodePort) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode}
+}
+
 func (s *podToLocalNodePort) Run(ctx context.Context, t *check.Test) {
 	var i int
 
@@ -246,6 +537,10 @@ func (s *outsideToNodePort) Name() string {
 	return "outside-to-nodeport"
 }
 
+func (s *outsideToNodePort) RequiredDeployments() []check.OptionalDeployment {
+	return []check.OptionalDeployment{check.DeployEchoOtherNode, check.DeployNodeWithoutCilium}
+}
+
 func (s *outsideToNodePort) Run(ctx context.Context, t *check.Test) {
 	clientPod := t.Context().HostNetNSPodsByNode()[t.NodesWithoutCilium()[0]]
 	i := 0
@@ -30,6 +30,10 @@ func (s *podToCIDR) Name() string {
 	return "pod-to-cidr"
 }
 
+func (s *podToCIDR) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
 func (s *podToCIDR) Run(ctx context.Context, t *check.Test) {
 	ct := t.Context()
 
@@ -29,6 +29,10 @@ func (s *podToWorld) Name() string {
 	return "pod-to-world"
 }
 
+func (s *podToWorld) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
 func (s *podToWorld) Run(ctx context.Context, t *check.Test) {
 	extTarget := t.Context().Params().ExternalTarget
 	http := check.HTTPEndpoint(extTarget+"-http", "http://"+extTarget)
@@ -71,6 +75,52 @@ func (s *podToWorld) Run(ctx context.Context, t *check.Test) {
 	}
 }
 
+// PodToExternalFQDN resolves ExternalFQDN from each client Pod, then sends an
+// HTTPS request to it, to check that external names are both resolvable and
+// reachable.
+func PodToExternalFQDN() check.Scenario {
+	return &podToExternalFQDN{}
+}
+
+// podToExternalFQDN implements a Scenario.
+type podToExternalFQDN struct{}
+
+func (s *podToExternalFQDN) Name() string {
+	return "pod-to-external-fqdn"
+}
+
+func (s *podToExternalFQDN) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
+func (s *podToExternalFQDN) Run(ctx context.Context, t *check.Test) {
+	ct := t.Context()
+	fqdn := ct.Params().ExternalFQDN
+	https := check.HTTPEndpoint(fqdn+"-https", "https://"+fqdn)
+
+	fp := check.FlowParameters{
+		DNSRequired: true,
+		RSTAllowed:  true,
+	}
+
+	var i int
+	for _, client := range ct.ClientPods() {
+		client := client // copy to avoid memory aliasing when using reference
+
+		t.NewAction(s, fmt.Sprintf("nslookup-%s-%d", fqdn, i), &client, https, check.IPFamilyAny).Run(func(a *check.Action) {
+			// BusyBox nslookup exits with an error if the name doesn't resolve.
+			a.ExecInPod(ctx, []string{"nslookup", fqdn})
+		})
+
+		t.NewAction(s, fmt.Sprintf("https-to-%s-%d", fqdn, i), &client, https, check.IPFamilyAny).Run(func(a *check.Action) {
+			a.ExecInPod(ctx, ct.CurlCommand(https, check.IPFamilyAny))
+			a.ValidateFlows(ctx, client, a.GetEgressRequirements(fp))
+		})
+
+		i++
+	}
+}
+
 // PodToWorld2 sends an HTTPS request to cilium.io from from random client
 // Pods.
 func PodToWorld2() check.Scenario {
@@ -84,6 +134,10 @@ func (s *podToWorld2) Name() string {
 	return "pod-to-world-2"
 }
 
+func (s *podToWorld2) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
 func (s *podToWorld2) Run(ctx context.Context, t *check.Test) {
 	https := check.HTTPEndpoint("cilium-io-https", "https://cilium.io")
 
@@ -128,6 +182,10 @@ func (s *podToWorldWithTLSIntercept) Name() string {
 	return "pod-to-world-with-tls-intercept"
 }
 
+func (s *podToWorldWithTLSIntercept) RequiredDeployments() []check.OptionalDeployment {
+	return nil
+}
+
 func (s *podToWorldWithTLSIntercept) Run(ctx context.Context, t *check.Test) {
 	extTarget := t.Context().Params().ExternalTarget
 
@@ -72,6 +72,62 @@ const (
 	ConnectivityPerformanceImage     = "quay.io/cilium/network-perf:a816f935930cb2b40ba43230643da4d5751a5711@sha256:679d3a370c696f63884da4557a4466f3b5569b4719bb4f86e8aac02fbe390eea"
 	ConnectivityCheckJSONMockImage   = "quay.io/cilium/json-mock:v1.3.5@sha256:d5dfd0044540cbe01ad6a1932cfb1913587f93cac4f145471ca04777f26342a4"
 	ConnectivityDNSTestServerImage   = "docker.io/coredns/coredns:1.10.0@sha256:017727efcfeb7d053af68e51436ce8e65edbc6ca573720afb4f79c8594036955"
+	// ConnectivityNetemImage is the image of the netem sidecar, which ships tc.
+	ConnectivityNetemImage = "quay.io/cilium/cilium-runtime:fe3fe058796057d2a089fac72a6a7afdf6b31435@sha256:d3f15d63ba73529963a3e9b5b2ff737f5638fc7a33819ac5380e72f2af7b4642"
+
+	// ConnectivityClientShell and ConnectivityPerformanceShell are the shells
+	// available in the default curl and performance images.
+	ConnectivityClientShell      = "/bin/ash"
+	ConnectivityPerformanceShell = "/bin/bash"
+
+	// ConnectivityPerformanceCPU and ConnectivityPerformanceMemory are the
+	// requests and limits of the perf pods when Guaranteed QoS is requested.
+	ConnectivityPerformanceCPU    = "1"
+	ConnectivityPerformanceMemory = "512Mi"
+
+	// ConnectivityPerformanceZoneWeight is the weight of the preferred zone
+	// affinity of the performance workloads.
+	ConnectivityPerformanceZoneWeight = 100
+
+	// ConnectivityNamespaceRetries is the number of times the creation of the
+	// test namespace is retried after a transient error.
+	ConnectivityNamespaceRetries = 3
+
+	// ConnectivityK8sClientQPS and ConnectivityK8sClientBurst are the rate
+	// limits of the Kubernetes clients used to deploy and validate the test
+	// resources, which are way above the client-go defaults of 5 and 10 as
+	// deploy issues many requests in a row.
+	ConnectivityK8sClientQPS   = 50
+	ConnectivityK8sClientBurst = 100
+
+	// ConnectivityProbeSuccessRatio is the minimum ratio of successful
+	// requests for the statistical probes enabled with --probe-count.
+	ConnectivityProbeSuccessRatio = 1.0
+
+	// ConnectivityEchoPort is the port the echo servers listen on.
+	ConnectivityEchoPort = 8080
+
+	// ConnectivityDNSTestServerReadyPort and ConnectivityDNSTestServerReadyPath
+	// match the defaults of the CoreDNS ready plugin.
+	ConnectivityDNSTestServerReadyPort = 8181
+	ConnectivityDNSTestServerReadyPath = "/ready"
+
+	// ConnectivityMaxParallelDeployments is the number of create requests the
+	// connectivity tests issue at once while deploying.
+	ConnectivityMaxParallelDeployments = 5
+
+	// ConnectivityDNSTestServerReadyTimeout and
+	// ConnectivityDNSTestServerReadyFailures are the timeout of each DNS test
+	// server readiness probe and the failures before it is marked unready.
+	ConnectivityDNSTestServerReadyTimeout  = 2 * time.Second
+	ConnectivityDNSTestServerReadyFailures = 3
+
+	// ConnectivityServiceDNSTarget is the name looked up from the client pods
+	// to validate that the cluster DNS is operational.
+	ConnectivityServiceDNSTarget = "kubernetes.default"
+
+	// ConnectivityClusterDomain is the default DNS domain of Kubernetes clusters.
+	ConnectivityClusterDomain = "cluster.local"
 
 	ConfigMapName = "cilium-config"
 	Version       = "v1.13.2"
@@ -94,6 +150,16 @@ const (
 
 	UninstallTimeout = 5 * time.Minute
 
+	IPCacheTimeout     = 20 * time.Second
+	IPCacheExecTimeout = 10 * time.Second
+	IPCacheExecRetries = 3
+	IPCacheInterval    = time.Second
+
+	DeploymentPollInterval      = time.Second
+	NamespaceDeletePollInterval = time.Second
+
+	ConnectivitySuiteCleanupTimeout = 5 * time.Minute
+
 	IngressClassName        = "cilium"
 	IngressService          = "cilium-ingress"
 	IngressControllerName   = "cilium.io/ingress-controller"
@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 
 	"github.com/cilium/cilium-cli/connectivity"
 	"github.com/cilium/cilium-cli/connectivity/check"
@@ -45,6 +47,7 @@ var params = check.Parameters{
 	},
 }
 var tests []string
+var printConfig string
 
 func newCmdConnectivityTest() *cobra.Command {
 	cmd := &cobra.Command{
@@ -76,6 +79,10 @@ func newCmdConnectivityTest() *cobra.Command {
 				return err
 			}
 
+			if printConfig != "" {
+				return printParams(cc.Params(), printConfig)
+			}
+
 			ctx, _ := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
 			go func() {
@@ -110,10 +117,44 @@ func newCmdConnectivityTest() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVar(&printConfig, "print-config", "", "Print the effective test parameters in the given format (json or yaml) and exit without deploying")
 	cmd.Flags().BoolVar(&params.SingleNode, "single-node", false, "Limit to tests able to run on a single node")
+	cmd.Flags().BoolVar(&params.Minimal, "minimal", false, "Deploy only one client and one echo server and run basic reachability tests")
 	cmd.Flags().BoolVar(&params.PrintFlows, "print-flows", false, "Print flow logs for each test")
 	cmd.Flags().DurationVar(&params.PostTestSleepDuration, "post-test-sleep", 0, "Wait time after each test before next test starts")
+	cmd.Flags().DurationVar(&params.LogSince, "log-since", 0, "Only dump the Cilium agent logs of the last given duration when a test fails, instead of all the logs since the test started")
 	cmd.Flags().BoolVar(&params.ForceDeploy, "force-deploy", false, "Force re-deploying test artifacts")
+	cmd.Flags().BoolVar(&params.VerifyCleanup, "verify-cleanup", false, "When deleting test artifacts, wait for the CiliumEndpoints and Cilium agent endpoints of the test pods to be deleted, and fail if they persist")
+	cmd.Flags().BoolVar(&params.DeleteNamespaceFirst, "delete-namespace-first", false, "When deleting test artifacts, only delete the test namespace and let it garbage collect its resources instead of deleting them one by one first")
+	cmd.Flags().Float32Var(&params.K8sClientQPS, "k8s-client-qps", defaults.ConnectivityK8sClientQPS, "Maximum QPS of the Kubernetes clients used to deploy and validate the test resources (0 for the client-go default)")
+	cmd.Flags().IntVar(&params.K8sClientBurst, "k8s-client-burst", defaults.ConnectivityK8sClientBurst, "Maximum burst of the Kubernetes clients used to deploy and validate the test resources (0 for the client-go default)")
+	cmd.Flags().StringVar(&params.ValidationKubeconfig, "validation-kubeconfig", "", "Kubeconfig of a separate, e.g. read-only, identity for the read requests validating the test deployments")
+	cmd.Flags().StringVar(&params.ValidationContext, "validation-context", "", "Kubernetes context of a separate, e.g. read-only, identity for the read requests validating the test deployments")
+	cmd.Flags().StringVar(&params.ValidationAs, "validation-as", "", "User to impersonate for the read requests validating the test deployments")
+	cmd.Flags().StringSliceVar(&params.ValidationAsGroups, "validation-as-group", nil, "Group to impersonate for the read requests validating the test deployments, can be repeated. Requires --validation-as")
+	cmd.Flags().BoolVar(&params.StrictLeftovers, "strict-leftovers", false, "Fail instead of warning if test deployments from a previous run are found and neither --force-deploy nor --reconcile is set")
+	cmd.Flags().BoolVar(&params.StrictImages, "strict-images", false, "Fail instead of warning if a client or echo pod doesn't run the configured image, e.g. because of a mutating webhook or a stale deployment")
+	cmd.Flags().BoolVar(&params.Reconcile, "reconcile", false, "Update existing test deployments, daemonsets, services, configmaps and ingresses whose spec drifted from the expected one")
+	cmd.Flags().DurationVar(&params.NetemLatency, "netem-latency", 0, "Latency added to the egress traffic of the netem target pods")
+	cmd.Flags().Float64Var(&params.NetemLoss, "netem-loss", 0, "Percentage of the egress packets of the netem target pods to drop")
+	cmd.Flags().StringVar(&params.NetemTarget, "netem-target", "echo", "Pods whose network is degraded by --netem-latency and --netem-loss: client or echo")
+	cmd.Flags().StringVar(&params.NetemImage, "netem-image", defaults.ConnectivityNetemImage, "Image path of the netem and connection counter sidecars, which must ship tc, iptables and a POSIX shell")
+	cmd.Flags().BoolVar(&params.WaitCEPAddressing, "wait-endpoint-addressing", false, "Wait for the CiliumEndpoints of the test pods to report their addressing, not only to exist")
+	cmd.Flags().StringVar(&params.ServiceIPFamily, "service-ip-family", "", "Create single-stack echo services of the given IP family (ipv4 or ipv6) instead of preferring dual-stack")
+	cmd.Flags().StringVar(&params.ExpectRoutingMode, "expect-routing-mode", "", "Fail if the Cilium routing mode differs: native, tunnel, vxlan or geneve")
+	cmd.Flags().StringVar((*string)(&params.NodeHealthCheck), "node-health-check", string(check.NodeHealthCheckModeDisabled), "Check the node-to-node connectivity reported by cilium-health before running the tests { disabled | warning | strict }")
+	cmd.Flags().StringVar((*string)(&params.MTUCheck), "mtu-check", string(check.MTUCheckModeDisabled), "Compare the device MTU computed by Cilium on each node before running the tests { disabled | warning | strict }")
+	cmd.Flags().BoolVar(&params.ClientDaemonSet, "client-daemonset", false, "Additionally deploy a client pod on each node, to run the tests from every node")
+	cmd.Flags().StringVar(&params.ClientSelector, "client-selector", "kind=client", "Label selector of the client pods the tests are run from")
+	cmd.Flags().StringVar(&params.ClientSource, "client-source", "client", "Pods the pod-to-service requests originate from: client, or host-netns to exercise the host network to service datapath")
+	cmd.Flags().BoolVar(&params.NoAutomountSAToken, "no-automount-service-account-token", false, "Do not mount service account tokens into the test pods")
+	cmd.Flags().BoolVar(&params.ProbeFromAllClients, "probe-from-all-clients", false, "Wait for services and NodePorts to be reachable from every client pod instead of from a random one")
+	cmd.Flags().BoolVar(&params.NodePortAllAddresses, "nodeport-all-addresses", false, "Wait for NodePorts to be reachable on the node address of each IP family of the service, e.g. IPv4 and IPv6 on dual-stack clusters, instead of only on the host IP")
+	cmd.Flags().StringVar(&params.SeccompProfile, "seccomp-profile", "", "Seccomp profile of the test pods, for clusters enforcing restricted Pod Security Admission: RuntimeDefault, Unconfined or Localhost/<path>")
+	cmd.Flags().StringVar(&params.ServiceAccount, "service-account", "", "Run all test pods as this existing ServiceAccount of the test namespace instead of creating one per deployment, e.g. to inherit its imagePullSecrets")
+	cmd.Flags().BoolVar(&params.NoHostNetNSNetRaw, "host-netns-no-net-raw", false, "Do not grant NET_RAW to the host-netns pods, for clusters enforcing restricted Pod Security Admission. Skips the encryption tests, which run tcpdump in them")
+	cmd.Flags().BoolVar(&params.PrePullImages, "pre-pull-images", false, "Pull the test images onto the nodes with a temporary daemonset before deploying the test workloads")
+	cmd.Flags().BoolVar(&params.VerboseDeploy, "verbose-deploy", false, "Log a summary of each created test resource, and its full manifest if the creation fails")
 	cmd.Flags().BoolVar(&params.Hubble, "hubble", true, "Automatically use Hubble for flow validation & troubleshooting")
 	cmd.Flags().StringVar(&params.HubbleServer, "hubble-server", "localhost:4245", "Address of the Hubble endpoint for flow validation")
 	cmd.Flags().StringVar(&params.TestNamespace, "test-namespace", defaults.ConnectivityCheckNamespace, "Namespace to perform the connectivity test in")
@@ -121,6 +162,10 @@ func newCmdConnectivityTest() *cobra.Command {
 	cmd.Flags().StringVar(&params.AgentPodSelector, "agent-pod-selector", defaults.AgentPodSelector, "Label on cilium-agent pods to select with")
 	cmd.Flags().StringToStringVar(&params.NodeSelector, "node-selector", map[string]string{}, "Restrict connectivity test pods to nodes matching this label")
 	cmd.Flags().StringVar(&params.MultiCluster, "multi-cluster", "", "Test across clusters to given context")
+	cmd.Flags().IntVar(&params.MultiClusterDeployConcurrency, "multi-cluster-deploy-concurrency", 0, "Number of clusters the test namespace, DNS configmap, image pre-pulling and test workloads are deployed to at once with --multi-cluster (defaults to all of them)")
+	cmd.Flags().IntVar(&params.MaxParallelDeployments, "max-parallel-deployments", defaults.ConnectivityMaxParallelDeployments, "Maximum number of test resources created at once while deploying, across all clusters")
+	cmd.Flags().DurationVar(&params.MultiClusterDeployTimeout, "multi-cluster-deploy-timeout", 0, "Timeout of each of these deployment steps in each cluster with --multi-cluster (defaults to no timeout)")
+	cmd.Flags().BoolVar(&params.MultiClusterBidirectional, "multi-cluster-bidirectional", false, "Also deploy a client in the --multi-cluster cluster and make the echo-same-node service global, to test in both directions")
 	cmd.Flags().StringSliceVar(&tests, "test", []string{}, "Run tests that match one of the given regular expressions, skip tests by starting the expression with '!', target Scenarios with e.g. '/pod-to-cidr'")
 	cmd.Flags().StringVar(&params.FlowValidation, "flow-validation", check.FlowValidationModeWarning, "Enable Hubble flow validation { disabled | warning | strict }")
 	cmd.Flags().BoolVar(&params.AllFlows, "all-flows", false, "Print all flows during flow validation")
@@ -130,13 +175,48 @@ func newCmdConnectivityTest() *cobra.Command {
 	cmd.Flags().BoolVarP(&params.Timestamp, "timestamp", "t", false, "Show timestamp in messages")
 	cmd.Flags().BoolVarP(&params.PauseOnFail, "pause-on-fail", "p", false, "Pause execution on test failure")
 	cmd.Flags().StringVar(&params.ExternalTarget, "external-target", "one.one.one.one", "Domain name to use as external target in connectivity tests")
+	cmd.Flags().StringVar(&params.ExternalFQDN, "external-fqdn", "", "Resolve and connect over HTTPS to this external domain name from the client pods under a toFQDNs policy (skipped if empty)")
 	cmd.Flags().StringVar(&params.ExternalCIDR, "external-cidr", "1.0.0.0/8", "CIDR to use as external target in connectivity tests")
 	cmd.Flags().StringVar(&params.ExternalIP, "external-ip", "1.1.1.1", "IP to use as external target in connectivity tests")
 	cmd.Flags().StringVar(&params.ExternalOtherIP, "external-other-ip", "1.0.0.1", "Other IP to use as external target in connectivity tests")
+	cmd.Flags().BoolVar(&params.ExternalNameService, "external-name-service", false, "Create an ExternalName service pointing at --external-target and test its resolution from the client pods")
+	cmd.Flags().StringVar(&params.ExpectedEgressIP, "expected-egress-ip", "", "Source IP the echo server on the node without Cilium must observe for traffic from the client pods, e.g. the IP of an egress gateway")
 	cmd.Flags().StringSliceVar(&params.ExternalFromCIDRs, "external-from-cidrs", []string{}, "CIDRs representing nodes without Cilium to be used in connectivity tests")
+	cmd.Flags().StringSliceVar(&params.ExpectedPodCIDRs, "expected-pod-cidr", nil, "Check that the IPs of the client and echo pods fall within the given pod CIDR, can be repeated, e.g. for dual-stack. Pod IPs of a family without an expected CIDR aren't checked")
 	cmd.Flags().StringVar(&params.JunitFile, "junit-file", "", "Generate junit report and write to file")
+	cmd.Flags().StringVar(&params.TopologyFile, "topology-file", "", "Write the deployed test topology as a Graphviz DOT graph to file")
+	cmd.Flags().StringVar(&params.CreatedResourcesFile, "created-resources-file", "", "Write the kind, namespace, name and cluster of each resource created on deploy as JSON to file, updated as they are created")
+	cmd.Flags().StringVar(&params.MetricsFile, "metrics-file", "", "Write the duration of each deployment and validation step in the Prometheus text format to file")
+	cmd.Flags().StringVar(&params.CiliumConfigFile, "collect-cilium-config", "", "Write the Cilium ConfigMap and agent runtime configuration as JSON to file before running the tests")
 	cmd.Flags().BoolVar(&params.SkipIPCacheCheck, "skip-ip-cache-check", true, "Skip IPCache check")
 	cmd.Flags().MarkHidden("skip-ip-cache-check")
+	cmd.Flags().DurationVar(&params.IPCacheTimeout, "ipcache-timeout", defaults.IPCacheTimeout, "Maximum time to wait for all pod IPs to appear in the Cilium ipcache")
+	cmd.Flags().DurationVar(&params.IPCacheInterval, "ipcache-interval", defaults.IPCacheInterval, "Interval between ipcache validation attempts")
+	cmd.Flags().DurationVar(&params.DeploymentPollInterval, "deployment-poll-interval", defaults.DeploymentPollInterval, "Interval between readiness checks of the test deployments, services and DNS")
+	cmd.Flags().DurationVar(&params.ServicePollInterval, "service-poll-interval", 0, "Initial interval between service lookups (defaults to --deployment-poll-interval)")
+	cmd.Flags().DurationVar(&params.ServicePollMaxInterval, "service-poll-max-interval", 0, "Ceiling of the doubling interval between failed service lookups (defaults to no backoff)")
+	cmd.Flags().DurationVar(&params.NamespaceDeletePollInterval, "namespace-delete-poll-interval", defaults.NamespaceDeletePollInterval, "Initial interval between checks that the test namespace is deleted during cleanup")
+	cmd.Flags().DurationVar(&params.NamespaceDeletePollMaxInterval, "namespace-delete-poll-max-interval", 0, "Ceiling of the doubling interval between checks that the test namespace is deleted (defaults to no backoff)")
+	cmd.Flags().DurationVar(&params.SuiteTimeout, "suite-timeout", 0, "Maximum time to deploy and validate the test workloads, after which they are deleted and the run aborted (defaults to no timeout)")
+	cmd.Flags().DurationVar(&params.SuiteCleanupTimeout, "suite-cleanup-timeout", defaults.ConnectivitySuiteCleanupTimeout, "Maximum time to delete the test workloads after --suite-timeout expired")
+	cmd.Flags().DurationVar(&params.NamespaceDeleteTimeout, "namespace-delete-timeout", 0, "Maximum time to wait for the test namespace to be deleted during cleanup (defaults to no timeout)")
+	cmd.Flags().IntVar(&params.ServiceMaxAttempts, "service-max-attempts", 0, "Maximum number of lookups per service before giving up (0 for no limit)")
+	cmd.Flags().StringVar(&params.PodReadinessCondition, "pod-readiness-condition", "", "Additional pod condition which must be True for the test pods to be considered ready, e.g. set by a readiness gate")
+	cmd.Flags().DurationVar(&params.DeploymentRolloutGrace, "deployment-rollout-grace", 0, "Time a test deployment's rollout must stay complete before it is considered ready")
+	cmd.Flags().IntVar(&params.ProbeCount, "probe-count", 0, "Send this many requests from each client pod to each echo pod to detect intermittent packet loss (0 to disable)")
+	cmd.Flags().Float64Var(&params.ProbeSuccessRatio, "probe-success-ratio", defaults.ConnectivityProbeSuccessRatio, "Minimum ratio of successful requests for the probes enabled with --probe-count")
+	cmd.Flags().IntVar(&params.PingCount, "ping-count", 0, "Send this many ICMP echo requests from each client pod to each echo pod to validate the ICMP datapath (0 to disable)")
+	cmd.Flags().Float64Var(&params.PingMaxLoss, "ping-max-loss", 0, "Maximum percentage of lost ICMP echo requests for the pings enabled with --ping-count")
+	cmd.Flags().IntVar(&params.EchoStatusCode, "echo-status-code", 0, "Add a sidecar to the echo pods which answers with this HTTP status on port 8082, and test that clients see it through the L7 proxy")
+	cmd.Flags().BoolVar(&params.EchoConnectionCounter, "echo-connection-counter", false, "Add a sidecar running --netem-image to the echo pods which counts the connections to the echo server with iptables")
+	cmd.Flags().StringVar(&params.EchoLBAlgorithm, "echo-lb-algorithm", "", "Cilium load-balancing algorithm to request on the echo services via annotation { maglev | random }")
+	cmd.Flags().StringVar(&params.EchoLBClass, "echo-lb-class", "", "Create the echo services as type LoadBalancer with the given load balancer class, e.g. io.cilium/l2-announcer, to select the controller handling them")
+	cmd.Flags().BoolVar(&params.EchoLBService, "echo-lb-service", false, "Create a service selecting the echo pods on all nodes and check that it balances connections across them. Requires --echo-connection-counter")
+	cmd.Flags().BoolVar(&params.EchoTopologyHints, "echo-topology-hints", false, "Deploy an echo pod in each zone behind a service with topology-aware hints and check that connections stay in the zone of the client. Requires --echo-connection-counter")
+	cmd.Flags().StringVar(&params.ClientNetworks, "client-networks", "", "Attach the client pods to additional networks through the Multus "+
+		"k8s.v1.cni.cncf.io/networks annotation, e.g. macvlan-conf, and ping the other client pods over them")
+	cmd.Flags().BoolVar(&params.EchoTLS, "echo-tls", false, "Deploy an echo pod serving HTTPS with a self-signed certificate and curl it over HTTPS")
+	cmd.Flags().BoolVar(&params.SkipExternalWorkloads, "skip-external-workloads", false, "Skip listing CiliumExternalWorkloads and disable external workload tests")
 	cmd.Flags().BoolVar(&params.Datapath, "datapath", false, "Run datapath conformance tests")
 	cmd.Flags().MarkHidden("datapath")
 
@@ -151,17 +231,43 @@ func newCmdConnectivityTest() *cobra.Command {
 	cmd.Flags().IntVar(&params.PerfSamples, "perf-samples", 1, "Number of Performance samples to capture (how many times to run each test)")
 	cmd.Flags().BoolVar(&params.PerfCRR, "perf-crr", false, "Run Netperf CRR Test. --perf-samples and --perf-duration ignored")
 	cmd.Flags().BoolVar(&params.PerfHostNet, "host-net", false, "Use host networking during network performance tests")
+	cmd.Flags().BoolVar(&params.PerfGuaranteedQoS, "perf-guaranteed-qos", false, "Set equal CPU and memory requests and limits on the performance test pods to get Guaranteed QoS")
+	cmd.Flags().IntVar(&params.PerfZoneWeight, "perf-zone-weight", defaults.ConnectivityPerformanceZoneWeight, "Weight of the preferred zone affinity of the performance test pods")
+	cmd.Flags().BoolVar(&params.PerfZoneRequired, "perf-zone-required", false, "Require instead of prefer scheduling the performance test pods in the same zone, leaving them pending if it is not possible")
+	cmd.Flags().BoolVar(&params.StrictPerfZone, "strict-perf-zone", false, "Fail instead of warning if the performance test pods are scheduled in different zones")
 
 	cmd.Flags().StringVar(&params.CurlImage, "curl-image", defaults.ConnectivityCheckAlpineCurlImage, "Image path to use for curl")
 	cmd.Flags().StringVar(&params.PerformanceImage, "performance-image", defaults.ConnectivityPerformanceImage, "Image path to use for performance")
+	cmd.Flags().StringToStringVar(&params.NamespaceLabels, "namespace-labels", map[string]string{}, "Labels added to the test namespace if an admission webhook rejects its creation or deletion")
+	cmd.Flags().StringToStringVar(&params.NamespaceAnnotations, "namespace-annotations", map[string]string{}, "Annotations added to the test namespace if an admission webhook rejects its creation or deletion")
+	cmd.Flags().IntVar(&params.NamespaceRetries, "namespace-retries", defaults.ConnectivityNamespaceRetries, "Number of times the creation of the test namespace is retried after a transient error, e.g. an admission webhook timeout")
+	cmd.Flags().StringToStringVar(&params.HostNetNSImages, "host-netns-image", map[string]string{}, "Per-architecture image for the host-netns pods, e.g. arm64=<image>. Nodes of other architectures use --curl-image")
+	cmd.Flags().StringSliceVar(&params.HostNetNSNodes, "host-netns-nodes", nil, "Only run the host-netns pods on the given nodes instead of on all nodes. Tests needing a host-netns pod on other nodes will not find one")
 	cmd.Flags().StringVar(&params.JSONMockImage, "json-mock-image", defaults.ConnectivityCheckJSONMockImage, "Image path to use for json mock")
+	cmd.Flags().StringVar(&params.EchoSameNodeImage, "echo-same-node-image", "", "Image path to use for the echo-same-node deployment (defaults to --json-mock-image)")
+	cmd.Flags().StringVar(&params.EchoOtherNodeImage, "echo-other-node-image", "", "Image path to use for the echo-other-node deployment (defaults to --json-mock-image)")
 	cmd.Flags().StringVar(&params.DNSTestServerImage, "dns-test-server-image", defaults.ConnectivityDNSTestServerImage, "Image path to use for CoreDNS")
+	cmd.Flags().StringVar(&params.DNSConfigMap, "dns-configmap", "", "Name of an existing configmap holding the Corefile of the DNS test server, used instead of the default one")
+	cmd.Flags().StringVar(&params.DNSConfigMapNamespace, "dns-configmap-namespace", "", "Namespace of the --dns-configmap, which is copied into the test namespace. Defaults to the test namespace, which must then exist and can not be combined with --force-deploy")
+	cmd.Flags().IntVar(&params.EchoPort, "echo-port", defaults.ConnectivityEchoPort, "Port the echo servers listen on, used for their container port, environment, readiness probe and the policy tests")
+	cmd.Flags().IntVar(&params.DNSTestServerReadyPort, "dns-test-server-ready-port", defaults.ConnectivityDNSTestServerReadyPort, "Port of the CoreDNS ready endpoint used by the DNS test server readiness probe")
+	cmd.Flags().StringVar(&params.DNSTestServerReadyPath, "dns-test-server-ready-path", defaults.ConnectivityDNSTestServerReadyPath, "HTTP path of the CoreDNS ready endpoint used by the DNS test server readiness probe")
+	cmd.Flags().DurationVar(&params.DNSTestServerReadyTimeout, "dns-test-server-ready-timeout", defaults.ConnectivityDNSTestServerReadyTimeout, "Timeout of each DNS test server readiness probe")
+	cmd.Flags().IntVar(&params.DNSTestServerReadyFailures, "dns-test-server-ready-failures", defaults.ConnectivityDNSTestServerReadyFailures, "Number of failed DNS test server readiness probes before the sidecar is marked unready")
+	cmd.Flags().StringVar(&params.ClusterDomain, "cluster-domain", defaults.ConnectivityClusterDomain, "DNS domain of the cluster, used to build the service FQDNs")
+	cmd.Flags().StringVar(&params.ServiceDNSTarget, "service-dns-target", defaults.ConnectivityServiceDNSTarget, "Name resolved from the client pods to validate that the cluster DNS is operational")
+	cmd.Flags().StringVar(&params.ClientShell, "client-shell", defaults.ConnectivityClientShell, "Shell available in the curl image, used to run the client pods")
+	cmd.Flags().StringVar(&params.PerfShell, "perf-shell", defaults.ConnectivityPerformanceShell, "Shell available in the performance image, used to run the performance pods")
 
 	cmd.Flags().UintVar(&params.Retry, "retry", defaults.ConnectRetry, "Number of retries on connection failure to external targets")
 	cmd.Flags().DurationVar(&params.RetryDelay, "retry-delay", defaults.ConnectRetryDelay, "Delay between retries for external targets")
 
 	cmd.Flags().DurationVar(&params.ConnectTimeout, "connect-timeout", defaults.ConnectTimeout, "Maximum time to allow initiation of the connection to take")
 	cmd.Flags().DurationVar(&params.RequestTimeout, "request-timeout", defaults.RequestTimeout, "Maximum time to allow a request to take")
+	cmd.Flags().StringArrayVar(&params.CurlHeaders, "curl-header", nil, "Header to add to the requests sent to the echo backends, in the \"Name: value\" format (can be repeated)")
+	cmd.Flags().StringVar(&params.CurlMethod, "curl-method", "", "HTTP method of the requests sent to the echo backends")
+	cmd.Flags().StringVar(&params.CurlPath, "curl-path", "", "Path of the requests sent to the echo backends")
+	cmd.Flags().StringVar(&params.CurlUserAgent, "curl-user-agent", "", "User agent of the requests sent to the echo backends")
 
 	cmd.Flags().BoolVar(&params.CollectSysdumpOnFailure, "collect-sysdump-on-failure", false, "Collect sysdump after a test fails")
 
@@ -169,3 +275,24 @@ func newCmdConnectivityTest() *cobra.Command {
 
 	return cmd
 }
+
+// printParams prints the effective connectivity test parameters in the given
+// format.
+func printParams(p check.Parameters, format string) error {
+	var out []byte
+	var err error
+	switch format {
+	case "json":
+		out, err = json.MarshalIndent(p, "", "  ")
+		out = append(out, '\n')
+	case "yaml":
+		out, err = yaml.Marshal(p)
+	default:
+		return fmt.Errorf("invalid config format %q, must be json or yaml", format)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to marshal the parameters: %w", err)
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
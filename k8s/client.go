@@ -22,6 +22,7 @@ import (
 	"helm.sh/helm/v3/pkg/chartutil"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -88,6 +89,14 @@ func NewClient(contextName, kubeconfig string) (*Client, error) {
 		return nil, err
 	}
 
+	if contextName == "" {
+		contextName = rawConfig.CurrentContext
+	}
+
+	return newClientForConfig(config, rawConfig, &restClientGetter, contextName)
+}
+
+func newClientForConfig(config *rest.Config, rawConfig clientcmdapi.Config, restClientGetter genericclioptions.RESTClientGetter, contextName string) (*Client, error) {
 	ciliumClientset, err := ciliumClientset.NewForConfig(config)
 	if err != nil {
 		return nil, err
@@ -113,10 +122,6 @@ func NewClient(contextName, kubeconfig string) (*Client, error) {
 		return nil, err
 	}
 
-	if contextName == "" {
-		contextName = rawConfig.CurrentContext
-	}
-
 	return &Client{
 		CiliumClientset:    ciliumClientset,
 		TetragonClientset:  tetragonClientset,
@@ -125,11 +130,36 @@ func NewClient(contextName, kubeconfig string) (*Client, error) {
 		Config:             config,
 		DynamicClientset:   dynamicClientset,
 		RawConfig:          rawConfig,
-		RESTClientGetter:   &restClientGetter,
+		RESTClientGetter:   restClientGetter,
 		contextName:        contextName,
 	}, nil
 }
 
+// WithRateLimit returns a copy of the client whose requests to the API server
+// are client-side throttled to the given QPS and burst instead of the
+// client-go defaults. A zero value keeps the current setting.
+func (c *Client) WithRateLimit(qps float32, burst int) (*Client, error) {
+	config := rest.CopyConfig(c.Config)
+	if qps != 0 {
+		config.QPS = qps
+	}
+	if burst != 0 {
+		config.Burst = burst
+	}
+	return newClientForConfig(config, c.RawConfig, c.RESTClientGetter, c.contextName)
+}
+
+// WithImpersonation returns a copy of the client whose requests to the API
+// server impersonate the given user and groups.
+func (c *Client) WithImpersonation(user string, groups []string) (*Client, error) {
+	config := rest.CopyConfig(c.Config)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: user,
+		Groups:   groups,
+	}
+	return newClientForConfig(config, c.RawConfig, c.RESTClientGetter, c.contextName)
+}
+
 // ContextName returns the name of the context the client is connected to
 func (c *Client) ContextName() (name string) {
 	return c.contextName
@@ -177,6 +207,10 @@ func (c *Client) GetSecret(ctx context.Context, namespace, name string, opts met
 	return c.Clientset.CoreV1().Secrets(namespace).Get(ctx, name, opts)
 }
 
+func (c *Client) GetServiceAccount(ctx context.Context, namespace, name string, opts metav1.GetOptions) (*corev1.ServiceAccount, error) {
+	return c.Clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, name, opts)
+}
+
 func (c *Client) CreateServiceAccount(ctx context.Context, namespace string, account *corev1.ServiceAccount, opts metav1.CreateOptions) (*corev1.ServiceAccount, error) {
 	return c.Clientset.CoreV1().ServiceAccounts(namespace).Create(ctx, account, opts)
 }
@@ -245,6 +279,10 @@ func (c *Client) CreateService(ctx context.Context, namespace string, service *c
 	return c.Clientset.CoreV1().Services(namespace).Create(ctx, service, opts)
 }
 
+func (c *Client) UpdateService(ctx context.Context, namespace string, service *corev1.Service, opts metav1.UpdateOptions) (*corev1.Service, error) {
+	return c.Clientset.CoreV1().Services(namespace).Update(ctx, service, opts)
+}
+
 func (c *Client) DeleteService(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
 	return c.Clientset.CoreV1().Services(namespace).Delete(ctx, name, opts)
 }
@@ -261,6 +299,10 @@ func (c *Client) GetEndpoints(ctx context.Context, namespace, name string, opts
 	return c.Clientset.CoreV1().Endpoints(namespace).Get(ctx, name, opts)
 }
 
+func (c *Client) ListEndpointSlices(ctx context.Context, namespace string, opts metav1.ListOptions) (*discoveryv1.EndpointSliceList, error) {
+	return c.Clientset.DiscoveryV1().EndpointSlices(namespace).List(ctx, opts)
+}
+
 func (c *Client) DeleteEndpoints(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
 	return c.Clientset.CoreV1().Endpoints(namespace).Delete(ctx, name, opts)
 }
@@ -273,10 +315,18 @@ func (c *Client) GetDeployment(ctx context.Context, namespace, name string, opts
 	return c.Clientset.AppsV1().Deployments(namespace).Get(ctx, name, opts)
 }
 
+func (c *Client) ListDeployments(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.DeploymentList, error) {
+	return c.Clientset.AppsV1().Deployments(namespace).List(ctx, opts)
+}
+
 func (c *Client) DeleteDeployment(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
 	return c.Clientset.AppsV1().Deployments(namespace).Delete(ctx, name, opts)
 }
 
+func (c *Client) UpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment, opts metav1.UpdateOptions) (*appsv1.Deployment, error) {
+	return c.Clientset.AppsV1().Deployments(namespace).Update(ctx, deployment, opts)
+}
+
 func (c *Client) PatchDeployment(ctx context.Context, namespace, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*appsv1.Deployment, error) {
 	return c.Clientset.AppsV1().Deployments(namespace).Patch(ctx, name, pt, data, opts)
 }
@@ -315,10 +365,44 @@ func (c *Client) CheckDeploymentStatus(ctx context.Context, namespace, deploymen
 	return nil
 }
 
+func (c *Client) CheckDaemonSetStatus(ctx context.Context, namespace, daemonSet string) error {
+	ds, err := c.GetDaemonSet(ctx, namespace, daemonSet, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if ds.Status.ObservedGeneration != ds.Generation {
+		return fmt.Errorf("observed generation (%d) is older than generation of the desired state (%d)",
+			ds.Status.ObservedGeneration, ds.Generation)
+	}
+
+	if ds.Status.DesiredNumberScheduled == 0 {
+		return fmt.Errorf("desired number of scheduled pods is zero")
+	}
+
+	if ds.Status.UpdatedNumberScheduled != ds.Status.DesiredNumberScheduled {
+		return fmt.Errorf("only %d of %d pods are up-to-date", ds.Status.UpdatedNumberScheduled, ds.Status.DesiredNumberScheduled)
+	}
+
+	if ds.Status.NumberReady != ds.Status.DesiredNumberScheduled {
+		return fmt.Errorf("only %d of %d pods are ready", ds.Status.NumberReady, ds.Status.DesiredNumberScheduled)
+	}
+
+	return nil
+}
+
 func (c *Client) CreateNamespace(ctx context.Context, namespace string, opts metav1.CreateOptions) (*corev1.Namespace, error) {
 	return c.Clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}, opts)
 }
 
+func (c *Client) CreateNamespaceObject(ctx context.Context, namespace *corev1.Namespace, opts metav1.CreateOptions) (*corev1.Namespace, error) {
+	return c.Clientset.CoreV1().Namespaces().Create(ctx, namespace, opts)
+}
+
+func (c *Client) PatchNamespace(ctx context.Context, namespace string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*corev1.Namespace, error) {
+	return c.Clientset.CoreV1().Namespaces().Patch(ctx, namespace, pt, data, opts)
+}
+
 func (c *Client) GetNamespace(ctx context.Context, namespace string, options metav1.GetOptions) (*corev1.Namespace, error) {
 	return c.Clientset.CoreV1().Namespaces().Get(ctx, namespace, options)
 }
@@ -480,6 +564,10 @@ func (c *Client) CreateDaemonSet(ctx context.Context, namespace string, ds *apps
 	return c.Clientset.AppsV1().DaemonSets(namespace).Create(ctx, ds, opts)
 }
 
+func (c *Client) UpdateDaemonSet(ctx context.Context, namespace string, ds *appsv1.DaemonSet, opts metav1.UpdateOptions) (*appsv1.DaemonSet, error) {
+	return c.Clientset.AppsV1().DaemonSets(namespace).Update(ctx, ds, opts)
+}
+
 func (c *Client) PatchDaemonSet(ctx context.Context, namespace, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*appsv1.DaemonSet, error) {
 	return c.Clientset.AppsV1().DaemonSets(namespace).Patch(ctx, name, pt, data, opts)
 }
@@ -1003,6 +1091,10 @@ func (c *Client) CreateIngress(ctx context.Context, namespace string, ingress *n
 	return c.Clientset.NetworkingV1().Ingresses(namespace).Create(ctx, ingress, opts)
 }
 
+func (c *Client) UpdateIngress(ctx context.Context, namespace string, ingress *networkingv1.Ingress, opts metav1.UpdateOptions) (*networkingv1.Ingress, error) {
+	return c.Clientset.NetworkingV1().Ingresses(namespace).Update(ctx, ingress, opts)
+}
+
 func (c *Client) DeleteIngressClass(ctx context.Context, name string, opts metav1.DeleteOptions) error {
 	return c.Clientset.NetworkingV1().IngressClasses().Delete(ctx, name, opts)
 }